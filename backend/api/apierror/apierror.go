@@ -7,6 +7,9 @@ type AppError struct {
 	Message    string
 	HTTPStatus int
 	IsUserError bool
+	// Current is the latest state of the resource, sent back with a version
+	// conflict so the client can merge without another request.
+	Current any
 }
 
 func (e *AppError) Error() string {
@@ -64,6 +67,17 @@ func WrongCurrentPassword() *AppError {
 	return NewUserError(ErrWrongCurrentPassword, "Le mot de passe actuel est incorrect.", http.StatusUnauthorized)
 }
 
+func VersionConflict(current any) *AppError {
+	err := NewUserError(ErrVersionConflict, "La ressource a été modifiée entre-temps, rechargez-la avant de réessayer.", http.StatusConflict)
+	err.Current = current
+	return err
+}
+
+// SetlistLocked rejects a change to the items of a setlist locked by an admin.
+func SetlistLocked() *AppError {
+	return NewUserError(ErrSetlistLocked, "Cette setlist est verrouillée : un administrateur doit la déverrouiller avant toute modification.", http.StatusLocked)
+}
+
 func InternalError(operation string) *AppError {
 	return NewServerError(ErrInternal, "Une erreur interne s'est produite lors de: "+operation)
 }
@@ -78,5 +92,7 @@ const (
 	ErrNotFound            = "NOT_FOUND"
 	ErrInvalidRefreshToken = "INVALID_REFRESH_TOKEN"
 	ErrWrongCurrentPassword = "WRONG_CURRENT_PASSWORD"
+	ErrVersionConflict     = "VERSION_CONFLICT"
+	ErrSetlistLocked       = "SETLIST_LOCKED"
 	ErrInternal            = "INTERNAL_ERROR"
 )
@@ -37,6 +37,27 @@ func (h AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) error
 	return nil
 }
 
+// CreateStreamToken returns a short-lived token for the event streams of the
+// current band, which EventSource passes in the URL instead of headers.
+func (h AuthHandler) CreateStreamToken(w http.ResponseWriter, r *http.Request) error {
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	response, err := h.AuthService.CreateStreamToken(userID, bandID)
+	if err != nil {
+		return apierror.InternalError("création du jeton de flux")
+	}
+
+	RespondOK(w, response)
+	return nil
+}
+
 func (h AuthHandler) Logout(w http.ResponseWriter, r *http.Request) error {
 	payload, err := DecodeJSON[RefreshTokenRequest](r)
 	if err != nil {
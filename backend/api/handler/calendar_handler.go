@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"setlist/api/apierror"
+	"setlist/api/service"
+	"strings"
+	"time"
+)
+
+type CalendarHandler struct {
+	CalendarService service.CalendarService
+}
+
+func mapCalendarError(err error, operation string) error {
+	if errors.Is(err, service.ErrCalendarFeedNotFound) {
+		return apierror.NotFound("Calendrier")
+	}
+	return apierror.InternalError(operation)
+}
+
+func (h CalendarHandler) GetCalendarFeed(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
+	feed, err := h.CalendarService.GetFeed(r.Context(), userID, bandID)
+	if err != nil {
+		return mapCalendarError(err, "récupération du lien de calendrier")
+	}
+
+	RespondOK(w, feed)
+	return nil
+}
+
+func (h CalendarHandler) RegenerateCalendarFeed(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
+	feed, err := h.CalendarService.RegenerateFeed(r.Context(), userID, bandID)
+	if err != nil {
+		return mapCalendarError(err, "régénération du lien de calendrier")
+	}
+
+	RespondOK(w, feed)
+	return nil
+}
+
+// GetCalendar serves the ICS feed behind /api/calendar/{token}.ics. The token
+// is the only credential, so calendar apps can subscribe without logging in.
+func (h CalendarHandler) GetCalendar(w http.ResponseWriter, r *http.Request) error {
+	token, ok := strings.CutSuffix(r.PathValue("file"), ".ics")
+	if !ok || token == "" {
+		return apierror.NotFound("Calendrier")
+	}
+
+	file, err := h.CalendarService.RenderFeed(r.Context(), token, time.Now())
+	if err != nil {
+		return mapCalendarError(err, "génération du calendrier")
+	}
+
+	RespondFile(w, file)
+	return nil
+}
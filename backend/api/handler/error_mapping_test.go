@@ -36,6 +36,15 @@ func TestMapSetlistError(t *testing.T) {
 		{"invalid item type -> 400", service.ErrInvalidItemType, http.StatusBadRequest, apierror.ErrInvalidRequest},
 		{"name required -> 400", service.ErrSetlistNameRequired, http.StatusBadRequest, apierror.ErrValidationFailed},
 		{"invalid color -> 400", service.ErrInvalidColor, http.StatusBadRequest, apierror.ErrValidationFailed},
+		{"invalid duration -> 400", service.ErrInvalidDuration, http.StatusBadRequest, apierror.ErrValidationFailed},
+		{"revision not found -> 404", service.ErrRevisionNotFound, http.StatusNotFound, apierror.ErrNotFound},
+		{"template not found -> 404", service.ErrTemplateNotFound, http.StatusNotFound, apierror.ErrNotFound},
+		{"target band not found -> 404", service.ErrBandNotFoundOrNotMember, http.StatusNotFound, apierror.ErrNotFound},
+		{"item group not found -> 404", service.ErrGroupNotFound, http.StatusNotFound, apierror.ErrNotFound},
+		{"locked setlist -> 423", service.ErrSetlistLocked, http.StatusLocked, apierror.ErrSetlistLocked},
+		{"invalid export format -> 400", service.ErrInvalidExportFormat, http.StatusBadRequest, apierror.ErrInvalidRequest},
+		{"validation error -> 400", &service.ValidationError{Msg: "comparaison impossible"}, http.StatusBadRequest, apierror.ErrValidationFailed},
+		{"version conflict -> 409", &service.VersionConflictError{}, http.StatusConflict, apierror.ErrVersionConflict},
 		{"unexpected error -> 500", errors.New("db down"), http.StatusInternalServerError, apierror.ErrInternal},
 	}
 
@@ -141,6 +150,8 @@ func TestMapSongError(t *testing.T) {
 	}{
 		{"song not found -> 404", service.ErrSongNotFound, http.StatusNotFound, apierror.ErrNotFound},
 		{"title required -> 400", service.ErrSongTitleRequired, http.StatusBadRequest, apierror.ErrValidationFailed},
+		{"validation -> 400", &service.ValidationError{Msg: "bad"}, http.StatusBadRequest, apierror.ErrValidationFailed},
+		{"version conflict -> 409", &service.VersionConflictError{}, http.StatusConflict, apierror.ErrVersionConflict},
 		{"unexpected error -> 500", errors.New("db down"), http.StatusInternalServerError, apierror.ErrInternal},
 	}
 
@@ -150,3 +161,69 @@ func TestMapSongError(t *testing.T) {
 		})
 	}
 }
+
+func TestMapShareError(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"share not found -> 404", service.ErrShareNotFound, http.StatusNotFound, apierror.ErrNotFound},
+		{"share expired -> 410", service.ErrShareExpired, http.StatusGone, apierror.ErrInvalidRequest},
+		{"validation error -> 400", &service.ValidationError{Msg: "durée invalide"}, http.StatusBadRequest, apierror.ErrValidationFailed},
+		{"setlist not found -> 404", service.ErrSetlistNotFound, http.StatusNotFound, apierror.ErrNotFound},
+		{"unexpected error -> 500", errors.New("db down"), http.StatusInternalServerError, apierror.ErrInternal},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assertAppError(t, mapShareError(tc.err, "test"), tc.wantStatus, tc.wantCode)
+		})
+	}
+}
+
+func TestMapLiveError(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"no live session -> 409", service.ErrNoLiveSession, http.StatusConflict, apierror.ErrInvalidRequest},
+		{"item not found -> 404", service.ErrItemNotFound, http.StatusNotFound, apierror.ErrNotFound},
+		{"validation error -> 400", &service.ValidationError{Msg: "setlist vide"}, http.StatusBadRequest, apierror.ErrValidationFailed},
+		{"unexpected error -> 500", errors.New("db down"), http.StatusInternalServerError, apierror.ErrInternal},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assertAppError(t, mapLiveError(tc.err, "test"), tc.wantStatus, tc.wantCode)
+		})
+	}
+}
+
+func TestMapGigError(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"gig not found -> 404", service.ErrGigNotFound, http.StatusNotFound, apierror.ErrNotFound},
+		{"linked setlist not found -> 404", service.ErrSetlistNotFound, http.StatusNotFound, apierror.ErrNotFound},
+		{"validation error -> 400", &service.ValidationError{Msg: "date requise"}, http.StatusBadRequest, apierror.ErrValidationFailed},
+		{"unexpected error -> 500", errors.New("db down"), http.StatusInternalServerError, apierror.ErrInternal},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assertAppError(t, mapGigError(tc.err, "test"), tc.wantStatus, tc.wantCode)
+		})
+	}
+}
+
+func TestMapCalendarError(t *testing.T) {
+	assertAppError(t, mapCalendarError(service.ErrCalendarFeedNotFound, "test"), http.StatusNotFound, apierror.ErrNotFound)
+	assertAppError(t, mapCalendarError(errors.New("db down"), "test"), http.StatusInternalServerError, apierror.ErrInternal)
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"setlist/api/apierror"
+	"setlist/api/service"
+)
+
+type GigHandler struct {
+	GigService service.GigService
+}
+
+// mapGigError translates gig sentinel errors into typed API errors and falls
+// back to the setlist mapping for the linked setlists.
+func mapGigError(err error, operation string) error {
+	switch {
+	case errors.Is(err, service.ErrGigNotFound):
+		return apierror.NotFound("Concert")
+	default:
+		return mapSetlistError(err, operation)
+	}
+}
+
+func (h GigHandler) GetGigs(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	gigs, err := h.GigService.List(r.Context(), bandID)
+	if err != nil {
+		return mapGigError(err, "récupération des concerts")
+	}
+
+	RespondOK(w, gigs)
+	return nil
+}
+
+func (h GigHandler) GetGig(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	gigID, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de concert invalide.")
+	}
+
+	gig, err := h.GigService.Get(r.Context(), gigID, bandID)
+	if err != nil {
+		return mapGigError(err, "récupération du concert")
+	}
+
+	RespondOK(w, gig)
+	return nil
+}
+
+func (h GigHandler) CreateGig(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	payload, err := DecodeJSON[service.GigPayload](r)
+	if err != nil {
+		return err
+	}
+
+	gig, err := h.GigService.Create(r.Context(), bandID, payload)
+	if err != nil {
+		return mapGigError(err, "création du concert")
+	}
+
+	RespondCreated(w, gig)
+	return nil
+}
+
+func (h GigHandler) UpdateGig(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	gigID, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de concert invalide.")
+	}
+
+	payload, err := DecodeJSON[service.GigPayload](r)
+	if err != nil {
+		return err
+	}
+
+	gig, err := h.GigService.Update(r.Context(), gigID, bandID, payload)
+	if err != nil {
+		return mapGigError(err, "mise à jour du concert")
+	}
+
+	RespondOK(w, gig)
+	return nil
+}
+
+func (h GigHandler) DeleteGig(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	gigID, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de concert invalide.")
+	}
+
+	if err := h.GigService.Delete(r.Context(), gigID, bandID); err != nil {
+		return mapGigError(err, "suppression du concert")
+	}
+
+	RespondNoContent(w)
+	return nil
+}
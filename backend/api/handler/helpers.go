@@ -3,23 +3,31 @@ package handler
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"setlist/api/apierror"
 	"setlist/api/middleware"
+	"setlist/api/service"
 	"strconv"
+	"strings"
+	"time"
 )
 
 func writeAppError(w http.ResponseWriter, appErr *apierror.AppError) {
 	if !appErr.IsUserError {
 		log.Printf("[ERROR][%s] %s", appErr.Code, appErr.Message)
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(appErr.HTTPStatus)
-	json.NewEncoder(w).Encode(map[string]string{
+	body := map[string]any{
 		"error": appErr.Message,
 		"code":  appErr.Code,
-	})
+	}
+	if appErr.Current != nil {
+		body["current"] = appErr.Current
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(appErr.HTTPStatus)
+	json.NewEncoder(w).Encode(body)
 }
 
 func DecodeJSON[T any](r *http.Request) (T, error) {
@@ -60,6 +68,47 @@ func GetIntParam(r *http.Request, key string) (int, error) {
 	return id, nil
 }
 
+// getDateParam parses a date bound of the query string, given either as
+// RFC 3339 or as a plain date. A plain end date includes the whole day.
+func getDateParam(r *http.Request, key string, end bool) (*time.Time, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return nil, apierror.InvalidRequest("Paramètre " + key + " invalide (format AAAA-MM-JJ ou RFC 3339 attendu).")
+	}
+	if end {
+		t = t.Add(24 * time.Hour)
+	}
+	return &t, nil
+}
+
+// getExpectedVersion returns the version the client based its change on: the
+// If-Match header when present, such as "3" or W/"3", or the version field of
+// the payload otherwise.
+func getExpectedVersion(r *http.Request, payloadVersion *int) (*int, error) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return payloadVersion, nil
+	}
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+	if err != nil {
+		return nil, apierror.InvalidRequest("En-tête If-Match invalide.")
+	}
+	return &version, nil
+}
+
+// setVersionHeader exposes a resource version as its ETag, the value to send
+// back in If-Match.
+func setVersionHeader(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, version))
+}
+
 func RespondJSON(w http.ResponseWriter, status int, data any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -78,6 +127,13 @@ func RespondNoContent(w http.ResponseWriter) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func RespondFile(w http.ResponseWriter, file service.ExportFile) {
+	w.Header().Set("Content-Type", file.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.FileName))
+	w.WriteHeader(http.StatusOK)
+	w.Write(file.Content)
+}
+
 func asAppError(err error) *apierror.AppError {
 	var appErr *apierror.AppError
 	if errors.As(err, &appErr) {
@@ -2,8 +2,10 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"setlist/api/apierror"
 	"setlist/api/middleware"
 	"strings"
 	"testing"
@@ -127,3 +129,84 @@ func TestGetUserID_Missing(t *testing.T) {
 		t.Fatal("expected error when UserIDKey is absent, got nil")
 	}
 }
+
+// --- getExportFormat ---
+
+func TestGetExportFormat(t *testing.T) {
+	cases := []struct {
+		name   string
+		target string
+		accept string
+		want   string
+	}{
+		{"query parameter wins", "/export?format=CSV", "text/markdown", "csv"},
+		{"negotiated from Accept", "/export", "application/json, text/markdown;q=0.9", "md"},
+		{"unknown Accept falls back to default", "/export", "*/*", ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			r.Header.Set("Accept", tc.accept)
+			if got := getExportFormat(r); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+// --- getExpectedVersion ---
+
+func TestGetExpectedVersion(t *testing.T) {
+	payloadVersion := 4
+	cases := []struct {
+		name    string
+		ifMatch string
+		payload *int
+		want    *int
+		wantErr bool
+	}{
+		{"no precondition", "", nil, nil, false},
+		{"payload version", "", &payloadVersion, &payloadVersion, false},
+		{"quoted If-Match wins over payload", `"7"`, &payloadVersion, intPtr(7), false},
+		{"weak If-Match", `W/"3"`, nil, intPtr(3), false},
+		{"invalid If-Match", `"abc"`, nil, nil, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/", nil)
+			if tc.ifMatch != "" {
+				r.Header.Set("If-Match", tc.ifMatch)
+			}
+			got, err := getExpectedVersion(r, tc.payload)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (got == nil) != (tc.want == nil) || (got != nil && *got != *tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestWriteAppError_VersionConflictIncludesCurrent(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeAppError(w, apierror.VersionConflict(map[string]int{"version": 5}))
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", w.Code)
+	}
+	var body struct {
+		Code    string         `json:"code"`
+		Current map[string]int `json:"current"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatalf("invalid body: %v", err)
+	}
+	if body.Code != apierror.ErrVersionConflict || body.Current["version"] != 5 {
+		t.Errorf("unexpected body: %+v", body)
+	}
+}
+
+func intPtr(v int) *int { return &v }
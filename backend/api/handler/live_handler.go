@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"setlist/api/apierror"
+	"setlist/api/service"
+	"setlist/realtime"
+	"time"
+)
+
+type LiveHandler struct {
+	LiveService service.LiveService
+	Broker      *realtime.Broker
+}
+
+func mapLiveError(err error, operation string) error {
+	if errors.Is(err, service.ErrNoLiveSession) {
+		return apierror.NewUserError(apierror.ErrInvalidRequest, "Aucun live en cours pour cette setlist.", http.StatusConflict)
+	}
+	return mapSetlistError(err, operation)
+}
+
+func (h LiveHandler) GetLiveState(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	setlistID, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	state, err := h.LiveService.GetState(r.Context(), setlistID, bandID)
+	if err != nil {
+		return mapLiveError(err, "récupération du live")
+	}
+
+	RespondOK(w, state)
+	return nil
+}
+
+// StreamLive sends the live state as Server-Sent Events: once on connection,
+// then on every change, and again every sseKeepAliveInterval with updated
+// elapsed times, which also keeps the connection open.
+func (h LiveHandler) StreamLive(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	setlistID, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	// Subscribe before reading the state so no change is lost in between.
+	events, unsubscribe := h.Broker.Subscribe(realtime.LiveTopic(setlistID))
+	defer unsubscribe()
+
+	state, err := h.LiveService.GetState(r.Context(), setlistID, bandID)
+	if err != nil {
+		return mapLiveError(err, "récupération du live")
+	}
+
+	stream, err := startSSE(w)
+	if err != nil {
+		return err
+	}
+	if err := stream.Send(service.LiveEventState, state); err != nil {
+		return nil
+	}
+
+	ticker := time.NewTicker(sseKeepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := json.Unmarshal(event.Payload, &state); err != nil {
+				continue
+			}
+			if err := stream.SendRaw(event.Type, event.Payload); err != nil {
+				return nil
+			}
+		case now := <-ticker.C:
+			state.Refresh(now)
+			if err := stream.Send(service.LiveEventState, state); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+func (h LiveHandler) StartLive(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
+	setlistID, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	state, err := h.LiveService.Start(r.Context(), setlistID, bandID, userID)
+	if err != nil {
+		return mapLiveError(err, "démarrage du live")
+	}
+
+	RespondOK(w, state)
+	return nil
+}
+
+func (h LiveHandler) NextLiveItem(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	setlistID, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	state, err := h.LiveService.Next(r.Context(), setlistID, bandID)
+	if err != nil {
+		return mapLiveError(err, "passage à l'élément suivant")
+	}
+
+	RespondOK(w, state)
+	return nil
+}
+
+func (h LiveHandler) PreviousLiveItem(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	setlistID, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	state, err := h.LiveService.Previous(r.Context(), setlistID, bandID)
+	if err != nil {
+		return mapLiveError(err, "retour à l'élément précédent")
+	}
+
+	RespondOK(w, state)
+	return nil
+}
+
+func (h LiveHandler) JumpLiveItem(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	setlistID, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	payload, err := DecodeJSON[service.JumpPayload](r)
+	if err != nil {
+		return err
+	}
+
+	state, err := h.LiveService.Jump(r.Context(), setlistID, bandID, payload)
+	if err != nil {
+		return mapLiveError(err, "saut vers un élément")
+	}
+
+	RespondOK(w, state)
+	return nil
+}
+
+func (h LiveHandler) StopLive(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	setlistID, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	state, err := h.LiveService.Stop(r.Context(), setlistID, bandID)
+	if err != nil {
+		return mapLiveError(err, "arrêt du live")
+	}
+
+	RespondOK(w, state)
+	return nil
+}
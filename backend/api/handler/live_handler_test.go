@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"setlist/api/middleware"
+	"setlist/api/model"
+	"setlist/api/repository/mocks"
+	"setlist/api/service"
+	"setlist/auth"
+	"setlist/realtime"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/mock/gomock"
+)
+
+const testJWTSecret = "testsecret"
+
+// newLiveStreamServer serves StreamLive behind StreamAuth, as main.go does.
+func newLiveStreamServer(t *testing.T, ctrl *gomock.Controller, userRepo *mocks.MockUserRepository) *httptest.Server {
+	t.Helper()
+
+	mockSetlistRepo := mocks.NewMockSetlistRepository(ctrl)
+	mockLiveRepo := mocks.NewMockLiveSessionRepository(ctrl)
+	mockSetlistRepo.EXPECT().GetSetlistByID(gomock.Any(), 10, 1).Return(model.Setlist{ID: 10, BandID: 1}, nil).AnyTimes()
+	mockSetlistRepo.EXPECT().GetSetlistItemsBySetlistID(gomock.Any(), 10).Return([]model.SetlistItem{}, nil).AnyTimes()
+	mockLiveRepo.EXPECT().Get(gomock.Any(), 10).Return(model.LiveSession{}, pgx.ErrNoRows).AnyTimes()
+
+	broker := realtime.NewBroker(nil)
+	h := LiveHandler{
+		LiveService: service.LiveService{LiveRepo: mockLiveRepo, SetlistRepo: mockSetlistRepo, Broker: broker},
+		Broker:      broker,
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /api/setlist/{id}/live/stream", middleware.StreamAuth(testJWTSecret, userRepo)(Wrap(h.StreamLive)))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestLiveHandler_StreamLive_StreamToken(t *testing.T) {
+	t.Run("connects without headers, like EventSource", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUserRepo := mocks.NewMockUserRepository(ctrl)
+		mockUserRepo.EXPECT().IsUserInBand(gomock.Any(), 2, 1).Return(true, nil)
+		server := newLiveStreamServer(t, ctrl, mockUserRepo)
+
+		token, _, err := auth.GenerateStreamToken(testJWTSecret, 2, 1)
+		if err != nil {
+			t.Fatalf("failed to generate token: %v", err)
+		}
+		resp, err := http.Get(server.URL + "/api/setlist/10/live/stream?token=" + token)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", resp.StatusCode)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Errorf("expected an event stream, got %q", ct)
+		}
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		if err != nil || strings.TrimSpace(line) != "event: "+service.LiveEventState {
+			t.Errorf("expected the live state first, got %q (%v)", line, err)
+		}
+	})
+
+	t.Run("rejects an access token in the URL", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		server := newLiveStreamServer(t, ctrl, mocks.NewMockUserRepository(ctrl))
+
+		token, err := auth.GenerateJWT(testJWTSecret, 2)
+		if err != nil {
+			t.Fatalf("failed to generate token: %v", err)
+		}
+		resp, err := http.Get(server.URL + "/api/setlist/10/live/stream?token=" + token)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("rejects a member who left the band", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUserRepo := mocks.NewMockUserRepository(ctrl)
+		mockUserRepo.EXPECT().IsUserInBand(gomock.Any(), 2, 1).Return(false, nil)
+		server := newLiveStreamServer(t, ctrl, mockUserRepo)
+
+		token, _, err := auth.GenerateStreamToken(testJWTSecret, 2, 1)
+		if err != nil {
+			t.Fatalf("failed to generate token: %v", err)
+		}
+		resp, err := http.Get(server.URL + "/api/setlist/10/live/stream?token=" + token)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("expected status 403, got %d", resp.StatusCode)
+		}
+	})
+}
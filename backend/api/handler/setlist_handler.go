@@ -6,15 +6,22 @@ import (
 	"setlist/api/apierror"
 	"setlist/api/model"
 	"setlist/api/service"
+	"setlist/realtime"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type SetlistHandler struct {
 	SetlistService service.SetlistService
+	Broker         *realtime.Broker
 }
 
 // mapSetlistError translates the setlist service's sentinel errors into typed
 // API errors; anything else is reported as an internal error on the operation.
 func mapSetlistError(err error, operation string) error {
+	var ve *service.ValidationError
+	var conflict *service.VersionConflictError
 	switch {
 	case errors.Is(err, service.ErrSetlistNotFound):
 		return apierror.NotFound("Setlist")
@@ -26,6 +33,24 @@ func mapSetlistError(err error, operation string) error {
 		return apierror.ValidationFailed("Le nom de la setlist est requis.")
 	case errors.Is(err, service.ErrInvalidColor):
 		return apierror.ValidationFailed("Le format de la couleur est invalide.")
+	case errors.Is(err, service.ErrInvalidDuration):
+		return apierror.ValidationFailed("La durée ne peut pas être négative.")
+	case errors.Is(err, service.ErrRevisionNotFound):
+		return apierror.NotFound("Révision")
+	case errors.Is(err, service.ErrTemplateNotFound):
+		return apierror.NotFound("Modèle")
+	case errors.Is(err, service.ErrBandNotFoundOrNotMember):
+		return apierror.NotFound("Groupe")
+	case errors.Is(err, service.ErrGroupNotFound):
+		return apierror.NotFound("Medley")
+	case errors.Is(err, service.ErrSetlistLocked):
+		return apierror.SetlistLocked()
+	case errors.Is(err, service.ErrInvalidExportFormat):
+		return apierror.InvalidRequest("Format d'export non pris en charge.")
+	case errors.As(err, &ve):
+		return apierror.ValidationFailed(ve.Msg)
+	case errors.As(err, &conflict):
+		return apierror.VersionConflict(conflict.Current)
 	default:
 		return apierror.InternalError(operation)
 	}
@@ -37,12 +62,17 @@ func (h SetlistHandler) CreateSetlist(w http.ResponseWriter, r *http.Request) er
 		return err
 	}
 
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
 	payload, err := DecodeJSON[service.CreateSetlistPayload](r)
 	if err != nil {
 		return err
 	}
 
-	setlist, err := h.SetlistService.Create(r.Context(), payload, bandID)
+	setlist, err := h.SetlistService.Create(r.Context(), payload, bandID, userID)
 	if err != nil {
 		return mapSetlistError(err, "création de setlist")
 	}
@@ -57,6 +87,11 @@ func (h SetlistHandler) UpdateSetlist(w http.ResponseWriter, r *http.Request) er
 		return err
 	}
 
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
 	id, err := GetIntParam(r, "id")
 	if err != nil {
 		return apierror.InvalidRequest("Identifiant de setlist invalide.")
@@ -66,12 +101,16 @@ func (h SetlistHandler) UpdateSetlist(w http.ResponseWriter, r *http.Request) er
 	if err != nil {
 		return err
 	}
+	if payload.Version, err = getExpectedVersion(r, payload.Version); err != nil {
+		return err
+	}
 
-	setlist, err := h.SetlistService.Update(r.Context(), id, bandID, payload)
+	setlist, err := h.SetlistService.Update(r.Context(), id, bandID, userID, payload)
 	if err != nil {
 		return mapSetlistError(err, "mise à jour de setlist")
 	}
 
+	setVersionHeader(w, setlist.Version)
 	RespondOK(w, setlist)
 	return nil
 }
@@ -95,24 +134,163 @@ func (h SetlistHandler) DeleteSetlist(w http.ResponseWriter, r *http.Request) er
 	return nil
 }
 
+// LockSetlist makes the items of a setlist read-only until it is unlocked.
+func (h SetlistHandler) LockSetlist(w http.ResponseWriter, r *http.Request) error {
+	return h.setLocked(w, r, true)
+}
+
+func (h SetlistHandler) UnlockSetlist(w http.ResponseWriter, r *http.Request) error {
+	return h.setLocked(w, r, false)
+}
+
+func (h SetlistHandler) setLocked(w http.ResponseWriter, r *http.Request, locked bool) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	setlist, err := h.SetlistService.SetLocked(r.Context(), id, bandID, userID, locked)
+	if err != nil {
+		return mapSetlistError(err, "verrouillage de setlist")
+	}
+
+	setVersionHeader(w, setlist.Version)
+	RespondOK(w, setlist)
+	return nil
+}
+
+// GetTrash lists the deleted setlists of the band that can still be restored.
+func (h SetlistHandler) GetTrash(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	setlists, err := h.SetlistService.GetTrash(r.Context(), bandID)
+	if err != nil {
+		return apierror.InternalError("récupération de la corbeille")
+	}
+
+	RespondOK(w, setlists)
+	return nil
+}
+
+func (h SetlistHandler) RestoreSetlist(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	setlist, err := h.SetlistService.Restore(r.Context(), id, bandID)
+	if err != nil {
+		return mapSetlistError(err, "restauration de setlist")
+	}
+
+	setVersionHeader(w, setlist.Version)
+	RespondOK(w, setlist)
+	return nil
+}
+
+// PurgeSetlist deletes a setlist of the trash for good.
+func (h SetlistHandler) PurgeSetlist(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	if err := h.SetlistService.Purge(r.Context(), id, bandID); err != nil {
+		return mapSetlistError(err, "suppression définitive de setlist")
+	}
+
+	RespondNoContent(w)
+	return nil
+}
+
 func (h SetlistHandler) GetSetlists(w http.ResponseWriter, r *http.Request) error {
 	bandID, err := GetBandID(r)
 	if err != nil {
 		return err
 	}
 
-	setlists, err := h.SetlistService.GetAllForBand(r.Context(), bandID)
+	query, err := getSetlistQuery(r)
 	if err != nil {
-		return apierror.InternalError("récupération des setlists")
+		return err
+	}
+
+	page, err := h.SetlistService.List(r.Context(), bandID, query)
+	if err != nil {
+		return mapSetlistError(err, "récupération des setlists")
 	}
+	setlists := page.Setlists
 	if setlists == nil {
 		setlists = make([]model.Setlist, 0)
 	}
 
+	// The body stays a plain array; the cursor of the next page, if any,
+	// travels in a header.
+	if page.NextCursor != "" {
+		w.Header().Set("X-Next-Cursor", page.NextCursor)
+	}
 	RespondOK(w, setlists)
 	return nil
 }
 
+// getSetlistQuery reads the filters, sort and pagination of the setlist list
+// from the query string.
+func getSetlistQuery(r *http.Request) (service.SetlistQuery, error) {
+	params := r.URL.Query()
+	query := service.SetlistQuery{
+		Search: strings.TrimSpace(params.Get("q")),
+		Color:  params.Get("color"),
+		Sort:   params.Get("sort"),
+		Cursor: params.Get("cursor"),
+	}
+
+	if raw := params.Get("archived"); raw != "" {
+		archived, err := strconv.ParseBool(raw)
+		if err != nil {
+			return query, apierror.InvalidRequest("Paramètre archived invalide (true ou false attendu).")
+		}
+		query.Archived = &archived
+	}
+	if raw := params.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			return query, apierror.InvalidRequest("Taille de page invalide.")
+		}
+		query.Limit = limit
+	}
+
+	var err error
+	if query.GigFrom, err = getDateParam(r, "gig_from", false); err != nil {
+		return query, err
+	}
+	if query.GigTo, err = getDateParam(r, "gig_to", true); err != nil {
+		return query, err
+	}
+	return query, nil
+}
+
 func (h SetlistHandler) GetSetlistDetails(w http.ResponseWriter, r *http.Request) error {
 	bandID, err := GetBandID(r)
 	if err != nil {
@@ -129,6 +307,7 @@ func (h SetlistHandler) GetSetlistDetails(w http.ResponseWriter, r *http.Request
 		return mapSetlistError(err, "récupération de la setlist")
 	}
 
+	setVersionHeader(w, details.Version)
 	RespondOK(w, details)
 	return nil
 }
@@ -139,6 +318,11 @@ func (h SetlistHandler) AddItem(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
 	setlistID, err := GetIntParam(r, "id")
 	if err != nil {
 		return apierror.InvalidRequest("Identifiant de setlist invalide.")
@@ -149,7 +333,7 @@ func (h SetlistHandler) AddItem(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	item, err := h.SetlistService.AddItem(r.Context(), setlistID, bandID, payload)
+	item, err := h.SetlistService.AddItem(r.Context(), setlistID, bandID, userID, payload)
 	if err != nil {
 		return mapSetlistError(err, "ajout d'élément à la setlist")
 	}
@@ -158,12 +342,77 @@ func (h SetlistHandler) AddItem(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+func (h SetlistHandler) AddItems(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
+	setlistID, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	payload, err := DecodeJSON[service.AddItemsPayload](r)
+	if err != nil {
+		return err
+	}
+
+	items, err := h.SetlistService.AddItems(r.Context(), setlistID, bandID, userID, payload)
+	if err != nil {
+		return mapSetlistError(err, "ajout d'éléments à la setlist")
+	}
+
+	RespondCreated(w, items)
+	return nil
+}
+
+func (h SetlistHandler) TransferItems(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
+	setlistID, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	payload, err := DecodeJSON[service.TransferItemsPayload](r)
+	if err != nil {
+		return err
+	}
+
+	items, err := h.SetlistService.TransferItems(r.Context(), setlistID, bandID, userID, payload)
+	if err != nil {
+		return mapSetlistError(err, "transfert d'éléments entre setlists")
+	}
+
+	RespondOK(w, items)
+	return nil
+}
+
 func (h SetlistHandler) UpdateItemOrder(w http.ResponseWriter, r *http.Request) error {
 	bandID, err := GetBandID(r)
 	if err != nil {
 		return err
 	}
 
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
 	setlistID, err := GetIntParam(r, "id")
 	if err != nil {
 		return apierror.InvalidRequest("Identifiant de setlist invalide.")
@@ -173,11 +422,16 @@ func (h SetlistHandler) UpdateItemOrder(w http.ResponseWriter, r *http.Request)
 	if err != nil {
 		return err
 	}
+	if payload.Version, err = getExpectedVersion(r, payload.Version); err != nil {
+		return err
+	}
 
-	if err := h.SetlistService.UpdateOrder(r.Context(), setlistID, bandID, payload); err != nil {
+	version, err := h.SetlistService.UpdateOrder(r.Context(), setlistID, bandID, userID, payload)
+	if err != nil {
 		return mapSetlistError(err, "mise à jour de l'ordre")
 	}
 
+	setVersionHeader(w, version)
 	RespondNoContent(w)
 	return nil
 }
@@ -188,6 +442,11 @@ func (h SetlistHandler) UpdateItem(w http.ResponseWriter, r *http.Request) error
 		return err
 	}
 
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
 	itemID, err := GetIntParam(r, "itemId")
 	if err != nil {
 		return apierror.InvalidRequest("Identifiant d'élément invalide.")
@@ -198,7 +457,7 @@ func (h SetlistHandler) UpdateItem(w http.ResponseWriter, r *http.Request) error
 		return err
 	}
 
-	item, err := h.SetlistService.UpdateItem(r.Context(), itemID, bandID, payload)
+	item, err := h.SetlistService.UpdateItem(r.Context(), itemID, bandID, userID, payload)
 	if err != nil {
 		return mapSetlistError(err, "mise à jour d'élément")
 	}
@@ -213,12 +472,17 @@ func (h SetlistHandler) DeleteItem(w http.ResponseWriter, r *http.Request) error
 		return err
 	}
 
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
 	itemID, err := GetIntParam(r, "itemId")
 	if err != nil {
 		return apierror.InvalidRequest("Identifiant d'élément invalide.")
 	}
 
-	if err := h.SetlistService.DeleteItem(r.Context(), itemID, bandID); err != nil {
+	if err := h.SetlistService.DeleteItem(r.Context(), itemID, bandID, userID); err != nil {
 		return mapSetlistError(err, "suppression d'élément")
 	}
 
@@ -226,12 +490,113 @@ func (h SetlistHandler) DeleteItem(w http.ResponseWriter, r *http.Request) error
 	return nil
 }
 
+// CreateItemGroup groups consecutive items of a setlist, such as a medley.
+func (h SetlistHandler) CreateItemGroup(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	payload, err := DecodeJSON[service.CreateGroupPayload](r)
+	if err != nil {
+		return err
+	}
+
+	group, err := h.SetlistService.CreateGroup(r.Context(), id, bandID, userID, payload)
+	if err != nil {
+		return mapSetlistError(err, "création du medley")
+	}
+
+	RespondCreated(w, group)
+	return nil
+}
+
+func (h SetlistHandler) RenameItemGroup(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	groupID, err := GetIntParam(r, "groupId")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de medley invalide.")
+	}
+
+	payload, err := DecodeJSON[service.RenameGroupPayload](r)
+	if err != nil {
+		return err
+	}
+
+	group, err := h.SetlistService.RenameGroup(r.Context(), id, groupID, bandID, userID, payload)
+	if err != nil {
+		return mapSetlistError(err, "modification du medley")
+	}
+
+	RespondOK(w, group)
+	return nil
+}
+
+// DeleteItemGroup ungroups the items of a group without removing them.
+func (h SetlistHandler) DeleteItemGroup(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	groupID, err := GetIntParam(r, "groupId")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de medley invalide.")
+	}
+
+	if err := h.SetlistService.DeleteGroup(r.Context(), id, groupID, bandID, userID); err != nil {
+		return mapSetlistError(err, "suppression du medley")
+	}
+
+	RespondNoContent(w)
+	return nil
+}
+
 func (h SetlistHandler) DuplicateSetlist(w http.ResponseWriter, r *http.Request) error {
 	bandID, err := GetBandID(r)
 	if err != nil {
 		return err
 	}
 
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
 	originalSetlistID, err := GetIntParam(r, "id")
 	if err != nil {
 		return apierror.InvalidRequest("Identifiant de setlist invalide.")
@@ -242,7 +607,7 @@ func (h SetlistHandler) DuplicateSetlist(w http.ResponseWriter, r *http.Request)
 		return err
 	}
 
-	newSetlist, err := h.SetlistService.Duplicate(r.Context(), originalSetlistID, bandID, payload.Name, payload.Color)
+	newSetlist, err := h.SetlistService.Duplicate(r.Context(), originalSetlistID, bandID, userID, payload.Name, payload.Color)
 	if err != nil {
 		return mapSetlistError(err, "duplication de setlist")
 	}
@@ -250,3 +615,410 @@ func (h SetlistHandler) DuplicateSetlist(w http.ResponseWriter, r *http.Request)
 	RespondCreated(w, newSetlist)
 	return nil
 }
+
+// GenerateSetlist returns a candidate setlist built from the band's songs; the
+// client saves it through the usual endpoints if it keeps it.
+func (h SetlistHandler) GenerateSetlist(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	payload, err := DecodeJSON[service.GeneratePayload](r)
+	if err != nil {
+		return err
+	}
+
+	generated, err := h.SetlistService.Generate(r.Context(), bandID, payload)
+	if err != nil {
+		return mapSetlistError(err, "génération de setlist")
+	}
+
+	RespondOK(w, generated)
+	return nil
+}
+
+func (h SetlistHandler) RepairPositions(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
+	repair, err := h.SetlistService.RepairPositions(r.Context(), bandID, userID)
+	if err != nil {
+		return mapSetlistError(err, "réparation des positions")
+	}
+
+	RespondOK(w, repair)
+	return nil
+}
+
+// CopyToBand copies a setlist with its songs and interludes into another band
+// of the user.
+func (h SetlistHandler) CopyToBand(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	payload, err := DecodeJSON[service.CopyToBandPayload](r)
+	if err != nil {
+		return err
+	}
+
+	report, err := h.SetlistService.CopyToBand(r.Context(), id, bandID, userID, payload)
+	if err != nil {
+		return mapSetlistError(err, "copie de setlist vers un autre groupe")
+	}
+
+	RespondCreated(w, report)
+	return nil
+}
+
+func (h SetlistHandler) SaveAsTemplate(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
+	setlistID, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	payload, err := DecodeJSON[service.SaveTemplatePayload](r)
+	if err != nil {
+		return err
+	}
+
+	template, err := h.SetlistService.SaveAsTemplate(r.Context(), setlistID, bandID, userID, payload)
+	if err != nil {
+		return mapSetlistError(err, "enregistrement du modèle")
+	}
+
+	RespondCreated(w, template)
+	return nil
+}
+
+func (h SetlistHandler) GetTemplates(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	templates, err := h.SetlistService.GetTemplates(r.Context(), bandID)
+	if err != nil {
+		return apierror.InternalError("récupération des modèles")
+	}
+
+	RespondOK(w, templates)
+	return nil
+}
+
+func (h SetlistHandler) GetRevisions(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	revisions, err := h.SetlistService.ListRevisions(r.Context(), id, bandID)
+	if err != nil {
+		return mapSetlistError(err, "récupération de l'historique")
+	}
+
+	RespondOK(w, revisions)
+	return nil
+}
+
+func (h SetlistHandler) GetRevision(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	revisionID, err := GetIntParam(r, "revisionId")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de révision invalide.")
+	}
+
+	revision, err := h.SetlistService.GetRevision(r.Context(), id, bandID, revisionID)
+	if err != nil {
+		return mapSetlistError(err, "récupération de la révision")
+	}
+
+	RespondOK(w, revision)
+	return nil
+}
+
+func (h SetlistHandler) RestoreRevision(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	revisionID, err := GetIntParam(r, "revisionId")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de révision invalide.")
+	}
+
+	details, err := h.SetlistService.RestoreRevision(r.Context(), id, bandID, userID, revisionID)
+	if err != nil {
+		return mapSetlistError(err, "restauration de la révision")
+	}
+
+	RespondOK(w, details)
+	return nil
+}
+
+func (h SetlistHandler) GetSetlistTiming(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	plannedStart, err := getPlannedStart(r)
+	if err != nil {
+		return err
+	}
+
+	timing, err := h.SetlistService.GetTiming(r.Context(), id, bandID, plannedStart)
+	if err != nil {
+		return mapSetlistError(err, "calcul du minutage de la setlist")
+	}
+
+	RespondOK(w, timing)
+	return nil
+}
+
+func (h SetlistHandler) ExportSetlistPDF(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	plannedStart, err := getPlannedStart(r)
+	if err != nil {
+		return err
+	}
+
+	file, err := h.SetlistService.ExportPDF(r.Context(), id, bandID, service.PDFExportOptions{
+		Layout:       r.URL.Query().Get("layout"),
+		Musician:     r.URL.Query().Get("musician"),
+		PlannedStart: plannedStart,
+	})
+	if err != nil {
+		return mapSetlistError(err, "export PDF de la setlist")
+	}
+
+	RespondFile(w, file)
+	return nil
+}
+
+func (h SetlistHandler) ExportSetlist(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	plannedStart, err := getPlannedStart(r)
+	if err != nil {
+		return err
+	}
+
+	file, err := h.SetlistService.Export(r.Context(), id, bandID, service.ExportOptions{
+		Format:       getExportFormat(r),
+		PlannedStart: plannedStart,
+	})
+	if err != nil {
+		return mapSetlistError(err, "export de la setlist")
+	}
+
+	RespondFile(w, file)
+	return nil
+}
+
+// exportMediaTypes maps the Accept header values understood by the export
+// endpoint to their format.
+var exportMediaTypes = map[string]string{
+	"text/plain":             service.ExportFormatText,
+	"text/markdown":          service.ExportFormatMarkdown,
+	"text/csv":               service.ExportFormatCSV,
+	"application/x-chordpro": service.ExportFormatChordPro,
+}
+
+// getExportFormat reads the ?format= parameter, falling back to the first
+// media type of the Accept header that an export can be rendered as.
+func getExportFormat(r *http.Request) string {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return strings.ToLower(format)
+	}
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(accepted, ";")
+		if format, ok := exportMediaTypes[strings.ToLower(strings.TrimSpace(mediaType))]; ok {
+			return format
+		}
+	}
+	return ""
+}
+
+func (h SetlistHandler) GetSetlistDiff(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	id, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	var opts service.DiffOptions
+	if opts.AgainstID, err = getOptionalIntQuery(r, "against"); err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist à comparer invalide.")
+	}
+	if opts.RevisionID, err = getOptionalIntQuery(r, "revision"); err != nil {
+		return apierror.InvalidRequest("Identifiant de révision invalide.")
+	}
+	if opts.ToRevisionID, err = getOptionalIntQuery(r, "to_revision"); err != nil {
+		return apierror.InvalidRequest("Identifiant de révision invalide.")
+	}
+	if opts.AgainstID != nil && opts.RevisionID != nil {
+		return apierror.InvalidRequest("Comparez avec une setlist ou une révision, pas les deux.")
+	}
+
+	diff, err := h.SetlistService.Diff(r.Context(), id, bandID, opts)
+	if err != nil {
+		return mapSetlistError(err, "comparaison de setlists")
+	}
+
+	RespondOK(w, diff)
+	return nil
+}
+
+// getOptionalIntQuery reads an optional integer query parameter.
+func getOptionalIntQuery(r *http.Request, name string) (*int, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// getPlannedStart reads the optional ?start= wall-clock time of the show.
+func getPlannedStart(r *http.Request) (*time.Time, error) {
+	raw := r.URL.Query().Get("start")
+	if raw == "" {
+		return nil, nil
+	}
+	start, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, apierror.InvalidRequest("Heure de début invalide (format RFC 3339 attendu).")
+	}
+	return &start, nil
+}
+
+// StreamSetlistEvents sends the changes made to a setlist as Server-Sent
+// Events until the client disconnects.
+func (h SetlistHandler) StreamSetlistEvents(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	setlistID, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	if _, err := h.SetlistService.GetDetails(r.Context(), setlistID, bandID); err != nil {
+		return mapSetlistError(err, "abonnement aux modifications de la setlist")
+	}
+
+	events, unsubscribe := h.Broker.Subscribe(realtime.SetlistTopic(setlistID))
+	defer unsubscribe()
+
+	stream, err := startSSE(w)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(sseKeepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := stream.SendRaw(event.Type, event.Payload); err != nil {
+				return nil
+			}
+		case <-ticker.C:
+			if err := stream.Ping(); err != nil {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"setlist/api/apierror"
+	"setlist/api/service"
+)
+
+type SetlistShareHandler struct {
+	ShareService service.SetlistShareService
+}
+
+// mapShareError translates share link sentinel errors into typed API errors and
+// falls back to the setlist mapping for everything else.
+func mapShareError(err error, operation string) error {
+	var ve *service.ValidationError
+	switch {
+	case errors.Is(err, service.ErrShareNotFound):
+		return apierror.NewUserError(apierror.ErrNotFound, "Lien de partage introuvable", http.StatusNotFound)
+	case errors.Is(err, service.ErrShareExpired):
+		return apierror.NewUserError(apierror.ErrInvalidRequest, "Lien de partage expiré", http.StatusGone)
+	case errors.As(err, &ve):
+		return apierror.ValidationFailed(ve.Msg)
+	default:
+		return mapSetlistError(err, operation)
+	}
+}
+
+func (h SetlistShareHandler) CreateShare(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	userID, err := GetUserID(r)
+	if err != nil {
+		return err
+	}
+
+	setlistID, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	var payload service.CreateSharePayload
+	if r.ContentLength != 0 {
+		if payload, err = DecodeJSON[service.CreateSharePayload](r); err != nil {
+			return err
+		}
+	}
+
+	share, err := h.ShareService.Create(r.Context(), setlistID, bandID, userID, payload)
+	if err != nil {
+		return mapShareError(err, "création du lien de partage")
+	}
+
+	RespondCreated(w, share)
+	return nil
+}
+
+func (h SetlistShareHandler) GetShares(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	setlistID, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	shares, err := h.ShareService.List(r.Context(), setlistID, bandID)
+	if err != nil {
+		return mapShareError(err, "récupération des liens de partage")
+	}
+
+	RespondOK(w, shares)
+	return nil
+}
+
+// RevokeShares revokes the link given by {token}, or every link of the
+// setlist on the route without a token.
+func (h SetlistShareHandler) RevokeShares(w http.ResponseWriter, r *http.Request) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	setlistID, err := GetIntParam(r, "id")
+	if err != nil {
+		return apierror.InvalidRequest("Identifiant de setlist invalide.")
+	}
+
+	if err := h.ShareService.Revoke(r.Context(), setlistID, bandID, r.PathValue("token")); err != nil {
+		return mapShareError(err, "révocation du lien de partage")
+	}
+
+	RespondNoContent(w)
+	return nil
+}
+
+func (h SetlistShareHandler) GetSharedSetlist(w http.ResponseWriter, r *http.Request) error {
+	token := r.PathValue("token")
+	if token == "" {
+		return apierror.InvalidRequest("Token manquant")
+	}
+
+	shared, err := h.ShareService.GetShared(r.Context(), token)
+	if err != nil {
+		return mapShareError(err, "récupération de la setlist partagée")
+	}
+
+	RespondOK(w, shared)
+	return nil
+}
@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"setlist/api/apierror"
 	"setlist/api/service"
+	"strconv"
 )
 
 type SongHandler struct {
@@ -14,11 +16,17 @@ type SongHandler struct {
 // mapSongError translates the song service's sentinel errors into typed API
 // errors; anything else is reported as an internal error on the operation.
 func mapSongError(err error, operation string) error {
+	var ve *service.ValidationError
+	var conflict *service.VersionConflictError
 	switch {
+	case errors.As(err, &ve):
+		return apierror.ValidationFailed(ve.Msg)
 	case errors.Is(err, service.ErrSongNotFound):
 		return apierror.NotFound("Chanson")
 	case errors.Is(err, service.ErrSongTitleRequired):
 		return apierror.ValidationFailed("Le titre de la chanson est requis.")
+	case errors.As(err, &conflict):
+		return apierror.VersionConflict(conflict.Current)
 	default:
 		return apierror.InternalError(operation)
 	}
@@ -75,6 +83,7 @@ func (h SongHandler) GetSong(w http.ResponseWriter, r *http.Request) error {
 		return mapSongError(err, "récupération de la chanson")
 	}
 
+	setVersionHeader(w, song.Version)
 	RespondOK(w, song)
 	return nil
 }
@@ -94,12 +103,16 @@ func (h SongHandler) UpdateSong(w http.ResponseWriter, r *http.Request) error {
 	if err != nil {
 		return err
 	}
+	if payload.Version, err = getExpectedVersion(r, payload.Version); err != nil {
+		return err
+	}
 
 	updatedSong, err := h.SongService.Update(r.Context(), id, bandID, payload)
 	if err != nil {
 		return mapSongError(err, "mise à jour de chanson")
 	}
 
+	setVersionHeader(w, updatedSong.Version)
 	RespondOK(w, updatedSong)
 	return nil
 }
@@ -122,3 +135,42 @@ func (h SongHandler) DeleteSong(w http.ResponseWriter, r *http.Request) error {
 	RespondNoContent(w)
 	return nil
 }
+
+// GetSongStats reports how often each song was used in the setlists.
+func (h SongHandler) GetSongStats(w http.ResponseWriter, r *http.Request) error {
+	return h.respondSongStats(w, r, h.SongService.Stats)
+}
+
+// GetNeverPlayedSongs reports the songs of the library never used in the
+// setlists.
+func (h SongHandler) GetNeverPlayedSongs(w http.ResponseWriter, r *http.Request) error {
+	return h.respondSongStats(w, r, h.SongService.NeverPlayed)
+}
+
+func (h SongHandler) respondSongStats(w http.ResponseWriter, r *http.Request, report func(context.Context, int, service.SongStatsQuery) (service.SongStatsReport, error)) error {
+	bandID, err := GetBandID(r)
+	if err != nil {
+		return err
+	}
+
+	var query service.SongStatsQuery
+	if query.From, err = getDateParam(r, "from", false); err != nil {
+		return err
+	}
+	if query.To, err = getDateParam(r, "to", true); err != nil {
+		return err
+	}
+	if raw := r.URL.Query().Get("exclude_archived"); raw != "" {
+		if query.ExcludeArchived, err = strconv.ParseBool(raw); err != nil {
+			return apierror.InvalidRequest("Paramètre exclude_archived invalide (true ou false attendu).")
+		}
+	}
+
+	stats, err := report(r.Context(), bandID, query)
+	if err != nil {
+		return mapSongError(err, "calcul des statistiques des chansons")
+	}
+
+	RespondOK(w, stats)
+	return nil
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"setlist/api/apierror"
+	"time"
+)
+
+// sseKeepAliveInterval is how often an idle stream sends a comment so proxies
+// do not close the connection.
+const sseKeepAliveInterval = 15 * time.Second
+
+// sseWriter writes Server-Sent Events and flushes each one to the client.
+type sseWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// startSSE sends the stream headers. Proxies must not buffer the response,
+// hence X-Accel-Buffering.
+func startSSE(w http.ResponseWriter) (*sseWriter, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, apierror.NewServerError(apierror.ErrInternal, "Le flux d'événements n'est pas pris en charge.")
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	return &sseWriter{w: w, flusher: flusher}, nil
+}
+
+func (s *sseWriter) Send(event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return s.SendRaw(event, payload)
+}
+
+func (s *sseWriter) SendRaw(event string, payload []byte) error {
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// Ping sends a comment line, which clients ignore.
+func (s *sseWriter) Ping() error {
+	if _, err := fmt.Fprint(s.w, ": ping\n\n"); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
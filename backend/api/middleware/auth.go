@@ -76,6 +76,48 @@ func JWTAuthUserOnly(jwtSecret string) func(http.Handler) http.Handler {
 	}
 }
 
+// StreamAuth authenticates the event streams like JWTAuth, or with a stream
+// token given in the token query parameter, since browsers' EventSource cannot
+// send the Authorization and X-Band-ID headers. The token comes from
+// POST /api/stream-token and names the band; membership is checked again here.
+func StreamAuth(jwtSecret string, userRepo repository.UserRepository) func(http.Handler) http.Handler {
+	headerAuth := JWTAuth(jwtSecret, userRepo)
+	return func(next http.Handler) http.Handler {
+		withHeaders := headerAuth(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenString := r.URL.Query().Get("token")
+			if tokenString == "" {
+				withHeaders.ServeHTTP(w, r)
+				return
+			}
+
+			claims := &auth.StreamClaims{}
+			token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+				return []byte(jwtSecret), nil
+			}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithAudience(auth.StreamTokenAudience))
+			if err != nil || !token.Valid {
+				http.Error(w, "Invalid stream token", http.StatusUnauthorized)
+				return
+			}
+
+			isMember, err := userRepo.IsUserInBand(r.Context(), claims.UserID, claims.BandID)
+			if err != nil {
+				http.Error(w, "Error verifying band membership", http.StatusInternalServerError)
+				return
+			}
+			if !isMember {
+				http.Error(w, "User is not a member of this band", http.StatusForbidden)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+			ctx = context.WithValue(ctx, BandIDKey, claims.BandID)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
 func validateJWT(w http.ResponseWriter, r *http.Request, jwtSecret string) (*auth.JWTClaims, bool) {
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
@@ -97,6 +139,11 @@ func validateJWT(w http.ResponseWriter, r *http.Request, jwtSecret string) (*aut
 		http.Error(w, "Invalid token", http.StatusUnauthorized)
 		return nil, false
 	}
+	// Stream tokens only open event streams.
+	if len(claims.Audience) > 0 {
+		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		return nil, false
+	}
 
 	return claims, true
 }
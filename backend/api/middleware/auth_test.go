@@ -91,3 +91,19 @@ func TestValidateJWT_RejectsWrongSecret(t *testing.T) {
 		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, status)
 	}
 }
+
+func TestValidateJWT_RejectsStreamToken(t *testing.T) {
+	token, _, err := auth.GenerateStreamToken(testJWTSecret, 1, 1)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	reached, status := serveWithAuth(t, "Bearer "+token)
+
+	if reached {
+		t.Error("handler should not be reached with a stream token")
+	}
+	if status != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, status)
+	}
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// CalendarFeed is the secret ICS feed of a band's gigs for one member.
+type CalendarFeed struct {
+	UserID    int       `json:"-"`
+	BandID    int       `json:"band_id"`
+	BandName  string    `json:"-"`
+	Token     string    `json:"token"`
+	Path      string    `json:"path"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+const (
+	GigKindGig       = "gig"
+	GigKindRehearsal = "rehearsal"
+)
+
+const (
+	GigStatusTentative = "tentative"
+	GigStatusConfirmed = "confirmed"
+	GigStatusCancelled = "cancelled"
+)
+
+type Gig struct {
+	ID           int          `json:"id"`
+	BandID       int          `json:"band_id"`
+	Kind         string       `json:"kind"`
+	Name         string       `json:"name"`
+	Venue        *string      `json:"venue"`
+	Address      *string      `json:"address"`
+	StartsAt     time.Time    `json:"starts_at"`
+	LoadInAt     *time.Time   `json:"load_in_at"`
+	SoundcheckAt *time.Time   `json:"soundcheck_at"`
+	Status       string       `json:"status"`
+	Notes        *string      `json:"notes"`
+	CreatedAt    time.Time    `json:"created_at"`
+	Setlists     []GigSetlist `json:"setlists"`
+}
+
+// GigSetlist is a setlist played at a gig, in order, with the time its set
+// starts when known.
+type GigSetlist struct {
+	SetlistID int        `json:"setlist_id"`
+	Name      string     `json:"name"`
+	Color     string     `json:"color"`
+	Position  int        `json:"position"`
+	StartsAt  *time.Time `json:"starts_at"`
+}
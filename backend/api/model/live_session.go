@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+type LiveSession struct {
+	SetlistID     int       `json:"setlist_id"`
+	CurrentItemID *int      `json:"current_item_id"`
+	StartedBy     *int      `json:"started_by"`
+	StartedAt     time.Time `json:"started_at"`
+	ItemStartedAt time.Time `json:"item_started_at"`
+}
@@ -3,10 +3,16 @@ package model
 import "time"
 
 type Setlist struct {
-	ID         int       `json:"id"`
-	BandID     int       `json:"band_id"`
-	Name       string    `json:"name"`
-	Color      string    `json:"color"`
-	IsArchived bool      `json:"is_archived"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID         int        `json:"id"`
+	BandID     int        `json:"band_id"`
+	Name       string     `json:"name"`
+	Color      string     `json:"color"`
+	IsArchived bool       `json:"is_archived"`
+	IsTemplate bool       `json:"is_template"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Version    int        `json:"version"`
+	IsLocked   bool       `json:"is_locked"`
+	LockedAt   *time.Time `json:"locked_at,omitempty"`
+	LockedBy   *int       `json:"locked_by,omitempty"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
 }
@@ -1,5 +1,24 @@
 package model
 
+import "time"
+
+const (
+	ItemTypeSong         = "song"
+	ItemTypeInterlude    = "interlude"
+	ItemTypeSetBreak     = "set_break"
+	ItemTypeEncore       = "encore"
+	ItemTypeIntermission = "intermission"
+	// ItemTypePlaceholder is a slot to fill when a setlist is built from a
+	// template, described by its label ("any ballad under 4 min").
+	ItemTypePlaceholder = "placeholder"
+)
+
+// IsSectionMarker reports whether the item type splits a setlist into sets
+// rather than being something the band performs.
+func IsSectionMarker(itemType string) bool {
+	return itemType == ItemTypeSetBreak || itemType == ItemTypeEncore || itemType == ItemTypeIntermission
+}
+
 type SetlistItem struct {
 	ID                        int     `json:"id"`
 	SetlistID                 int     `json:"setlist_id"`
@@ -8,7 +27,12 @@ type SetlistItem struct {
 	SongID                    *int32  `json:"song_id,omitempty"`
 	InterludeID               *int32  `json:"interlude_id,omitempty"`
 	Notes                     *string `json:"notes"`
+	NotesPrivate              bool    `json:"notes_private"`
 	TransitionDurationSeconds int     `json:"transition_duration_seconds"`
+	Label                     *string `json:"label,omitempty"`
+	ItemDurationSeconds       *int32  `json:"item_duration_seconds,omitempty"`
+	ItemTempo                 *int32  `json:"item_tempo,omitempty"`
+	ItemSongKey               *string `json:"item_song_key,omitempty"`
 	Title                     *string `json:"title,omitempty"`
 	DurationSeconds           *int32  `json:"duration_seconds,omitempty"`
 	Tempo                     *int32  `json:"tempo,omitempty"`
@@ -16,4 +40,15 @@ type SetlistItem struct {
 	Script                    *string `json:"script,omitempty"`
 	SongKey                   *string `json:"song_key,omitempty"`
 	Links                     *string `json:"links,omitempty"`
+	GroupID                   *int    `json:"group_id,omitempty"`
+	GroupName                 *string `json:"group_name,omitempty"`
+}
+
+// SetlistItemGroup ties consecutive items of a setlist together, such as the
+// songs of a medley, so they move and are timed as one block.
+type SetlistItemGroup struct {
+	ID        int       `json:"id"`
+	SetlistID int       `json:"setlist_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
 }
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type SetlistRevision struct {
+	ID         int             `json:"id"`
+	SetlistID  int             `json:"setlist_id"`
+	AuthorID   *int            `json:"author_id"`
+	AuthorName *string         `json:"author_name"`
+	Action     string          `json:"action"`
+	CreatedAt  time.Time       `json:"created_at"`
+	Snapshot   json.RawMessage `json:"snapshot,omitempty"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+type SetlistShare struct {
+	ID        int       `json:"id"`
+	Token     string    `json:"token"`
+	SetlistID int       `json:"setlist_id"`
+	BandID    int       `json:"-"`
+	CreatedBy *int      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -19,4 +19,5 @@ type Song struct {
 	Notes           *string         `json:"notes"`
 	Links           *string         `json:"links"`
 	CreatedAt       time.Time       `json:"created_at"`
+	Version         int             `json:"version"`
 }
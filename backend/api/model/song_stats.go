@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// SongStats sums up how a song of the library has been used in the setlists.
+// A setlist counts as used on its first gig, or on its creation date when it
+// was never played at a gig. SetlistShare is the part of the active setlists,
+// neither archived nor deleted, that include the song.
+type SongStats struct {
+	SongID          int        `json:"song_id"`
+	Title           string     `json:"title"`
+	AlbumName       *string    `json:"album_name"`
+	TimesUsed       int        `json:"times_used"`
+	SetlistCount    int        `json:"setlist_count"`
+	SetlistShare    float64    `json:"setlist_share"`
+	FirstUsedAt     *time.Time `json:"first_used_at"`
+	LastUsedAt      *time.Time `json:"last_used_at"`
+	AveragePosition *float64   `json:"average_position"`
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"setlist/api/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CalendarFeedRepository interface {
+	Get(ctx context.Context, userID int, bandID int) (model.CalendarFeed, error)
+	Save(ctx context.Context, feed *model.CalendarFeed) error
+	GetByToken(ctx context.Context, token string) (model.CalendarFeed, error)
+}
+
+type PgCalendarFeedRepository struct {
+	DB *pgxpool.Pool
+}
+
+func (r *PgCalendarFeedRepository) Get(ctx context.Context, userID int, bandID int) (model.CalendarFeed, error) {
+	feed := model.CalendarFeed{UserID: userID, BandID: bandID}
+	query := `SELECT token, created_at FROM calendar_feeds WHERE user_id = $1 AND band_id = $2`
+	err := r.DB.QueryRow(ctx, query, userID, bandID).Scan(&feed.Token, &feed.CreatedAt)
+	return feed, err
+}
+
+// Save stores the feed token of the user for the band, replacing the previous
+// one so its URL stops working.
+func (r *PgCalendarFeedRepository) Save(ctx context.Context, feed *model.CalendarFeed) error {
+	query := `
+		INSERT INTO calendar_feeds (user_id, band_id, token)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, band_id) DO UPDATE SET token = EXCLUDED.token, created_at = NOW()
+		RETURNING created_at
+	`
+	return r.DB.QueryRow(ctx, query, feed.UserID, feed.BandID, feed.Token).Scan(&feed.CreatedAt)
+}
+
+// GetByToken only finds the feeds of users who are still members of the band.
+func (r *PgCalendarFeedRepository) GetByToken(ctx context.Context, token string) (model.CalendarFeed, error) {
+	feed := model.CalendarFeed{Token: token}
+	query := `
+		SELECT cf.user_id, cf.band_id, b.name, cf.created_at
+		FROM calendar_feeds cf
+		JOIN band_users bu ON bu.user_id = cf.user_id AND bu.band_id = cf.band_id
+		JOIN bands b ON b.id = cf.band_id
+		WHERE cf.token = $1
+	`
+	err := r.DB.QueryRow(ctx, query, token).Scan(&feed.UserID, &feed.BandID, &feed.BandName, &feed.CreatedAt)
+	return feed, err
+}
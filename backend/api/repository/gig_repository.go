@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"setlist/api/model"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type GigRepository interface {
+	Create(ctx context.Context, db DBTX, gig *model.Gig) error
+	Update(ctx context.Context, db DBTX, gig *model.Gig) error
+	Delete(ctx context.Context, id int, bandID int) error
+	GetByID(ctx context.Context, id int, bandID int) (model.Gig, error)
+	ListByBandID(ctx context.Context, bandID int) ([]model.Gig, error)
+	ListSetlists(ctx context.Context, gigIDs []int) (map[int][]model.GigSetlist, error)
+	ReplaceSetlists(ctx context.Context, db DBTX, gigID int, setlists []model.GigSetlist) error
+	ArchivePastSetlists(ctx context.Context, before time.Time) ([]ArchivedSetlist, error)
+	BeginTx(ctx context.Context) (pgx.Tx, error)
+}
+
+// ArchivedSetlist identifies a setlist archived after its gig.
+type ArchivedSetlist struct {
+	SetlistID int
+	BandID    int
+}
+
+type PgGigRepository struct {
+	DB *pgxpool.Pool
+}
+
+const gigColumns = `id, band_id, kind, name, venue, address, starts_at, load_in_at, soundcheck_at, status, notes, created_at`
+
+func scanGig(row pgx.Row, gig *model.Gig) error {
+	return row.Scan(
+		&gig.ID, &gig.BandID, &gig.Kind, &gig.Name, &gig.Venue, &gig.Address, &gig.StartsAt,
+		&gig.LoadInAt, &gig.SoundcheckAt, &gig.Status, &gig.Notes, &gig.CreatedAt,
+	)
+}
+
+func (r *PgGigRepository) BeginTx(ctx context.Context) (pgx.Tx, error) {
+	return r.DB.Begin(ctx)
+}
+
+func (r *PgGigRepository) Create(ctx context.Context, db DBTX, gig *model.Gig) error {
+	query := `
+		INSERT INTO gigs (band_id, kind, name, venue, address, starts_at, load_in_at, soundcheck_at, status, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING ` + gigColumns
+	return scanGig(db.QueryRow(ctx, query,
+		gig.BandID, gig.Kind, gig.Name, gig.Venue, gig.Address, gig.StartsAt, gig.LoadInAt, gig.SoundcheckAt, gig.Status, gig.Notes,
+	), gig)
+}
+
+// Update rewrites a gig. Moving a past gig back into the future makes its
+// setlists eligible for archiving again.
+func (r *PgGigRepository) Update(ctx context.Context, db DBTX, gig *model.Gig) error {
+	query := `
+		UPDATE gigs SET
+			name = $1, venue = $2, address = $3, starts_at = $4, load_in_at = $5,
+			soundcheck_at = $6, status = $7, notes = $8, kind = $9, updated_at = NOW(),
+			setlists_archived = setlists_archived AND $4 <= NOW()
+		WHERE id = $10 AND band_id = $11
+		RETURNING ` + gigColumns
+	return scanGig(db.QueryRow(ctx, query,
+		gig.Name, gig.Venue, gig.Address, gig.StartsAt, gig.LoadInAt,
+		gig.SoundcheckAt, gig.Status, gig.Notes, gig.Kind, gig.ID, gig.BandID,
+	), gig)
+}
+
+func (r *PgGigRepository) Delete(ctx context.Context, id int, bandID int) error {
+	tag, err := r.DB.Exec(ctx, "DELETE FROM gigs WHERE id = $1 AND band_id = $2", id, bandID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func (r *PgGigRepository) GetByID(ctx context.Context, id int, bandID int) (model.Gig, error) {
+	var gig model.Gig
+	query := `SELECT ` + gigColumns + ` FROM gigs WHERE id = $1 AND band_id = $2`
+	err := scanGig(r.DB.QueryRow(ctx, query, id, bandID), &gig)
+	return gig, err
+}
+
+func (r *PgGigRepository) ListByBandID(ctx context.Context, bandID int) ([]model.Gig, error) {
+	gigs := make([]model.Gig, 0)
+	query := `SELECT ` + gigColumns + ` FROM gigs WHERE band_id = $1 ORDER BY starts_at ASC`
+	rows, err := r.DB.Query(ctx, query, bandID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var gig model.Gig
+		if err := scanGig(rows, &gig); err != nil {
+			return gigs, err
+		}
+		gigs = append(gigs, gig)
+	}
+	return gigs, rows.Err()
+}
+
+// ListSetlists returns the setlists of each of the given gigs, in order.
+func (r *PgGigRepository) ListSetlists(ctx context.Context, gigIDs []int) (map[int][]model.GigSetlist, error) {
+	setlists := make(map[int][]model.GigSetlist, len(gigIDs))
+	query := `
+		SELECT gs.gig_id, gs.setlist_id, s.name, s.color, gs.position, gs.starts_at
+		FROM gig_setlists gs
+		JOIN setlists s ON gs.setlist_id = s.id
+		WHERE gs.gig_id = ANY($1) AND s.deleted_at IS NULL
+		ORDER BY gs.gig_id, gs.position
+	`
+	rows, err := r.DB.Query(ctx, query, gigIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var gigID int
+		var setlist model.GigSetlist
+		if err := rows.Scan(&gigID, &setlist.SetlistID, &setlist.Name, &setlist.Color, &setlist.Position, &setlist.StartsAt); err != nil {
+			return setlists, err
+		}
+		setlists[gigID] = append(setlists[gigID], setlist)
+	}
+	return setlists, rows.Err()
+}
+
+func (r *PgGigRepository) ReplaceSetlists(ctx context.Context, db DBTX, gigID int, setlists []model.GigSetlist) error {
+	if _, err := db.Exec(ctx, "DELETE FROM gig_setlists WHERE gig_id = $1", gigID); err != nil {
+		return err
+	}
+	query := `INSERT INTO gig_setlists (gig_id, setlist_id, position, starts_at) VALUES ($1, $2, $3, $4)`
+	for _, setlist := range setlists {
+		if _, err := db.Exec(ctx, query, gigID, setlist.SetlistID, setlist.Position, setlist.StartsAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ArchivePastSetlists archives the setlists of the gigs that started before
+// the given time, once per gig so a setlist restored by hand stays restored.
+// Cancelled gigs and rehearsals are ignored, and so are setlists still planned
+// for a later gig.
+func (r *PgGigRepository) ArchivePastSetlists(ctx context.Context, before time.Time) ([]ArchivedSetlist, error) {
+	query := `
+		WITH past AS (
+			UPDATE gigs SET setlists_archived = TRUE
+			WHERE NOT setlists_archived AND kind = 'gig' AND status <> 'cancelled' AND starts_at < $1
+			RETURNING id
+		)
+		UPDATE setlists s SET is_archived = TRUE, version = s.version + 1
+		FROM gig_setlists gs
+		WHERE gs.gig_id IN (SELECT id FROM past)
+			AND gs.setlist_id = s.id
+			AND NOT s.is_archived
+			AND s.deleted_at IS NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM gig_setlists later
+				JOIN gigs g ON later.gig_id = g.id
+				WHERE later.setlist_id = s.id AND g.kind = 'gig' AND g.status <> 'cancelled' AND g.starts_at >= $1
+			)
+		RETURNING s.id, s.band_id
+	`
+	rows, err := r.DB.Query(ctx, query, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	archived := make([]ArchivedSetlist, 0)
+	for rows.Next() {
+		var setlist ArchivedSetlist
+		if err := rows.Scan(&setlist.SetlistID, &setlist.BandID); err != nil {
+			return archived, err
+		}
+		archived = append(archived, setlist)
+	}
+	return archived, rows.Err()
+}
@@ -9,6 +9,7 @@ import (
 
 type InterludeRepository interface {
 	CreateInterlude(ctx context.Context, interlude model.Interlude) (model.Interlude, error)
+	InsertInterlude(ctx context.Context, db DBTX, interlude model.Interlude) (model.Interlude, error)
 	GetAllInterludesByBandID(ctx context.Context, bandID int) ([]model.Interlude, error)
 	GetInterludeByID(ctx context.Context, id int, bandID int) (model.Interlude, error)
 	UpdateInterlude(ctx context.Context, interlude model.Interlude) (model.Interlude, error)
@@ -19,12 +20,18 @@ type PgInterludeRepository struct {
 }
 
 func (r PgInterludeRepository) CreateInterlude(ctx context.Context, interlude model.Interlude) (model.Interlude, error) {
+	return r.InsertInterlude(ctx, r.DB, interlude)
+}
+
+// InsertInterlude creates an interlude through db, so that it can be part of
+// a larger transaction.
+func (r PgInterludeRepository) InsertInterlude(ctx context.Context, db DBTX, interlude model.Interlude) (model.Interlude, error) {
 	query := `
 		INSERT INTO interludes (band_id, title, speaker, script, duration_seconds)
 		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at
 	`
-	err := r.DB.QueryRow(ctx, query,
+	err := db.QueryRow(ctx, query,
 		interlude.BandID,
 		interlude.Title,
 		interlude.Speaker,
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"setlist/api/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LiveSessionRepository interface {
+	Get(ctx context.Context, setlistID int) (model.LiveSession, error)
+	Start(ctx context.Context, session *model.LiveSession) error
+	MoveTo(ctx context.Context, setlistID int, itemID int) (model.LiveSession, error)
+	Delete(ctx context.Context, setlistID int) error
+}
+
+type PgLiveSessionRepository struct {
+	DB *pgxpool.Pool
+}
+
+func (r *PgLiveSessionRepository) Get(ctx context.Context, setlistID int) (model.LiveSession, error) {
+	var session model.LiveSession
+	query := `
+		SELECT setlist_id, current_item_id, started_by, started_at, item_started_at
+		FROM live_sessions
+		WHERE setlist_id = $1
+	`
+	err := r.DB.QueryRow(ctx, query, setlistID).
+		Scan(&session.SetlistID, &session.CurrentItemID, &session.StartedBy, &session.StartedAt, &session.ItemStartedAt)
+	return session, err
+}
+
+// Start opens a session on the given item. Starting a session that is already
+// running sends it back to that item but keeps its original start time.
+func (r *PgLiveSessionRepository) Start(ctx context.Context, session *model.LiveSession) error {
+	query := `
+		INSERT INTO live_sessions (setlist_id, current_item_id, started_by)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (setlist_id) DO UPDATE
+		SET current_item_id = EXCLUDED.current_item_id, item_started_at = NOW()
+		RETURNING started_by, started_at, item_started_at
+	`
+	return r.DB.QueryRow(ctx, query, session.SetlistID, session.CurrentItemID, session.StartedBy).
+		Scan(&session.StartedBy, &session.StartedAt, &session.ItemStartedAt)
+}
+
+// MoveTo points a running session at another item; it returns pgx.ErrNoRows
+// when the setlist has no session.
+func (r *PgLiveSessionRepository) MoveTo(ctx context.Context, setlistID int, itemID int) (model.LiveSession, error) {
+	var session model.LiveSession
+	query := `
+		UPDATE live_sessions
+		SET current_item_id = $2, item_started_at = NOW()
+		WHERE setlist_id = $1
+		RETURNING setlist_id, current_item_id, started_by, started_at, item_started_at
+	`
+	err := r.DB.QueryRow(ctx, query, setlistID, itemID).
+		Scan(&session.SetlistID, &session.CurrentItemID, &session.StartedBy, &session.StartedAt, &session.ItemStartedAt)
+	return session, err
+}
+
+func (r *PgLiveSessionRepository) Delete(ctx context.Context, setlistID int) error {
+	_, err := r.DB.Exec(ctx, "DELETE FROM live_sessions WHERE setlist_id = $1", setlistID)
+	return err
+}
@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api/repository/calendar_feed_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api/repository/calendar_feed_repository.go -destination=api/repository/mocks/calendar_feed_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	model "setlist/api/model"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCalendarFeedRepository is a mock of CalendarFeedRepository interface.
+type MockCalendarFeedRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCalendarFeedRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockCalendarFeedRepositoryMockRecorder is the mock recorder for MockCalendarFeedRepository.
+type MockCalendarFeedRepositoryMockRecorder struct {
+	mock *MockCalendarFeedRepository
+}
+
+// NewMockCalendarFeedRepository creates a new mock instance.
+func NewMockCalendarFeedRepository(ctrl *gomock.Controller) *MockCalendarFeedRepository {
+	mock := &MockCalendarFeedRepository{ctrl: ctrl}
+	mock.recorder = &MockCalendarFeedRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCalendarFeedRepository) EXPECT() *MockCalendarFeedRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockCalendarFeedRepository) Get(ctx context.Context, userID, bandID int) (model.CalendarFeed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, userID, bandID)
+	ret0, _ := ret[0].(model.CalendarFeed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockCalendarFeedRepositoryMockRecorder) Get(ctx, userID, bandID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockCalendarFeedRepository)(nil).Get), ctx, userID, bandID)
+}
+
+// GetByToken mocks base method.
+func (m *MockCalendarFeedRepository) GetByToken(ctx context.Context, token string) (model.CalendarFeed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByToken", ctx, token)
+	ret0, _ := ret[0].(model.CalendarFeed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByToken indicates an expected call of GetByToken.
+func (mr *MockCalendarFeedRepositoryMockRecorder) GetByToken(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByToken", reflect.TypeOf((*MockCalendarFeedRepository)(nil).GetByToken), ctx, token)
+}
+
+// Save mocks base method.
+func (m *MockCalendarFeedRepository) Save(ctx context.Context, feed *model.CalendarFeed) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, feed)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockCalendarFeedRepositoryMockRecorder) Save(ctx, feed any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockCalendarFeedRepository)(nil).Save), ctx, feed)
+}
@@ -13,6 +13,7 @@ import (
 	context "context"
 	reflect "reflect"
 	model "setlist/api/model"
+	repository "setlist/api/repository"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -86,6 +87,21 @@ func (mr *MockInterludeRepositoryMockRecorder) GetInterludeByID(ctx, id, bandID
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInterludeByID", reflect.TypeOf((*MockInterludeRepository)(nil).GetInterludeByID), ctx, id, bandID)
 }
 
+// InsertInterlude mocks base method.
+func (m *MockInterludeRepository) InsertInterlude(ctx context.Context, db repository.DBTX, interlude model.Interlude) (model.Interlude, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertInterlude", ctx, db, interlude)
+	ret0, _ := ret[0].(model.Interlude)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertInterlude indicates an expected call of InsertInterlude.
+func (mr *MockInterludeRepositoryMockRecorder) InsertInterlude(ctx, db, interlude any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertInterlude", reflect.TypeOf((*MockInterludeRepository)(nil).InsertInterlude), ctx, db, interlude)
+}
+
 // UpdateInterlude mocks base method.
 func (m *MockInterludeRepository) UpdateInterlude(ctx context.Context, interlude model.Interlude) (model.Interlude, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,100 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api/repository/live_session_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api/repository/live_session_repository.go -destination=api/repository/mocks/live_session_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	model "setlist/api/model"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockLiveSessionRepository is a mock of LiveSessionRepository interface.
+type MockLiveSessionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockLiveSessionRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockLiveSessionRepositoryMockRecorder is the mock recorder for MockLiveSessionRepository.
+type MockLiveSessionRepositoryMockRecorder struct {
+	mock *MockLiveSessionRepository
+}
+
+// NewMockLiveSessionRepository creates a new mock instance.
+func NewMockLiveSessionRepository(ctrl *gomock.Controller) *MockLiveSessionRepository {
+	mock := &MockLiveSessionRepository{ctrl: ctrl}
+	mock.recorder = &MockLiveSessionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLiveSessionRepository) EXPECT() *MockLiveSessionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockLiveSessionRepository) Delete(ctx context.Context, setlistID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, setlistID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockLiveSessionRepositoryMockRecorder) Delete(ctx, setlistID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockLiveSessionRepository)(nil).Delete), ctx, setlistID)
+}
+
+// Get mocks base method.
+func (m *MockLiveSessionRepository) Get(ctx context.Context, setlistID int) (model.LiveSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, setlistID)
+	ret0, _ := ret[0].(model.LiveSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockLiveSessionRepositoryMockRecorder) Get(ctx, setlistID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockLiveSessionRepository)(nil).Get), ctx, setlistID)
+}
+
+// MoveTo mocks base method.
+func (m *MockLiveSessionRepository) MoveTo(ctx context.Context, setlistID, itemID int) (model.LiveSession, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoveTo", ctx, setlistID, itemID)
+	ret0, _ := ret[0].(model.LiveSession)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MoveTo indicates an expected call of MoveTo.
+func (mr *MockLiveSessionRepositoryMockRecorder) MoveTo(ctx, setlistID, itemID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoveTo", reflect.TypeOf((*MockLiveSessionRepository)(nil).MoveTo), ctx, setlistID, itemID)
+}
+
+// Start mocks base method.
+func (m *MockLiveSessionRepository) Start(ctx context.Context, session *model.LiveSession) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Start", ctx, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Start indicates an expected call of Start.
+func (mr *MockLiveSessionRepositoryMockRecorder) Start(ctx, session any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockLiveSessionRepository)(nil).Start), ctx, session)
+}
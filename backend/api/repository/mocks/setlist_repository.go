@@ -14,6 +14,7 @@ import (
 	reflect "reflect"
 	model "setlist/api/model"
 	repository "setlist/api/repository"
+	time "time"
 
 	v5 "github.com/jackc/pgx/v5"
 	pgconn "github.com/jackc/pgx/v5/pgconn"
@@ -143,21 +144,6 @@ func (m *MockSetlistRepository) EXPECT() *MockSetlistRepositoryMockRecorder {
 	return m.recorder
 }
 
-// AddItemToSetlist mocks base method.
-func (m *MockSetlistRepository) AddItemToSetlist(ctx context.Context, item model.SetlistItem) (model.SetlistItem, error) {
-	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddItemToSetlist", ctx, item)
-	ret0, _ := ret[0].(model.SetlistItem)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
-}
-
-// AddItemToSetlist indicates an expected call of AddItemToSetlist.
-func (mr *MockSetlistRepositoryMockRecorder) AddItemToSetlist(ctx, item any) *gomock.Call {
-	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddItemToSetlist", reflect.TypeOf((*MockSetlistRepository)(nil).AddItemToSetlist), ctx, item)
-}
-
 // BeginTx mocks base method.
 func (m *MockSetlistRepository) BeginTx(ctx context.Context) (v5.Tx, error) {
 	m.ctrl.T.Helper()
@@ -187,47 +173,90 @@ func (mr *MockSetlistRepositoryMockRecorder) CopyItemsToNewSetlist(ctx, tx, newS
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyItemsToNewSetlist", reflect.TypeOf((*MockSetlistRepository)(nil).CopyItemsToNewSetlist), ctx, tx, newSetlistID, items)
 }
 
+// CreateItemGroup mocks base method.
+func (m *MockSetlistRepository) CreateItemGroup(ctx context.Context, db repository.DBTX, setlistID int, name string, itemIDs []int) (model.SetlistItemGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateItemGroup", ctx, db, setlistID, name, itemIDs)
+	ret0, _ := ret[0].(model.SetlistItemGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateItemGroup indicates an expected call of CreateItemGroup.
+func (mr *MockSetlistRepositoryMockRecorder) CreateItemGroup(ctx, db, setlistID, name, itemIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateItemGroup", reflect.TypeOf((*MockSetlistRepository)(nil).CreateItemGroup), ctx, db, setlistID, name, itemIDs)
+}
+
 // CreateSetlist mocks base method.
-func (m *MockSetlistRepository) CreateSetlist(ctx context.Context, db repository.DBTX, name, color string, bandID int) (model.Setlist, error) {
+func (m *MockSetlistRepository) CreateSetlist(ctx context.Context, db repository.DBTX, name, color string, bandID int, isTemplate bool) (model.Setlist, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateSetlist", ctx, db, name, color, bandID)
+	ret := m.ctrl.Call(m, "CreateSetlist", ctx, db, name, color, bandID, isTemplate)
 	ret0, _ := ret[0].(model.Setlist)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateSetlist indicates an expected call of CreateSetlist.
-func (mr *MockSetlistRepositoryMockRecorder) CreateSetlist(ctx, db, name, color, bandID any) *gomock.Call {
+func (mr *MockSetlistRepositoryMockRecorder) CreateSetlist(ctx, db, name, color, bandID, isTemplate any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSetlist", reflect.TypeOf((*MockSetlistRepository)(nil).CreateSetlist), ctx, db, name, color, bandID, isTemplate)
+}
+
+// DeleteItemGroup mocks base method.
+func (m *MockSetlistRepository) DeleteItemGroup(ctx context.Context, db repository.DBTX, groupID, setlistID, bandID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteItemGroup", ctx, db, groupID, setlistID, bandID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteItemGroup indicates an expected call of DeleteItemGroup.
+func (mr *MockSetlistRepositoryMockRecorder) DeleteItemGroup(ctx, db, groupID, setlistID, bandID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSetlist", reflect.TypeOf((*MockSetlistRepository)(nil).CreateSetlist), ctx, db, name, color, bandID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteItemGroup", reflect.TypeOf((*MockSetlistRepository)(nil).DeleteItemGroup), ctx, db, groupID, setlistID, bandID)
 }
 
-// DeleteSetlist mocks base method.
-func (m *MockSetlistRepository) DeleteSetlist(ctx context.Context, setlistID, bandID int) error {
+// DeleteItems mocks base method.
+func (m *MockSetlistRepository) DeleteItems(ctx context.Context, db repository.DBTX, setlistID int, itemIDs []int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteSetlist", ctx, setlistID, bandID)
+	ret := m.ctrl.Call(m, "DeleteItems", ctx, db, setlistID, itemIDs)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteSetlist indicates an expected call of DeleteSetlist.
-func (mr *MockSetlistRepositoryMockRecorder) DeleteSetlist(ctx, setlistID, bandID any) *gomock.Call {
+// DeleteItems indicates an expected call of DeleteItems.
+func (mr *MockSetlistRepositoryMockRecorder) DeleteItems(ctx, db, setlistID, itemIDs any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSetlist", reflect.TypeOf((*MockSetlistRepository)(nil).DeleteSetlist), ctx, setlistID, bandID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteItems", reflect.TypeOf((*MockSetlistRepository)(nil).DeleteItems), ctx, db, setlistID, itemIDs)
+}
+
+// DeleteItemsBySetlistID mocks base method.
+func (m *MockSetlistRepository) DeleteItemsBySetlistID(ctx context.Context, db repository.DBTX, setlistID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteItemsBySetlistID", ctx, db, setlistID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteItemsBySetlistID indicates an expected call of DeleteItemsBySetlistID.
+func (mr *MockSetlistRepositoryMockRecorder) DeleteItemsBySetlistID(ctx, db, setlistID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteItemsBySetlistID", reflect.TypeOf((*MockSetlistRepository)(nil).DeleteItemsBySetlistID), ctx, db, setlistID)
 }
 
 // DeleteSetlistItem mocks base method.
-func (m *MockSetlistRepository) DeleteSetlistItem(ctx context.Context, itemID, bandID int) error {
+func (m *MockSetlistRepository) DeleteSetlistItem(ctx context.Context, db repository.DBTX, itemID, setlistID int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteSetlistItem", ctx, itemID, bandID)
+	ret := m.ctrl.Call(m, "DeleteSetlistItem", ctx, db, itemID, setlistID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteSetlistItem indicates an expected call of DeleteSetlistItem.
-func (mr *MockSetlistRepositoryMockRecorder) DeleteSetlistItem(ctx, itemID, bandID any) *gomock.Call {
+func (mr *MockSetlistRepositoryMockRecorder) DeleteSetlistItem(ctx, db, itemID, setlistID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSetlistItem", reflect.TypeOf((*MockSetlistRepository)(nil).DeleteSetlistItem), ctx, itemID, bandID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSetlistItem", reflect.TypeOf((*MockSetlistRepository)(nil).DeleteSetlistItem), ctx, db, itemID, setlistID)
 }
 
 // GetDB mocks base method.
@@ -244,6 +273,51 @@ func (mr *MockSetlistRepositoryMockRecorder) GetDB() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDB", reflect.TypeOf((*MockSetlistRepository)(nil).GetDB))
 }
 
+// GetItemSetlistID mocks base method.
+func (m *MockSetlistRepository) GetItemSetlistID(ctx context.Context, itemID, bandID int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetItemSetlistID", ctx, itemID, bandID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetItemSetlistID indicates an expected call of GetItemSetlistID.
+func (mr *MockSetlistRepositoryMockRecorder) GetItemSetlistID(ctx, itemID, bandID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetItemSetlistID", reflect.TypeOf((*MockSetlistRepository)(nil).GetItemSetlistID), ctx, itemID, bandID)
+}
+
+// GetItems mocks base method.
+func (m *MockSetlistRepository) GetItems(ctx context.Context, db repository.DBTX, setlistID int) ([]model.SetlistItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetItems", ctx, db, setlistID)
+	ret0, _ := ret[0].([]model.SetlistItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetItems indicates an expected call of GetItems.
+func (mr *MockSetlistRepositoryMockRecorder) GetItems(ctx, db, setlistID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetItems", reflect.TypeOf((*MockSetlistRepository)(nil).GetItems), ctx, db, setlistID)
+}
+
+// GetItemsByIDs mocks base method.
+func (m *MockSetlistRepository) GetItemsByIDs(ctx context.Context, db repository.DBTX, setlistID int, itemIDs []int) ([]model.SetlistItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetItemsByIDs", ctx, db, setlistID, itemIDs)
+	ret0, _ := ret[0].([]model.SetlistItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetItemsByIDs indicates an expected call of GetItemsByIDs.
+func (mr *MockSetlistRepositoryMockRecorder) GetItemsByIDs(ctx, db, setlistID, itemIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetItemsByIDs", reflect.TypeOf((*MockSetlistRepository)(nil).GetItemsByIDs), ctx, db, setlistID, itemIDs)
+}
+
 // GetSetlistByID mocks base method.
 func (m *MockSetlistRepository) GetSetlistByID(ctx context.Context, id, bandID int) (model.Setlist, error) {
 	m.ctrl.T.Helper()
@@ -274,61 +348,244 @@ func (mr *MockSetlistRepositoryMockRecorder) GetSetlistItemsBySetlistID(ctx, set
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSetlistItemsBySetlistID", reflect.TypeOf((*MockSetlistRepository)(nil).GetSetlistItemsBySetlistID), ctx, setlistID)
 }
 
-// GetSetlistsByBandID mocks base method.
-func (m *MockSetlistRepository) GetSetlistsByBandID(ctx context.Context, bandID int) ([]model.Setlist, error) {
+// GetTemplatesByBandID mocks base method.
+func (m *MockSetlistRepository) GetTemplatesByBandID(ctx context.Context, bandID int) ([]model.Setlist, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSetlistsByBandID", ctx, bandID)
+	ret := m.ctrl.Call(m, "GetTemplatesByBandID", ctx, bandID)
 	ret0, _ := ret[0].([]model.Setlist)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetSetlistsByBandID indicates an expected call of GetSetlistsByBandID.
-func (mr *MockSetlistRepositoryMockRecorder) GetSetlistsByBandID(ctx, bandID any) *gomock.Call {
+// GetTemplatesByBandID indicates an expected call of GetTemplatesByBandID.
+func (mr *MockSetlistRepositoryMockRecorder) GetTemplatesByBandID(ctx, bandID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSetlistsByBandID", reflect.TypeOf((*MockSetlistRepository)(nil).GetSetlistsByBandID), ctx, bandID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTemplatesByBandID", reflect.TypeOf((*MockSetlistRepository)(nil).GetTemplatesByBandID), ctx, bandID)
 }
 
-// UpdateItemOrder mocks base method.
-func (m *MockSetlistRepository) UpdateItemOrder(ctx context.Context, setlistID int, itemIDs []int) error {
+// GetTrashedSetlists mocks base method.
+func (m *MockSetlistRepository) GetTrashedSetlists(ctx context.Context, bandID int) ([]model.Setlist, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateItemOrder", ctx, setlistID, itemIDs)
+	ret := m.ctrl.Call(m, "GetTrashedSetlists", ctx, bandID)
+	ret0, _ := ret[0].([]model.Setlist)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrashedSetlists indicates an expected call of GetTrashedSetlists.
+func (mr *MockSetlistRepositoryMockRecorder) GetTrashedSetlists(ctx, bandID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrashedSetlists", reflect.TypeOf((*MockSetlistRepository)(nil).GetTrashedSetlists), ctx, bandID)
+}
+
+// InsertItems mocks base method.
+func (m *MockSetlistRepository) InsertItems(ctx context.Context, db repository.DBTX, setlistID int, index *int, items []model.SetlistItem) ([]model.SetlistItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertItems", ctx, db, setlistID, index, items)
+	ret0, _ := ret[0].([]model.SetlistItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertItems indicates an expected call of InsertItems.
+func (mr *MockSetlistRepositoryMockRecorder) InsertItems(ctx, db, setlistID, index, items any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertItems", reflect.TypeOf((*MockSetlistRepository)(nil).InsertItems), ctx, db, setlistID, index, items)
+}
+
+// LockSetlists mocks base method.
+func (m *MockSetlistRepository) LockSetlists(ctx context.Context, db repository.DBTX, setlistIDs ...int) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, db}
+	for _, a := range setlistIDs {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "LockSetlists", varargs...)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// LockSetlists indicates an expected call of LockSetlists.
+func (mr *MockSetlistRepositoryMockRecorder) LockSetlists(ctx, db any, setlistIDs ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, db}, setlistIDs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockSetlists", reflect.TypeOf((*MockSetlistRepository)(nil).LockSetlists), varargs...)
+}
+
+// MissingItemReferences mocks base method.
+func (m *MockSetlistRepository) MissingItemReferences(ctx context.Context, db repository.DBTX, bandID int, items []model.SetlistItem) ([]model.SetlistItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MissingItemReferences", ctx, db, bandID, items)
+	ret0, _ := ret[0].([]model.SetlistItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MissingItemReferences indicates an expected call of MissingItemReferences.
+func (mr *MockSetlistRepositoryMockRecorder) MissingItemReferences(ctx, db, bandID, items any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MissingItemReferences", reflect.TypeOf((*MockSetlistRepository)(nil).MissingItemReferences), ctx, db, bandID, items)
+}
+
+// PurgeSetlist mocks base method.
+func (m *MockSetlistRepository) PurgeSetlist(ctx context.Context, setlistID, bandID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeSetlist", ctx, setlistID, bandID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeSetlist indicates an expected call of PurgeSetlist.
+func (mr *MockSetlistRepositoryMockRecorder) PurgeSetlist(ctx, setlistID, bandID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeSetlist", reflect.TypeOf((*MockSetlistRepository)(nil).PurgeSetlist), ctx, setlistID, bandID)
+}
+
+// PurgeTrashedBefore mocks base method.
+func (m *MockSetlistRepository) PurgeTrashedBefore(ctx context.Context, before time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeTrashedBefore", ctx, before)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeTrashedBefore indicates an expected call of PurgeTrashedBefore.
+func (mr *MockSetlistRepositoryMockRecorder) PurgeTrashedBefore(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeTrashedBefore", reflect.TypeOf((*MockSetlistRepository)(nil).PurgeTrashedBefore), ctx, before)
+}
+
+// RenameItemGroup mocks base method.
+func (m *MockSetlistRepository) RenameItemGroup(ctx context.Context, db repository.DBTX, groupID, setlistID, bandID int, name string) (model.SetlistItemGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameItemGroup", ctx, db, groupID, setlistID, bandID, name)
+	ret0, _ := ret[0].(model.SetlistItemGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenameItemGroup indicates an expected call of RenameItemGroup.
+func (mr *MockSetlistRepositoryMockRecorder) RenameItemGroup(ctx, db, groupID, setlistID, bandID, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameItemGroup", reflect.TypeOf((*MockSetlistRepository)(nil).RenameItemGroup), ctx, db, groupID, setlistID, bandID, name)
+}
+
+// RepairPositions mocks base method.
+func (m *MockSetlistRepository) RepairPositions(ctx context.Context, db repository.DBTX, bandID int) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RepairPositions", ctx, db, bandID)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RepairPositions indicates an expected call of RepairPositions.
+func (mr *MockSetlistRepositoryMockRecorder) RepairPositions(ctx, db, bandID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepairPositions", reflect.TypeOf((*MockSetlistRepository)(nil).RepairPositions), ctx, db, bandID)
+}
+
+// RestoreSetlist mocks base method.
+func (m *MockSetlistRepository) RestoreSetlist(ctx context.Context, setlistID, bandID int) (model.Setlist, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreSetlist", ctx, setlistID, bandID)
+	ret0, _ := ret[0].(model.Setlist)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreSetlist indicates an expected call of RestoreSetlist.
+func (mr *MockSetlistRepositoryMockRecorder) RestoreSetlist(ctx, setlistID, bandID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreSetlist", reflect.TypeOf((*MockSetlistRepository)(nil).RestoreSetlist), ctx, setlistID, bandID)
+}
+
+// SearchSetlists mocks base method.
+func (m *MockSetlistRepository) SearchSetlists(ctx context.Context, bandID int, filter repository.SetlistFilter) ([]model.Setlist, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchSetlists", ctx, bandID, filter)
+	ret0, _ := ret[0].([]model.Setlist)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchSetlists indicates an expected call of SearchSetlists.
+func (mr *MockSetlistRepositoryMockRecorder) SearchSetlists(ctx, bandID, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchSetlists", reflect.TypeOf((*MockSetlistRepository)(nil).SearchSetlists), ctx, bandID, filter)
+}
+
+// SetSetlistLock mocks base method.
+func (m *MockSetlistRepository) SetSetlistLock(ctx context.Context, db repository.DBTX, setlistID, bandID int, locked bool, userID int) (model.Setlist, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSetlistLock", ctx, db, setlistID, bandID, locked, userID)
+	ret0, _ := ret[0].(model.Setlist)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetSetlistLock indicates an expected call of SetSetlistLock.
+func (mr *MockSetlistRepositoryMockRecorder) SetSetlistLock(ctx, db, setlistID, bandID, locked, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSetlistLock", reflect.TypeOf((*MockSetlistRepository)(nil).SetSetlistLock), ctx, db, setlistID, bandID, locked, userID)
+}
+
+// TrashSetlist mocks base method.
+func (m *MockSetlistRepository) TrashSetlist(ctx context.Context, setlistID, bandID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TrashSetlist", ctx, setlistID, bandID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TrashSetlist indicates an expected call of TrashSetlist.
+func (mr *MockSetlistRepositoryMockRecorder) TrashSetlist(ctx, setlistID, bandID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TrashSetlist", reflect.TypeOf((*MockSetlistRepository)(nil).TrashSetlist), ctx, setlistID, bandID)
+}
+
+// UpdateItemOrder mocks base method.
+func (m *MockSetlistRepository) UpdateItemOrder(ctx context.Context, db repository.DBTX, setlistID int, itemIDs []int, expectedVersion *int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateItemOrder", ctx, db, setlistID, itemIDs, expectedVersion)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // UpdateItemOrder indicates an expected call of UpdateItemOrder.
-func (mr *MockSetlistRepositoryMockRecorder) UpdateItemOrder(ctx, setlistID, itemIDs any) *gomock.Call {
+func (mr *MockSetlistRepositoryMockRecorder) UpdateItemOrder(ctx, db, setlistID, itemIDs, expectedVersion any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateItemOrder", reflect.TypeOf((*MockSetlistRepository)(nil).UpdateItemOrder), ctx, setlistID, itemIDs)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateItemOrder", reflect.TypeOf((*MockSetlistRepository)(nil).UpdateItemOrder), ctx, db, setlistID, itemIDs, expectedVersion)
 }
 
 // UpdateSetlist mocks base method.
-func (m *MockSetlistRepository) UpdateSetlist(ctx context.Context, setlist model.Setlist) (model.Setlist, error) {
+func (m *MockSetlistRepository) UpdateSetlist(ctx context.Context, db repository.DBTX, setlist model.Setlist, expectedVersion *int) (model.Setlist, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateSetlist", ctx, setlist)
+	ret := m.ctrl.Call(m, "UpdateSetlist", ctx, db, setlist, expectedVersion)
 	ret0, _ := ret[0].(model.Setlist)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // UpdateSetlist indicates an expected call of UpdateSetlist.
-func (mr *MockSetlistRepositoryMockRecorder) UpdateSetlist(ctx, setlist any) *gomock.Call {
+func (mr *MockSetlistRepositoryMockRecorder) UpdateSetlist(ctx, db, setlist, expectedVersion any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSetlist", reflect.TypeOf((*MockSetlistRepository)(nil).UpdateSetlist), ctx, setlist)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSetlist", reflect.TypeOf((*MockSetlistRepository)(nil).UpdateSetlist), ctx, db, setlist, expectedVersion)
 }
 
 // UpdateSetlistItem mocks base method.
-func (m *MockSetlistRepository) UpdateSetlistItem(ctx context.Context, itemID, bandID int, notes *string) (model.SetlistItem, error) {
+func (m *MockSetlistRepository) UpdateSetlistItem(ctx context.Context, db repository.DBTX, itemID, setlistID, bandID int, update repository.SetlistItemUpdate) (model.SetlistItem, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateSetlistItem", ctx, itemID, bandID, notes)
+	ret := m.ctrl.Call(m, "UpdateSetlistItem", ctx, db, itemID, setlistID, bandID, update)
 	ret0, _ := ret[0].(model.SetlistItem)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // UpdateSetlistItem indicates an expected call of UpdateSetlistItem.
-func (mr *MockSetlistRepositoryMockRecorder) UpdateSetlistItem(ctx, itemID, bandID, notes any) *gomock.Call {
+func (mr *MockSetlistRepositoryMockRecorder) UpdateSetlistItem(ctx, db, itemID, setlistID, bandID, update any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSetlistItem", reflect.TypeOf((*MockSetlistRepository)(nil).UpdateSetlistItem), ctx, itemID, bandID, notes)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSetlistItem", reflect.TypeOf((*MockSetlistRepository)(nil).UpdateSetlistItem), ctx, db, itemID, setlistID, bandID, update)
 }
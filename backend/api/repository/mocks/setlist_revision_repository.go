@@ -0,0 +1,87 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: api/repository/setlist_revision_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=api/repository/setlist_revision_repository.go -destination=api/repository/mocks/setlist_revision_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	model "setlist/api/model"
+	repository "setlist/api/repository"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSetlistRevisionRepository is a mock of SetlistRevisionRepository interface.
+type MockSetlistRevisionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSetlistRevisionRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockSetlistRevisionRepositoryMockRecorder is the mock recorder for MockSetlistRevisionRepository.
+type MockSetlistRevisionRepositoryMockRecorder struct {
+	mock *MockSetlistRevisionRepository
+}
+
+// NewMockSetlistRevisionRepository creates a new mock instance.
+func NewMockSetlistRevisionRepository(ctrl *gomock.Controller) *MockSetlistRevisionRepository {
+	mock := &MockSetlistRevisionRepository{ctrl: ctrl}
+	mock.recorder = &MockSetlistRevisionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSetlistRevisionRepository) EXPECT() *MockSetlistRevisionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetByID mocks base method.
+func (m *MockSetlistRevisionRepository) GetByID(ctx context.Context, setlistID, revisionID int) (model.SetlistRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, setlistID, revisionID)
+	ret0, _ := ret[0].(model.SetlistRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockSetlistRevisionRepositoryMockRecorder) GetByID(ctx, setlistID, revisionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockSetlistRevisionRepository)(nil).GetByID), ctx, setlistID, revisionID)
+}
+
+// ListBySetlistID mocks base method.
+func (m *MockSetlistRevisionRepository) ListBySetlistID(ctx context.Context, setlistID int) ([]model.SetlistRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBySetlistID", ctx, setlistID)
+	ret0, _ := ret[0].([]model.SetlistRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBySetlistID indicates an expected call of ListBySetlistID.
+func (mr *MockSetlistRevisionRepositoryMockRecorder) ListBySetlistID(ctx, setlistID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBySetlistID", reflect.TypeOf((*MockSetlistRevisionRepository)(nil).ListBySetlistID), ctx, setlistID)
+}
+
+// Record mocks base method.
+func (m *MockSetlistRevisionRepository) Record(ctx context.Context, db repository.DBTX, setlistID, authorID int, action string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", ctx, db, setlistID, authorID, action)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockSetlistRevisionRepositoryMockRecorder) Record(ctx, db, setlistID, authorID, action any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockSetlistRevisionRepository)(nil).Record), ctx, db, setlistID, authorID, action)
+}
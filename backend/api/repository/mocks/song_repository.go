@@ -13,6 +13,7 @@ import (
 	context "context"
 	reflect "reflect"
 	model "setlist/api/model"
+	repository "setlist/api/repository"
 
 	gomock "go.uber.org/mock/gomock"
 )
@@ -86,6 +87,37 @@ func (mr *MockSongRepositoryMockRecorder) GetSongByID(ctx, id, bandID any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSongByID", reflect.TypeOf((*MockSongRepository)(nil).GetSongByID), ctx, id, bandID)
 }
 
+// GetSongStats mocks base method.
+func (m *MockSongRepository) GetSongStats(ctx context.Context, bandID int, filter repository.SongStatsFilter) ([]model.SongStats, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSongStats", ctx, bandID, filter)
+	ret0, _ := ret[0].([]model.SongStats)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetSongStats indicates an expected call of GetSongStats.
+func (mr *MockSongRepositoryMockRecorder) GetSongStats(ctx, bandID, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSongStats", reflect.TypeOf((*MockSongRepository)(nil).GetSongStats), ctx, bandID, filter)
+}
+
+// InsertSong mocks base method.
+func (m *MockSongRepository) InsertSong(ctx context.Context, db repository.DBTX, song model.Song) (model.Song, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertSong", ctx, db, song)
+	ret0, _ := ret[0].(model.Song)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertSong indicates an expected call of InsertSong.
+func (mr *MockSongRepositoryMockRecorder) InsertSong(ctx, db, song any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertSong", reflect.TypeOf((*MockSongRepository)(nil).InsertSong), ctx, db, song)
+}
+
 // SoftDeleteSong mocks base method.
 func (m *MockSongRepository) SoftDeleteSong(ctx context.Context, id, bandID int) error {
 	m.ctrl.T.Helper()
@@ -101,16 +133,16 @@ func (mr *MockSongRepositoryMockRecorder) SoftDeleteSong(ctx, id, bandID any) *g
 }
 
 // UpdateSong mocks base method.
-func (m *MockSongRepository) UpdateSong(ctx context.Context, song model.Song) (model.Song, error) {
+func (m *MockSongRepository) UpdateSong(ctx context.Context, song model.Song, expectedVersion *int) (model.Song, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateSong", ctx, song)
+	ret := m.ctrl.Call(m, "UpdateSong", ctx, song, expectedVersion)
 	ret0, _ := ret[0].(model.Song)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // UpdateSong indicates an expected call of UpdateSong.
-func (mr *MockSongRepositoryMockRecorder) UpdateSong(ctx, song any) *gomock.Call {
+func (mr *MockSongRepositoryMockRecorder) UpdateSong(ctx, song, expectedVersion any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSong", reflect.TypeOf((*MockSongRepository)(nil).UpdateSong), ctx, song)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSong", reflect.TypeOf((*MockSongRepository)(nil).UpdateSong), ctx, song, expectedVersion)
 }
@@ -3,13 +3,24 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"setlist/api/model"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var (
+	ErrVersionConflict = errors.New("setlist was modified since the expected version")
+	ErrItemSetMismatch = errors.New("item IDs do not match the items of the setlist")
+	ErrSetlistLocked   = errors.New("setlist is locked")
+)
+
 type DBTX interface {
 	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
 	Query(context.Context, string, ...interface{}) (pgx.Rows, error)
@@ -18,17 +29,34 @@ type DBTX interface {
 }
 
 type SetlistRepository interface {
-	CreateSetlist(ctx context.Context, db DBTX, name, color string, bandID int) (model.Setlist, error)
-	UpdateSetlist(ctx context.Context, setlist model.Setlist) (model.Setlist, error)
-	GetSetlistsByBandID(ctx context.Context, bandID int) ([]model.Setlist, error)
+	CreateSetlist(ctx context.Context, db DBTX, name, color string, bandID int, isTemplate bool) (model.Setlist, error)
+	UpdateSetlist(ctx context.Context, db DBTX, setlist model.Setlist, expectedVersion *int) (model.Setlist, error)
+	SearchSetlists(ctx context.Context, bandID int, filter SetlistFilter) ([]model.Setlist, error)
+	GetTemplatesByBandID(ctx context.Context, bandID int) ([]model.Setlist, error)
 	GetSetlistByID(ctx context.Context, id int, bandID int) (model.Setlist, error)
-	DeleteSetlist(ctx context.Context, setlistID int, bandID int) error
+	TrashSetlist(ctx context.Context, setlistID int, bandID int) error
+	GetTrashedSetlists(ctx context.Context, bandID int) ([]model.Setlist, error)
+	RestoreSetlist(ctx context.Context, setlistID int, bandID int) (model.Setlist, error)
+	PurgeSetlist(ctx context.Context, setlistID int, bandID int) error
+	PurgeTrashedBefore(ctx context.Context, before time.Time) (int, error)
+	SetSetlistLock(ctx context.Context, db DBTX, setlistID int, bandID int, locked bool, userID int) (model.Setlist, error)
+	GetItemSetlistID(ctx context.Context, itemID int, bandID int) (int, error)
 	GetSetlistItemsBySetlistID(ctx context.Context, setlistID int) ([]model.SetlistItem, error)
-	AddItemToSetlist(ctx context.Context, item model.SetlistItem) (model.SetlistItem, error)
-	UpdateItemOrder(ctx context.Context, setlistID int, itemIDs []int) error
-	UpdateSetlistItem(ctx context.Context, itemID int, bandID int, notes *string) (model.SetlistItem, error)
-	DeleteSetlistItem(ctx context.Context, itemID int, bandID int) error
+	GetItems(ctx context.Context, db DBTX, setlistID int) ([]model.SetlistItem, error)
+	LockSetlists(ctx context.Context, db DBTX, setlistIDs ...int) error
+	InsertItems(ctx context.Context, db DBTX, setlistID int, index *int, items []model.SetlistItem) ([]model.SetlistItem, error)
+	GetItemsByIDs(ctx context.Context, db DBTX, setlistID int, itemIDs []int) ([]model.SetlistItem, error)
+	DeleteItems(ctx context.Context, db DBTX, setlistID int, itemIDs []int) error
+	UpdateItemOrder(ctx context.Context, db DBTX, setlistID int, itemIDs []int, expectedVersion *int) (int, error)
+	UpdateSetlistItem(ctx context.Context, db DBTX, itemID int, setlistID int, bandID int, update SetlistItemUpdate) (model.SetlistItem, error)
+	DeleteSetlistItem(ctx context.Context, db DBTX, itemID int, setlistID int) error
+	RepairPositions(ctx context.Context, db DBTX, bandID int) ([]int, error)
+	DeleteItemsBySetlistID(ctx context.Context, db DBTX, setlistID int) error
 	CopyItemsToNewSetlist(ctx context.Context, tx DBTX, newSetlistID int, items []model.SetlistItem) error
+	MissingItemReferences(ctx context.Context, db DBTX, bandID int, items []model.SetlistItem) ([]model.SetlistItem, error)
+	CreateItemGroup(ctx context.Context, db DBTX, setlistID int, name string, itemIDs []int) (model.SetlistItemGroup, error)
+	RenameItemGroup(ctx context.Context, db DBTX, groupID int, setlistID int, bandID int, name string) (model.SetlistItemGroup, error)
+	DeleteItemGroup(ctx context.Context, db DBTX, groupID int, setlistID int, bandID int) error
 	BeginTx(ctx context.Context) (pgx.Tx, error)
 	GetDB() *pgxpool.Pool
 }
@@ -45,38 +73,152 @@ func (r PgSetlistRepository) GetDB() *pgxpool.Pool {
 	return r.DB
 }
 
-func (r PgSetlistRepository) CreateSetlist(ctx context.Context, db DBTX, name, color string, bandID int) (model.Setlist, error) {
+// setlistColumns are the columns read by scanSetlist.
+const setlistColumns = `id, band_id, name, color, is_archived, is_template, created_at, version, is_locked, locked_at, locked_by`
+
+// scanSetlist reads the setlistColumns of a row, then any extra columns into
+// extra.
+func scanSetlist(row pgx.Row, setlist *model.Setlist, extra ...any) error {
+	return row.Scan(append([]any{
+		&setlist.ID, &setlist.BandID, &setlist.Name, &setlist.Color, &setlist.IsArchived, &setlist.IsTemplate,
+		&setlist.CreatedAt, &setlist.Version, &setlist.IsLocked, &setlist.LockedAt, &setlist.LockedBy,
+	}, extra...)...)
+}
+
+func (r PgSetlistRepository) CreateSetlist(ctx context.Context, db DBTX, name, color string, bandID int, isTemplate bool) (model.Setlist, error) {
 	var setlist model.Setlist
 	query := `
-		INSERT INTO setlists (name, color, band_id)
-		VALUES ($1, $2, $3)
-		RETURNING id, band_id, name, color, is_archived, created_at
+		INSERT INTO setlists (name, color, band_id, is_template)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + setlistColumns + `
 	`
-	err := db.QueryRow(ctx, query, name, color, bandID).Scan(
-		&setlist.ID, &setlist.BandID, &setlist.Name, &setlist.Color, &setlist.IsArchived, &setlist.CreatedAt,
-	)
+	err := scanSetlist(db.QueryRow(ctx, query, name, color, bandID, isTemplate), &setlist)
 	return setlist, err
 }
 
-func (r PgSetlistRepository) UpdateSetlist(ctx context.Context, setlist model.Setlist) (model.Setlist, error) {
+// UpdateSetlist bumps the version of the setlist. With an expectedVersion, the
+// update only applies to that version and pgx.ErrNoRows is returned otherwise.
+func (r PgSetlistRepository) UpdateSetlist(ctx context.Context, db DBTX, setlist model.Setlist, expectedVersion *int) (model.Setlist, error) {
 	query := `
 		UPDATE setlists
-		SET name = $1, color = $2, is_archived = $3
-		WHERE id = $4 AND band_id = $5
-		RETURNING id, band_id, name, color, is_archived, created_at
+		SET name = $1, color = $2, is_archived = $3, version = version + 1
+		WHERE id = $4 AND band_id = $5 AND deleted_at IS NULL AND ($6::int IS NULL OR version = $6)
+		RETURNING ` + setlistColumns + `
 	`
-	err := r.DB.QueryRow(ctx, query, setlist.Name, setlist.Color, setlist.IsArchived, setlist.ID, setlist.BandID).Scan(
-		&setlist.ID, &setlist.BandID, &setlist.Name, &setlist.Color, &setlist.IsArchived, &setlist.CreatedAt,
-	)
+	err := scanSetlist(db.QueryRow(ctx, query, setlist.Name, setlist.Color, setlist.IsArchived, setlist.ID, setlist.BandID, expectedVersion), &setlist)
 	return setlist, err
 }
 
-func (r PgSetlistRepository) GetSetlistsByBandID(ctx context.Context, bandID int) ([]model.Setlist, error) {
+const (
+	SetlistSortCreatedDesc = "created_desc"
+	SetlistSortCreatedAsc  = "created_asc"
+	SetlistSortNameAsc     = "name_asc"
+	SetlistSortNameDesc    = "name_desc"
+)
+
+// SetlistFilter selects the real setlists of a band. Nil and empty fields do
+// not filter. After resumes a listing after the given setlist, which Sort
+// must be the same as for the previous page; Limit 0 returns every setlist.
+type SetlistFilter struct {
+	Archived *bool
+	Search   string
+	Color    string
+	GigFrom  *time.Time
+	GigTo    *time.Time
+	Sort     string
+	After    *SetlistCursor
+	Limit    int
+}
+
+// SetlistCursor is the position of a setlist in a sorted listing: its sort
+// value (creation time in RFC 3339 or name) and its ID.
+type SetlistCursor struct {
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// SearchSetlists returns the real setlists of the band matching the filter;
+// templates are listed by GetTemplatesByBandID.
+func (r PgSetlistRepository) SearchSetlists(ctx context.Context, bandID int, filter SetlistFilter) ([]model.Setlist, error) {
+	args := []any{bandID}
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	conditions := []string{"band_id = $1", "NOT is_template", "deleted_at IS NULL"}
+	if filter.Archived != nil {
+		conditions = append(conditions, "is_archived = "+arg(*filter.Archived))
+	}
+	if filter.Search != "" {
+		conditions = append(conditions, "name ILIKE '%' || "+arg(likeEscaper.Replace(filter.Search))+" || '%'")
+	}
+	if filter.Color != "" {
+		conditions = append(conditions, "LOWER(color) = LOWER("+arg(filter.Color)+")")
+	}
+	if filter.GigFrom != nil || filter.GigTo != nil {
+		gig := "SELECT 1 FROM gig_setlists gs JOIN gigs g ON gs.gig_id = g.id WHERE gs.setlist_id = setlists.id"
+		if filter.GigFrom != nil {
+			gig += " AND g.starts_at >= " + arg(*filter.GigFrom)
+		}
+		if filter.GigTo != nil {
+			gig += " AND g.starts_at < " + arg(*filter.GigTo)
+		}
+		conditions = append(conditions, "EXISTS ("+gig+")")
+	}
+
+	column, direction, cast := "created_at", "DESC", "::timestamptz"
+	switch filter.Sort {
+	case SetlistSortCreatedAsc:
+		direction = "ASC"
+	case SetlistSortNameAsc:
+		column, direction, cast = "name", "ASC", ""
+	case SetlistSortNameDesc:
+		column, direction, cast = "name", "DESC", ""
+	}
+	if filter.After != nil {
+		comparison := ">"
+		if direction == "DESC" {
+			comparison = "<"
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s%s, %s)",
+			column, comparison, arg(filter.After.Value), cast, arg(filter.After.ID)))
+	}
+
+	query := `
+		SELECT ` + setlistColumns + `
+		FROM setlists
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY ` + column + " " + direction + ", id " + direction
+	if filter.Limit > 0 {
+		query += " LIMIT " + arg(filter.Limit)
+	}
+
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	setlists := make([]model.Setlist, 0)
+	for rows.Next() {
+		var setlist model.Setlist
+		if err := scanSetlist(rows, &setlist); err != nil {
+			return setlists, err
+		}
+		setlists = append(setlists, setlist)
+	}
+	return setlists, rows.Err()
+}
+
+func (r PgSetlistRepository) GetTemplatesByBandID(ctx context.Context, bandID int) ([]model.Setlist, error) {
 	setlists := make([]model.Setlist, 0)
 	query := `
-		SELECT id, band_id, name, color, is_archived, created_at
+		SELECT ` + setlistColumns + `
 		FROM setlists
-		WHERE band_id = $1
+		WHERE band_id = $1 AND is_template AND deleted_at IS NULL
 		ORDER BY created_at DESC
 	`
 	rows, err := r.DB.Query(ctx, query, bandID)
@@ -87,7 +229,7 @@ func (r PgSetlistRepository) GetSetlistsByBandID(ctx context.Context, bandID int
 
 	for rows.Next() {
 		var setlist model.Setlist
-		if err := rows.Scan(&setlist.ID, &setlist.BandID, &setlist.Name, &setlist.Color, &setlist.IsArchived, &setlist.CreatedAt); err != nil {
+		if err := scanSetlist(rows, &setlist); err != nil {
 			return setlists, err
 		}
 		setlists = append(setlists, setlist)
@@ -98,13 +240,82 @@ func (r PgSetlistRepository) GetSetlistsByBandID(ctx context.Context, bandID int
 
 func (r PgSetlistRepository) GetSetlistByID(ctx context.Context, id int, bandID int) (model.Setlist, error) {
 	var setlist model.Setlist
-	query := `SELECT id, band_id, name, color, is_archived, created_at FROM setlists WHERE id = $1 AND band_id = $2`
-	err := r.DB.QueryRow(ctx, query, id, bandID).Scan(&setlist.ID, &setlist.BandID, &setlist.Name, &setlist.Color, &setlist.IsArchived, &setlist.CreatedAt)
+	query := `SELECT ` + setlistColumns + ` FROM setlists WHERE id = $1 AND band_id = $2 AND deleted_at IS NULL`
+	err := scanSetlist(r.DB.QueryRow(ctx, query, id, bandID), &setlist)
+	return setlist, err
+}
+
+// SetSetlistLock locks or unlocks a setlist, noting who locked it.
+func (r PgSetlistRepository) SetSetlistLock(ctx context.Context, db DBTX, setlistID int, bandID int, locked bool, userID int) (model.Setlist, error) {
+	var setlist model.Setlist
+	query := `
+		UPDATE setlists SET
+			is_locked = $3,
+			locked_at = CASE WHEN $3 THEN NOW() END,
+			locked_by = CASE WHEN $3 THEN $4::int END,
+			version = version + 1
+		WHERE id = $1 AND band_id = $2 AND deleted_at IS NULL
+		RETURNING ` + setlistColumns + `
+	`
+	err := scanSetlist(db.QueryRow(ctx, query, setlistID, bandID, locked, userID), &setlist)
+	return setlist, err
+}
+
+// TrashSetlist moves a setlist to the trash; its items are kept so it can be
+// restored.
+func (r PgSetlistRepository) TrashSetlist(ctx context.Context, setlistID int, bandID int) error {
+	query := `UPDATE setlists SET deleted_at = NOW(), version = version + 1 WHERE id = $1 AND band_id = $2 AND deleted_at IS NULL`
+	cmdTag, err := r.DB.Exec(ctx, query, setlistID, bandID)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetTrashedSetlists returns the setlists and templates in the trash of the
+// band, last deleted first.
+func (r PgSetlistRepository) GetTrashedSetlists(ctx context.Context, bandID int) ([]model.Setlist, error) {
+	setlists := make([]model.Setlist, 0)
+	query := `
+		SELECT ` + setlistColumns + `, deleted_at
+		FROM setlists
+		WHERE band_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id DESC
+	`
+	rows, err := r.DB.Query(ctx, query, bandID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var setlist model.Setlist
+		if err := scanSetlist(rows, &setlist, &setlist.DeletedAt); err != nil {
+			return setlists, err
+		}
+		setlists = append(setlists, setlist)
+	}
+	return setlists, rows.Err()
+}
+
+// RestoreSetlist takes a setlist out of the trash.
+func (r PgSetlistRepository) RestoreSetlist(ctx context.Context, setlistID int, bandID int) (model.Setlist, error) {
+	var setlist model.Setlist
+	query := `
+		UPDATE setlists SET deleted_at = NULL, version = version + 1
+		WHERE id = $1 AND band_id = $2 AND deleted_at IS NOT NULL
+		RETURNING ` + setlistColumns + `
+	`
+	err := scanSetlist(r.DB.QueryRow(ctx, query, setlistID, bandID), &setlist)
 	return setlist, err
 }
 
-func (r PgSetlistRepository) DeleteSetlist(ctx context.Context, setlistID int, bandID int) error {
-	query := `DELETE FROM setlists WHERE id = $1 AND band_id = $2`
+// PurgeSetlist deletes a setlist of the trash for good, with its items.
+func (r PgSetlistRepository) PurgeSetlist(ctx context.Context, setlistID int, bandID int) error {
+	query := `DELETE FROM setlists WHERE id = $1 AND band_id = $2 AND deleted_at IS NOT NULL`
 	cmdTag, err := r.DB.Exec(ctx, query, setlistID, bandID)
 	if err != nil {
 		return err
@@ -115,26 +326,63 @@ func (r PgSetlistRepository) DeleteSetlist(ctx context.Context, setlistID int, b
 	return nil
 }
 
+// PurgeTrashedBefore deletes for good the setlists of every band put in the
+// trash before the given time and returns how many were deleted.
+func (r PgSetlistRepository) PurgeTrashedBefore(ctx context.Context, before time.Time) (int, error) {
+	cmdTag, err := r.DB.Exec(ctx, `DELETE FROM setlists WHERE deleted_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return int(cmdTag.RowsAffected()), nil
+}
+
+// setlistItemColumns reads items with the effective values of their song or
+// interlude: an item's own duration, tempo and key override the song's. The
+// item is aliased si and needs setlistItemJoins.
+const setlistItemColumns = `
+		si.id, si.setlist_id, si.position, si.item_type,
+		si.song_id, si.interlude_id, si.notes, si.notes_private, si.transition_duration_seconds,
+		si.label, si.duration_seconds, si.tempo, si.song_key,
+		COALESCE(s.title, i.title, si.label) as title,
+		COALESCE(si.duration_seconds, s.duration_seconds, i.duration_seconds) as duration_seconds,
+		COALESCE(si.tempo, s.tempo) as tempo,
+		i.speaker,
+		i.script,
+		COALESCE(si.song_key, s.song_key) as song_key,
+		s.links,
+		si.group_id, ig.name as group_name`
+
+const setlistItemJoins = `
+	LEFT JOIN songs s ON si.song_id = s.id
+	LEFT JOIN interludes i ON si.interlude_id = i.id
+	LEFT JOIN setlist_item_groups ig ON si.group_id = ig.id`
+
+func scanSetlistItem(row pgx.Row, item *model.SetlistItem) error {
+	return row.Scan(
+		&item.ID, &item.SetlistID, &item.Position, &item.ItemType,
+		&item.SongID, &item.InterludeID, &item.Notes, &item.NotesPrivate, &item.TransitionDurationSeconds,
+		&item.Label, &item.ItemDurationSeconds, &item.ItemTempo, &item.ItemSongKey,
+		&item.Title, &item.DurationSeconds, &item.Tempo,
+		&item.Speaker, &item.Script,
+		&item.SongKey, &item.Links,
+		&item.GroupID, &item.GroupName,
+	)
+}
+
 func (r PgSetlistRepository) GetSetlistItemsBySetlistID(ctx context.Context, setlistID int) ([]model.SetlistItem, error) {
+	return r.GetItems(ctx, r.DB, setlistID)
+}
+
+// GetItems returns the items of the setlist in order. Read within a
+// transaction holding the lock on the setlist, they cannot change before it
+// ends.
+func (r PgSetlistRepository) GetItems(ctx context.Context, db DBTX, setlistID int) ([]model.SetlistItem, error) {
 	items := make([]model.SetlistItem, 0)
-	query := `
-		SELECT
-			si.id, si.setlist_id, si.position, si.item_type,
-			si.song_id, si.interlude_id, si.notes, si.transition_duration_seconds,
-			COALESCE(s.title, i.title) as title,
-			COALESCE(s.duration_seconds, i.duration_seconds) as duration_seconds,
-			s.tempo,
-			i.speaker, 
-			i.script,
-			s.song_key,
-			s.links
-		FROM setlist_items si
-		LEFT JOIN songs s ON si.song_id = s.id
-		LEFT JOIN interludes i ON si.interlude_id = i.id
+	query := `SELECT ` + setlistItemColumns + ` FROM setlist_items si` + setlistItemJoins + `
 		WHERE si.setlist_id = $1
 		ORDER BY si.position ASC
 	`
-	rows, err := r.DB.Query(ctx, query, setlistID)
+	rows, err := db.Query(ctx, query, setlistID)
 	if err != nil {
 		return nil, err
 	}
@@ -142,14 +390,7 @@ func (r PgSetlistRepository) GetSetlistItemsBySetlistID(ctx context.Context, set
 
 	for rows.Next() {
 		var item model.SetlistItem
-		err := rows.Scan(
-			&item.ID, &item.SetlistID, &item.Position, &item.ItemType,
-			&item.SongID, &item.InterludeID, &item.Notes, &item.TransitionDurationSeconds,
-			&item.Title, &item.DurationSeconds, &item.Tempo,
-			&item.Speaker, &item.Script,
-			&item.SongKey, &item.Links,
-		)
-		if err != nil {
+		if err := scanSetlistItem(rows, &item); err != nil {
 			return items, err
 		}
 		items = append(items, item)
@@ -157,84 +398,392 @@ func (r PgSetlistRepository) GetSetlistItemsBySetlistID(ctx context.Context, set
 	return items, rows.Err()
 }
 
-func (r PgSetlistRepository) AddItemToSetlist(ctx context.Context, item model.SetlistItem) (model.SetlistItem, error) {
-	var maxPosition *int32
-	posQuery := `SELECT MAX(position) FROM setlist_items WHERE setlist_id = $1`
-	r.DB.QueryRow(ctx, posQuery, item.SetlistID).Scan(&maxPosition)
+// LockSetlists locks the rows of the setlists until the end of the
+// transaction, always in the same order so two transactions locking the same
+// setlists cannot deadlock. It returns pgx.ErrNoRows if a setlist is missing
+// and ErrSetlistLocked if one is locked against changes to its items; read
+// under the row lock, the flag cannot change before the transaction ends.
+func (r PgSetlistRepository) LockSetlists(ctx context.Context, db DBTX, setlistIDs ...int) error {
+	rows, err := db.Query(ctx, "SELECT id, is_locked FROM setlists WHERE id = ANY($1) AND deleted_at IS NULL ORDER BY id FOR UPDATE", setlistIDs)
+	if err != nil {
+		return err
+	}
+	isLocked := make(map[int]bool, len(setlistIDs))
+	var id int
+	var locked bool
+	_, err = pgx.ForEachRow(rows, []any{&id, &locked}, func() error {
+		isLocked[id] = locked
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, id := range setlistIDs {
+		locked, ok := isLocked[id]
+		if !ok {
+			return pgx.ErrNoRows
+		}
+		if locked {
+			return ErrSetlistLocked
+		}
+	}
+	return nil
+}
+
+// InsertItems inserts the items, in order, before the item found at the given
+// index, or after the last item when index is nil or past the end, and returns
+// them with their IDs and positions. It runs within the caller's transaction,
+// which should hold the lock on the setlist so concurrent inserts are applied
+// one after the other. Items are never inserted inside a group: an index
+// pointing between two of its members inserts them right after the group
+// instead, and the items returned, copies included, belong to no group.
+func (r PgSetlistRepository) InsertItems(ctx context.Context, db DBTX, setlistID int, index *int, items []model.SetlistItem) ([]model.SetlistItem, error) {
+	position := -1
+	if index != nil {
+		var groupID *int
+		query := `SELECT position, group_id FROM setlist_items WHERE setlist_id = $1 ORDER BY position OFFSET $2 LIMIT 1`
+		err := db.QueryRow(ctx, query, setlistID, *index).Scan(&position, &groupID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		if groupID != nil {
+			groupQuery := `SELECT CASE WHEN MIN(position) < $2 THEN MAX(position) + 1 ELSE $2 END FROM setlist_items WHERE group_id = $1`
+			if err := db.QueryRow(ctx, groupQuery, *groupID, position).Scan(&position); err != nil {
+				return nil, err
+			}
+		}
+	}
 
-	nextPos := 0
-	if maxPosition != nil {
-		nextPos = int(*maxPosition) + 1
+	if position >= 0 {
+		shiftQuery := `UPDATE setlist_items SET position = position + $1 WHERE setlist_id = $2 AND position >= $3`
+		if _, err := db.Exec(ctx, shiftQuery, len(items), setlistID, position); err != nil {
+			return nil, err
+		}
+	} else {
+		posQuery := `SELECT COALESCE(MAX(position) + 1, 0) FROM setlist_items WHERE setlist_id = $1`
+		if err := db.QueryRow(ctx, posQuery, setlistID).Scan(&position); err != nil {
+			return nil, err
+		}
 	}
-	item.Position = nextPos
 
-	insertQuery := `INSERT INTO setlist_items (setlist_id, position, item_type, song_id, interlude_id, notes, transition_duration_seconds)
-					VALUES ($1, $2, $3, $4, $5, $6, $7)
+	insertQuery := `INSERT INTO setlist_items (setlist_id, position, item_type, song_id, interlude_id, notes, notes_private, transition_duration_seconds, label, duration_seconds, tempo, song_key)
+					VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 					RETURNING id`
 
-	err := r.DB.QueryRow(ctx, insertQuery,
-		item.SetlistID,
-		item.Position,
-		item.ItemType,
-		item.SongID,
-		item.InterludeID,
-		item.Notes,
-		item.TransitionDurationSeconds,
-	).Scan(&item.ID)
+	created := make([]model.SetlistItem, len(items))
+	for i, item := range items {
+		item.SetlistID = setlistID
+		item.Position = position + i
+		item.GroupID = nil
+		item.GroupName = nil
+		err := db.QueryRow(ctx, insertQuery,
+			item.SetlistID,
+			item.Position,
+			item.ItemType,
+			item.SongID,
+			item.InterludeID,
+			item.Notes,
+			item.NotesPrivate,
+			item.TransitionDurationSeconds,
+			item.Label,
+			item.ItemDurationSeconds,
+			item.ItemTempo,
+			item.ItemSongKey,
+		).Scan(&item.ID)
+		if err != nil {
+			return nil, err
+		}
+		created[i] = item
+	}
+	return created, bumpVersion(ctx, db, setlistID)
+}
 
-	return item, err
+// bumpVersion marks a change to the items of the setlists so that writes
+// based on an older version are detected. Every method changing items calls
+// it with the same db, so the bump commits or rolls back with the change.
+func bumpVersion(ctx context.Context, db DBTX, setlistIDs ...int) error {
+	_, err := db.Exec(ctx, "UPDATE setlists SET version = version + 1 WHERE id = ANY($1)", setlistIDs)
+	return err
 }
 
-func (r PgSetlistRepository) UpdateItemOrder(ctx context.Context, setlistID int, itemIDs []int) error {
-	tx, err := r.DB.Begin(ctx)
+// GetItemsByIDs returns the items of the setlist among itemIDs, in setlist
+// order; IDs of items from other setlists are left out.
+func (r PgSetlistRepository) GetItemsByIDs(ctx context.Context, db DBTX, setlistID int, itemIDs []int) ([]model.SetlistItem, error) {
+	query := `SELECT ` + setlistItemColumns + ` FROM setlist_items si` + setlistItemJoins + `
+		WHERE si.setlist_id = $1 AND si.id = ANY($2)
+		ORDER BY si.position ASC
+	`
+	rows, err := db.Query(ctx, query, setlistID, itemIDs)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer tx.Rollback(ctx)
+	defer rows.Close()
 
-	if _, err := tx.Exec(ctx, "SET CONSTRAINTS unique_position_in_setlist DEFERRED"); err != nil {
+	items := make([]model.SetlistItem, 0, len(itemIDs))
+	for rows.Next() {
+		var item model.SetlistItem
+		if err := scanSetlistItem(rows, &item); err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// DeleteItems removes items of the setlist and renumbers the remaining ones.
+func (r PgSetlistRepository) DeleteItems(ctx context.Context, db DBTX, setlistID int, itemIDs []int) error {
+	if _, err := db.Exec(ctx, "DELETE FROM setlist_items WHERE setlist_id = $1 AND id = ANY($2)", setlistID, itemIDs); err != nil {
+		return err
+	}
+	if _, err := renumberItems(ctx, db, "si.setlist_id = $1", setlistID); err != nil {
 		return err
 	}
+	if err := dissolveSmallGroups(ctx, db, setlistID); err != nil {
+		return err
+	}
+	return bumpVersion(ctx, db, setlistID)
+}
+
+// UpdateItemOrder renumbers the items of a setlist in the given order and
+// returns the new version of the setlist. itemIDs must list every item of the
+// setlist exactly once, otherwise ErrItemSetMismatch is returned and nothing is
+// changed; ErrVersionConflict is returned when expectedVersion is stale. It
+// runs within the caller's transaction.
+func (r PgSetlistRepository) UpdateItemOrder(ctx context.Context, db DBTX, setlistID int, itemIDs []int, expectedVersion *int) (int, error) {
+	var version int
+	if err := db.QueryRow(ctx, "SELECT version FROM setlists WHERE id = $1 FOR UPDATE", setlistID).Scan(&version); err != nil {
+		return 0, err
+	}
+	if expectedVersion != nil && *expectedVersion != version {
+		return 0, ErrVersionConflict
+	}
+
+	rows, err := db.Query(ctx, "SELECT id FROM setlist_items WHERE setlist_id = $1", setlistID)
+	if err != nil {
+		return 0, err
+	}
+	current, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return 0, err
+	}
+	if !sameIDs(current, itemIDs) {
+		return 0, ErrItemSetMismatch
+	}
+
+	if _, err := db.Exec(ctx, "SET CONSTRAINTS unique_position_in_setlist DEFERRED"); err != nil {
+		return 0, err
+	}
 
 	query := `UPDATE setlist_items SET position = $1 WHERE id = $2 AND setlist_id = $3`
 
 	for i, id := range itemIDs {
-		if _, err := tx.Exec(ctx, query, i, id, setlistID); err != nil {
-			return err
+		if _, err := db.Exec(ctx, query, i, id, setlistID); err != nil {
+			return 0, err
 		}
 	}
 
-	return tx.Commit(ctx)
+	err = db.QueryRow(ctx, "UPDATE setlists SET version = version + 1 WHERE id = $1 RETURNING version", setlistID).Scan(&version)
+	return version, err
 }
 
-func (r PgSetlistRepository) UpdateSetlistItem(ctx context.Context, itemID int, bandID int, notes *string) (model.SetlistItem, error) {
+// sameIDs reports whether ids holds exactly the IDs of current, each once.
+func sameIDs(current []int, ids []int) bool {
+	if len(current) != len(ids) {
+		return false
+	}
+	remaining := make(map[int]bool, len(current))
+	for _, id := range current {
+		remaining[id] = true
+	}
+	for _, id := range ids {
+		if !remaining[id] {
+			return false
+		}
+		delete(remaining, id)
+	}
+	return true
+}
+
+// SetlistItemUpdate lists the changes to an item. A nil field keeps the
+// current value; empty notes are cleared, and a zero duration or tempo and an
+// empty key remove the override so the song's own value applies again.
+type SetlistItemUpdate struct {
+	Notes                     *string
+	NotesPrivate              *bool
+	TransitionDurationSeconds *int
+	DurationSeconds           *int32
+	Tempo                     *int32
+	SongKey                   *string
+}
+
+// UpdateSetlistItem applies the changes to an item of the setlist, bumps the
+// version of the setlist, then returns the item with its effective values. It
+// returns pgx.ErrNoRows if the item is no longer in the setlist, which the
+// caller's transaction should hold the lock on.
+func (r PgSetlistRepository) UpdateSetlistItem(ctx context.Context, db DBTX, itemID int, setlistID int, bandID int, update SetlistItemUpdate) (model.SetlistItem, error) {
 	var item model.SetlistItem
 	query := `
-		UPDATE setlist_items si SET notes = $1
-		FROM setlists s
-		WHERE si.id = $2 AND si.setlist_id = s.id AND s.band_id = $3
-		RETURNING si.id, si.notes
-	`
-	err := r.DB.QueryRow(ctx, query, notes, itemID, bandID).Scan(&item.ID, &item.Notes)
-	return item, err
+		WITH updated AS (
+			UPDATE setlist_items si SET
+				notes = CASE WHEN $1::text IS NULL THEN si.notes ELSE NULLIF($1, '') END,
+				notes_private = COALESCE($2, si.notes_private),
+				transition_duration_seconds = COALESCE($3, si.transition_duration_seconds),
+				duration_seconds = CASE WHEN $4::int IS NULL THEN si.duration_seconds ELSE NULLIF($4, 0) END,
+				tempo = CASE WHEN $5::int IS NULL THEN si.tempo ELSE NULLIF($5, 0) END,
+				song_key = CASE WHEN $6::text IS NULL THEN si.song_key ELSE NULLIF($6, '') END
+			FROM setlists s
+			WHERE si.id = $7 AND si.setlist_id = $8 AND si.setlist_id = s.id AND s.band_id = $9 AND s.deleted_at IS NULL
+			RETURNING si.*
+		)
+		SELECT ` + setlistItemColumns + ` FROM updated si` + setlistItemJoins
+	err := scanSetlistItem(db.QueryRow(ctx, query,
+		update.Notes, update.NotesPrivate, update.TransitionDurationSeconds,
+		update.DurationSeconds, update.Tempo, update.SongKey, itemID, setlistID, bandID,
+	), &item)
+	if err != nil {
+		return model.SetlistItem{}, err
+	}
+	return item, bumpVersion(ctx, db, item.SetlistID)
 }
 
-func (r PgSetlistRepository) DeleteSetlistItem(ctx context.Context, itemID int, bandID int) error {
+// GetItemSetlistID returns the setlist an item of the band belongs to.
+func (r PgSetlistRepository) GetItemSetlistID(ctx context.Context, itemID int, bandID int) (int, error) {
+	var setlistID int
 	query := `
-		DELETE FROM setlist_items si
-		USING setlists s
-		WHERE si.id = $1 AND si.setlist_id = s.id AND s.band_id = $2
+		SELECT si.setlist_id FROM setlist_items si
+		JOIN setlists s ON si.setlist_id = s.id
+		WHERE si.id = $1 AND s.band_id = $2 AND s.deleted_at IS NULL
 	`
-	cmdTag, err := r.DB.Exec(ctx, query, itemID, bandID)
+	err := r.DB.QueryRow(ctx, query, itemID, bandID).Scan(&setlistID)
+	return setlistID, err
+}
+
+// DeleteSetlistItem removes an item of the setlist and closes the gap it
+// leaves in the positions, within the caller's transaction, which should hold
+// the lock on the setlist. It returns pgx.ErrNoRows if the item is no longer
+// in the setlist.
+func (r PgSetlistRepository) DeleteSetlistItem(ctx context.Context, db DBTX, itemID int, setlistID int) error {
+	tag, err := db.Exec(ctx, "DELETE FROM setlist_items WHERE id = $1 AND setlist_id = $2", itemID, setlistID)
 	if err != nil {
 		return err
 	}
-	if cmdTag.RowsAffected() == 0 {
-		return sql.ErrNoRows
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
 	}
-	return nil
+	if _, err := renumberItems(ctx, db, "si.setlist_id = $1", setlistID); err != nil {
+		return err
+	}
+	if err := dissolveSmallGroups(ctx, db, setlistID); err != nil {
+		return err
+	}
+	return bumpVersion(ctx, db, setlistID)
+}
+
+// RepairPositions renumbers the items of every setlist of the band from 0
+// without gaps, keeping their order, and returns the setlists it changed. It
+// locks the setlists of the band within the caller's transaction.
+func (r PgSetlistRepository) RepairPositions(ctx context.Context, db DBTX, bandID int) ([]int, error) {
+	if _, err := db.Exec(ctx, "SELECT id FROM setlists WHERE band_id = $1 ORDER BY id FOR UPDATE", bandID); err != nil {
+		return nil, err
+	}
+	repaired, err := renumberItems(ctx, db, "si.setlist_id IN (SELECT id FROM setlists WHERE band_id = $1)", bandID)
+	if err != nil || len(repaired) == 0 {
+		return repaired, err
+	}
+	return repaired, bumpVersion(ctx, db, repaired...)
 }
 
+// renumberItems gives the items of the setlists matched by where dense
+// positions from 0, ordered by their current position then ID so duplicates
+// keep a stable order. It returns the setlists whose items moved.
+func renumberItems(ctx context.Context, db DBTX, where string, args ...any) ([]int, error) {
+	query := `
+		WITH ranked AS (
+			SELECT si.id, ROW_NUMBER() OVER (PARTITION BY si.setlist_id ORDER BY si.position, si.id) - 1 AS position
+			FROM setlist_items si
+			WHERE ` + where + `
+		)
+		UPDATE setlist_items si SET position = ranked.position
+		FROM ranked
+		WHERE si.id = ranked.id AND si.position <> ranked.position
+		RETURNING si.setlist_id
+	`
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	setlistIDs, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, err
+	}
+
+	repaired := make([]int, 0)
+	seen := make(map[int]bool)
+	for _, id := range setlistIDs {
+		if !seen[id] {
+			seen[id] = true
+			repaired = append(repaired, id)
+		}
+	}
+	slices.Sort(repaired)
+	return repaired, nil
+}
+
+func (r PgSetlistRepository) DeleteItemsBySetlistID(ctx context.Context, db DBTX, setlistID int) error {
+	_, err := db.Exec(ctx, `DELETE FROM setlist_items WHERE setlist_id = $1`, setlistID)
+	return err
+}
+
+// MissingItemReferences returns the items whose song or interlude no longer
+// exists in the band. The songs and interludes found are share-locked until
+// the end of the transaction, so they cannot disappear before the items
+// referencing them are inserted.
+func (r PgSetlistRepository) MissingItemReferences(ctx context.Context, db DBTX, bandID int, items []model.SetlistItem) ([]model.SetlistItem, error) {
+	var songIDs, interludeIDs []int32
+	for _, item := range items {
+		if item.SongID != nil {
+			songIDs = append(songIDs, *item.SongID)
+		}
+		if item.InterludeID != nil {
+			interludeIDs = append(interludeIDs, *item.InterludeID)
+		}
+	}
+
+	existing := func(table string, ids []int32) (map[int32]bool, error) {
+		found := make(map[int32]bool, len(ids))
+		if len(ids) == 0 {
+			return found, nil
+		}
+		rows, err := db.Query(ctx, "SELECT id FROM "+table+" WHERE band_id = $1 AND id = ANY($2) FOR KEY SHARE", bandID, ids)
+		if err != nil {
+			return nil, err
+		}
+		foundIDs, err := pgx.CollectRows(rows, pgx.RowTo[int32])
+		for _, id := range foundIDs {
+			found[id] = true
+		}
+		return found, err
+	}
+	songs, err := existing("songs", songIDs)
+	if err != nil {
+		return nil, err
+	}
+	interludes, err := existing("interludes", interludeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	missing := make([]model.SetlistItem, 0)
+	for _, item := range items {
+		if (item.SongID != nil && !songs[*item.SongID]) || (item.InterludeID != nil && !interludes[*item.InterludeID]) {
+			missing = append(missing, item)
+		}
+	}
+	return missing, nil
+}
+
+// CopyItemsToNewSetlist inserts copies of the items at their positions, along
+// with the groups they belong to.
 func (r PgSetlistRepository) CopyItemsToNewSetlist(ctx context.Context, tx DBTX, newSetlistID int, items []model.SetlistItem) error {
 	if len(items) == 0 {
 		return nil
@@ -249,16 +798,129 @@ func (r PgSetlistRepository) CopyItemsToNewSetlist(ctx context.Context, tx DBTX,
 			item.SongID,
 			item.InterludeID,
 			item.Notes,
+			item.NotesPrivate,
 			item.TransitionDurationSeconds,
+			item.Label,
+			item.ItemDurationSeconds,
+			item.ItemTempo,
+			item.ItemSongKey,
 		}
 	}
 
 	_, err := tx.CopyFrom(
 		ctx,
 		pgx.Identifier{"setlist_items"},
-		[]string{"setlist_id", "position", "item_type", "song_id", "interlude_id", "notes", "transition_duration_seconds"},
+		[]string{"setlist_id", "position", "item_type", "song_id", "interlude_id", "notes", "notes_private", "transition_duration_seconds", "label", "duration_seconds", "tempo", "song_key"},
 		pgx.CopyFromRows(rows),
 	)
+	if err != nil {
+		return err
+	}
+
+	return copyItemGroups(ctx, tx, newSetlistID, items)
+}
 
+// copyItemGroups gives the copied items the groups of the items they were
+// copied from, as new groups of the target setlist with the same names. The
+// copies are found by position. Groups left with fewer than two items, such
+// as those of items a restore just replaced, are dissolved.
+func copyItemGroups(ctx context.Context, db DBTX, setlistID int, items []model.SetlistItem) error {
+	var groupIDs []int
+	members := make(map[int][]int)
+	names := make(map[int]string)
+	for _, item := range items {
+		if item.GroupID == nil {
+			continue
+		}
+		if _, ok := members[*item.GroupID]; !ok {
+			groupIDs = append(groupIDs, *item.GroupID)
+			names[*item.GroupID] = valueOrDefault(item.GroupName, "Medley")
+		}
+		members[*item.GroupID] = append(members[*item.GroupID], item.Position)
+	}
+
+	for _, groupID := range groupIDs {
+		var newGroupID int
+		insertQuery := `INSERT INTO setlist_item_groups (setlist_id, name) VALUES ($1, $2) RETURNING id`
+		if err := db.QueryRow(ctx, insertQuery, setlistID, names[groupID]).Scan(&newGroupID); err != nil {
+			return err
+		}
+		updateQuery := `UPDATE setlist_items SET group_id = $1 WHERE setlist_id = $2 AND position = ANY($3)`
+		if _, err := db.Exec(ctx, updateQuery, newGroupID, setlistID, members[groupID]); err != nil {
+			return err
+		}
+	}
+	return dissolveSmallGroups(ctx, db, setlistID)
+}
+
+func valueOrDefault(s *string, fallback string) string {
+	if s == nil || *s == "" {
+		return fallback
+	}
+	return *s
+}
+
+// CreateItemGroup creates a group in the setlist and puts the items in it,
+// within the caller's transaction, which should hold the lock on the setlist.
+func (r PgSetlistRepository) CreateItemGroup(ctx context.Context, db DBTX, setlistID int, name string, itemIDs []int) (model.SetlistItemGroup, error) {
+	var group model.SetlistItemGroup
+	query := `
+		INSERT INTO setlist_item_groups (setlist_id, name)
+		VALUES ($1, $2)
+		RETURNING id, setlist_id, name, created_at
+	`
+	err := db.QueryRow(ctx, query, setlistID, name).Scan(&group.ID, &group.SetlistID, &group.Name, &group.CreatedAt)
+	if err != nil {
+		return model.SetlistItemGroup{}, err
+	}
+
+	updateQuery := `UPDATE setlist_items SET group_id = $1 WHERE setlist_id = $2 AND id = ANY($3)`
+	if _, err := db.Exec(ctx, updateQuery, group.ID, setlistID, itemIDs); err != nil {
+		return model.SetlistItemGroup{}, err
+	}
+	return group, bumpVersion(ctx, db, setlistID)
+}
+
+func (r PgSetlistRepository) RenameItemGroup(ctx context.Context, db DBTX, groupID int, setlistID int, bandID int, name string) (model.SetlistItemGroup, error) {
+	var group model.SetlistItemGroup
+	query := `
+		UPDATE setlist_item_groups g SET name = $1
+		FROM setlists s
+		WHERE g.id = $2 AND g.setlist_id = $3 AND g.setlist_id = s.id AND s.band_id = $4 AND s.deleted_at IS NULL
+		RETURNING g.id, g.setlist_id, g.name, g.created_at
+	`
+	err := db.QueryRow(ctx, query, name, groupID, setlistID, bandID).Scan(&group.ID, &group.SetlistID, &group.Name, &group.CreatedAt)
+	if err != nil {
+		return model.SetlistItemGroup{}, err
+	}
+	return group, bumpVersion(ctx, db, setlistID)
+}
+
+// DeleteItemGroup removes a group; its items stay in place, ungrouped.
+func (r PgSetlistRepository) DeleteItemGroup(ctx context.Context, db DBTX, groupID int, setlistID int, bandID int) error {
+	query := `
+		DELETE FROM setlist_item_groups g
+		USING setlists s
+		WHERE g.id = $1 AND g.setlist_id = $2 AND g.setlist_id = s.id AND s.band_id = $3 AND s.deleted_at IS NULL
+	`
+	cmdTag, err := db.Exec(ctx, query, groupID, setlistID, bandID)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return bumpVersion(ctx, db, setlistID)
+}
+
+// dissolveSmallGroups deletes the groups of the setlist that no longer hold
+// at least two items, which ungroups the item left.
+func dissolveSmallGroups(ctx context.Context, db DBTX, setlistID int) error {
+	query := `
+		DELETE FROM setlist_item_groups g
+		WHERE g.setlist_id = $1
+		AND (SELECT COUNT(*) FROM setlist_items si WHERE si.group_id = g.id) < 2
+	`
+	_, err := db.Exec(ctx, query, setlistID)
 	return err
 }
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"setlist/api/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SetlistRevisionRepository interface {
+	Record(ctx context.Context, db DBTX, setlistID int, authorID int, action string) error
+	ListBySetlistID(ctx context.Context, setlistID int) ([]model.SetlistRevision, error)
+	GetByID(ctx context.Context, setlistID int, revisionID int) (model.SetlistRevision, error)
+}
+
+type PgSetlistRevisionRepository struct {
+	DB *pgxpool.Pool
+}
+
+// Record stores the current state of the setlist and its items. It runs within
+// the transaction of the change it records, so the snapshot, built by the
+// database, is exactly what was written; its items use the JSON layout of
+// model.SetlistItem.
+func (r *PgSetlistRevisionRepository) Record(ctx context.Context, db DBTX, setlistID int, authorID int, action string) error {
+	query := `
+		INSERT INTO setlist_revisions (setlist_id, author_id, action, snapshot)
+		SELECT s.id, $2, $3, jsonb_build_object(
+			'name', s.name,
+			'color', s.color,
+			'is_archived', s.is_archived,
+			'items', COALESCE((
+				SELECT jsonb_agg(jsonb_build_object(
+					'id', si.id,
+					'position', si.position,
+					'item_type', si.item_type,
+					'song_id', si.song_id,
+					'interlude_id', si.interlude_id,
+					'notes', si.notes,
+					'notes_private', si.notes_private,
+					'transition_duration_seconds', si.transition_duration_seconds,
+					'label', si.label,
+					'item_duration_seconds', si.duration_seconds,
+					'item_tempo', si.tempo,
+					'item_song_key', si.song_key,
+					'title', COALESCE(so.title, i.title, si.label),
+					'duration_seconds', COALESCE(si.duration_seconds, so.duration_seconds, i.duration_seconds),
+					'tempo', COALESCE(si.tempo, so.tempo),
+					'speaker', i.speaker,
+					'song_key', COALESCE(si.song_key, so.song_key),
+					'group_id', si.group_id,
+					'group_name', g.name
+				) ORDER BY si.position)
+				FROM setlist_items si
+				LEFT JOIN songs so ON si.song_id = so.id
+				LEFT JOIN interludes i ON si.interlude_id = i.id
+				LEFT JOIN setlist_item_groups g ON si.group_id = g.id
+				WHERE si.setlist_id = s.id
+			), '[]'::jsonb)
+		)
+		FROM setlists s
+		WHERE s.id = $1
+	`
+	_, err := db.Exec(ctx, query, setlistID, authorID, action)
+	return err
+}
+
+// ListBySetlistID returns the history of a setlist, newest first, without the
+// snapshots.
+func (r *PgSetlistRevisionRepository) ListBySetlistID(ctx context.Context, setlistID int) ([]model.SetlistRevision, error) {
+	revisions := make([]model.SetlistRevision, 0)
+	query := `
+		SELECT r.id, r.setlist_id, r.author_id, u.username, r.action, r.created_at
+		FROM setlist_revisions r
+		LEFT JOIN users u ON r.author_id = u.id
+		WHERE r.setlist_id = $1
+		ORDER BY r.created_at DESC, r.id DESC
+	`
+	rows, err := r.DB.Query(ctx, query, setlistID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var revision model.SetlistRevision
+		if err := rows.Scan(&revision.ID, &revision.SetlistID, &revision.AuthorID, &revision.AuthorName, &revision.Action, &revision.CreatedAt); err != nil {
+			return revisions, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+func (r *PgSetlistRevisionRepository) GetByID(ctx context.Context, setlistID int, revisionID int) (model.SetlistRevision, error) {
+	var revision model.SetlistRevision
+	query := `
+		SELECT r.id, r.setlist_id, r.author_id, u.username, r.action, r.created_at, r.snapshot
+		FROM setlist_revisions r
+		LEFT JOIN users u ON r.author_id = u.id
+		WHERE r.id = $1 AND r.setlist_id = $2
+	`
+	err := r.DB.QueryRow(ctx, query, revisionID, setlistID).Scan(
+		&revision.ID, &revision.SetlistID, &revision.AuthorID, &revision.AuthorName,
+		&revision.Action, &revision.CreatedAt, &revision.Snapshot,
+	)
+	return revision, err
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"setlist/api/model"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SetlistShareRepository interface {
+	Create(ctx context.Context, share *model.SetlistShare) error
+	GetByToken(ctx context.Context, token string) (model.SetlistShare, error)
+	ListActiveBySetlistID(ctx context.Context, setlistID int) ([]model.SetlistShare, error)
+	Delete(ctx context.Context, setlistID int, token string) error
+	DeleteAllBySetlistID(ctx context.Context, setlistID int) error
+}
+
+type PgSetlistShareRepository struct {
+	DB *pgxpool.Pool
+}
+
+func (r *PgSetlistShareRepository) Create(ctx context.Context, share *model.SetlistShare) error {
+	query := `
+		INSERT INTO setlist_shares (token, setlist_id, created_by, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	return r.DB.QueryRow(ctx, query, share.Token, share.SetlistID, share.CreatedBy, share.ExpiresAt).
+		Scan(&share.ID, &share.CreatedAt)
+}
+
+func (r *PgSetlistShareRepository) GetByToken(ctx context.Context, token string) (model.SetlistShare, error) {
+	var share model.SetlistShare
+	query := `
+		SELECT sh.id, sh.token, sh.setlist_id, s.band_id, sh.created_by, sh.created_at, sh.expires_at
+		FROM setlist_shares sh
+		JOIN setlists s ON sh.setlist_id = s.id
+		WHERE sh.token = $1 AND s.deleted_at IS NULL
+	`
+	err := r.DB.QueryRow(ctx, query, token).
+		Scan(&share.ID, &share.Token, &share.SetlistID, &share.BandID, &share.CreatedBy, &share.CreatedAt, &share.ExpiresAt)
+	return share, err
+}
+
+func (r *PgSetlistShareRepository) ListActiveBySetlistID(ctx context.Context, setlistID int) ([]model.SetlistShare, error) {
+	shares := make([]model.SetlistShare, 0)
+	query := `
+		SELECT id, token, setlist_id, created_by, created_at, expires_at
+		FROM setlist_shares
+		WHERE setlist_id = $1 AND expires_at > NOW()
+		ORDER BY created_at DESC
+	`
+	rows, err := r.DB.Query(ctx, query, setlistID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var share model.SetlistShare
+		if err := rows.Scan(&share.ID, &share.Token, &share.SetlistID, &share.CreatedBy, &share.CreatedAt, &share.ExpiresAt); err != nil {
+			return shares, err
+		}
+		shares = append(shares, share)
+	}
+	return shares, rows.Err()
+}
+
+func (r *PgSetlistShareRepository) Delete(ctx context.Context, setlistID int, token string) error {
+	query := `DELETE FROM setlist_shares WHERE setlist_id = $1 AND token = $2`
+	cmdTag, err := r.DB.Exec(ctx, query, setlistID, token)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *PgSetlistShareRepository) DeleteAllBySetlistID(ctx context.Context, setlistID int) error {
+	query := `DELETE FROM setlist_shares WHERE setlist_id = $1`
+	_, err := r.DB.Exec(ctx, query, setlistID)
+	return err
+}
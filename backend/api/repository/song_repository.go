@@ -4,31 +4,46 @@ import (
 	"context"
 	"database/sql"
 	"setlist/api/model"
-
-	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
 )
 
 type SongRepository interface {
 	CreateSong(ctx context.Context, song model.Song) (model.Song, error)
+	InsertSong(ctx context.Context, db DBTX, song model.Song) (model.Song, error)
 	GetAllSongsByBandID(ctx context.Context, bandID int) ([]model.Song, error)
 	GetSongByID(ctx context.Context, id int, bandID int) (model.Song, error)
-	UpdateSong(ctx context.Context, song model.Song) (model.Song, error)
+	UpdateSong(ctx context.Context, song model.Song, expectedVersion *int) (model.Song, error)
 	SoftDeleteSong(ctx context.Context, id int, bandID int) error
+	GetSongStats(ctx context.Context, bandID int, filter SongStatsFilter) ([]model.SongStats, int, error)
+}
+
+// SongStatsFilter selects the setlists the statistics are computed on. From
+// and To bound the date a setlist was used, To being excluded.
+type SongStatsFilter struct {
+	From            *time.Time
+	To              *time.Time
+	ExcludeArchived bool
 }
 
 type PgSongRepository struct {
-	DB *pgxpool.Pool
+	DB DBTX
 }
 
 func (r PgSongRepository) CreateSong(ctx context.Context, song model.Song) (model.Song, error) {
+	return r.InsertSong(ctx, r.DB, song)
+}
+
+// InsertSong creates a song through db, so that it can be part of a larger
+// transaction.
+func (r PgSongRepository) InsertSong(ctx context.Context, db DBTX, song model.Song) (model.Song, error) {
 	query := `
 		INSERT INTO songs (
 			band_id, title, duration_seconds, tempo, song_key, lyrics, album_name, instrumentation, links
 		)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING id, created_at
+		RETURNING id, created_at, version
 	`
-	err := r.DB.QueryRow(ctx, query,
+	err := db.QueryRow(ctx, query,
 		song.BandID,
 		song.Title,
 		song.DurationSeconds,
@@ -38,14 +53,14 @@ func (r PgSongRepository) CreateSong(ctx context.Context, song model.Song) (mode
 		song.AlbumName,
 		song.Instrumentation,
 		song.Links,
-	).Scan(&song.ID, &song.CreatedAt)
+	).Scan(&song.ID, &song.CreatedAt, &song.Version)
 
 	return song, err
 }
 
 func (r PgSongRepository) GetAllSongsByBandID(ctx context.Context, bandID int) ([]model.Song, error) {
 	songs := make([]model.Song, 0)
-	query := `SELECT id, title, album_name, duration_seconds, tempo, song_key, links FROM songs WHERE band_id = $1 AND is_deleted = FALSE ORDER BY album_name ASC, title ASC`
+	query := `SELECT id, title, album_name, duration_seconds, tempo, song_key, links, version FROM songs WHERE band_id = $1 AND is_deleted = FALSE ORDER BY album_name ASC, title ASC`
 
 	rows, err := r.DB.Query(ctx, query, bandID)
 	if err != nil {
@@ -55,7 +70,7 @@ func (r PgSongRepository) GetAllSongsByBandID(ctx context.Context, bandID int) (
 
 	for rows.Next() {
 		var song model.Song
-		if err := rows.Scan(&song.ID, &song.Title, &song.AlbumName, &song.DurationSeconds, &song.Tempo, &song.SongKey, &song.Links); err != nil {
+		if err := rows.Scan(&song.ID, &song.Title, &song.AlbumName, &song.DurationSeconds, &song.Tempo, &song.SongKey, &song.Links, &song.Version); err != nil {
 			return nil, err
 		}
 		songs = append(songs, song)
@@ -67,30 +82,32 @@ func (r PgSongRepository) GetSongByID(ctx context.Context, id int, bandID int) (
 	var song model.Song
 	query := `
 		SELECT 
-			id, band_id, title, duration_seconds, tempo, song_key, lyrics, album_name, instrumentation, links, created_at
+			id, band_id, title, duration_seconds, tempo, song_key, lyrics, chords, album_name, instrumentation, links, created_at, version
 		FROM songs 
 		WHERE id = $1 AND band_id = $2 AND is_deleted = FALSE
 	`
 	err := r.DB.QueryRow(ctx, query, id, bandID).Scan(
-		&song.ID, &song.BandID, &song.Title, &song.DurationSeconds, &song.Tempo, &song.SongKey, &song.Lyrics,
-		&song.AlbumName, &song.Instrumentation, &song.Links, &song.CreatedAt,
+		&song.ID, &song.BandID, &song.Title, &song.DurationSeconds, &song.Tempo, &song.SongKey, &song.Lyrics, &song.Chords,
+		&song.AlbumName, &song.Instrumentation, &song.Links, &song.CreatedAt, &song.Version,
 	)
 	return song, err
 }
 
-func (r PgSongRepository) UpdateSong(ctx context.Context, song model.Song) (model.Song, error) {
+// UpdateSong bumps the version of the song. With an expectedVersion, the
+// update only applies to that version and pgx.ErrNoRows is returned otherwise.
+func (r PgSongRepository) UpdateSong(ctx context.Context, song model.Song, expectedVersion *int) (model.Song, error) {
 	query := `
 		UPDATE songs SET
 			title = $1, duration_seconds = $2, tempo = $3, song_key = $4, lyrics = $5,
-			album_name = $6, instrumentation = $7, links = $8, updated_at = NOW()
-		WHERE id = $9 AND band_id = $10
-		RETURNING id, created_at, updated_at
+			album_name = $6, instrumentation = $7, links = $8, updated_at = NOW(), version = version + 1
+		WHERE id = $9 AND band_id = $10 AND ($11::int IS NULL OR version = $11)
+		RETURNING id, created_at, version
 	`
 	err := r.DB.QueryRow(ctx, query,
 		song.Title, song.DurationSeconds, song.Tempo, song.SongKey, song.Lyrics,
 		song.AlbumName, song.Instrumentation, song.Links,
-		song.ID, song.BandID,
-	).Scan(&song.ID, &song.CreatedAt, &song.CreatedAt)
+		song.ID, song.BandID, expectedVersion,
+	).Scan(&song.ID, &song.CreatedAt, &song.Version)
 
 	return song, err
 }
@@ -106,3 +123,59 @@ func (r PgSongRepository) SoftDeleteSong(ctx context.Context, id int, bandID int
 	}
 	return nil
 }
+
+// GetSongStats returns the usage of every song of the library, unused songs
+// included, with the number of setlists the statistics were computed on.
+// Templates are not setlists that get played and are left out. The share of
+// a song is always taken over the active setlists of the period, whether or
+// not the filter leaves the archived ones out of the counts.
+func (r PgSongRepository) GetSongStats(ctx context.Context, bandID int, filter SongStatsFilter) ([]model.SongStats, int, error) {
+	query := `
+		WITH used AS (
+			SELECT s.id, s.is_archived, COALESCE((
+				SELECT MIN(g.starts_at)
+				FROM gig_setlists gs
+				JOIN gigs g ON gs.gig_id = g.id
+				WHERE gs.setlist_id = s.id AND g.kind = 'gig'
+			), s.created_at) AS used_at
+			FROM setlists s
+			WHERE s.band_id = $1 AND NOT s.is_template AND s.deleted_at IS NULL AND NOT ($4 AND s.is_archived)
+		), scope AS (
+			SELECT id, is_archived, used_at FROM used
+			WHERE ($2::timestamptz IS NULL OR used_at >= $2) AND ($3::timestamptz IS NULL OR used_at < $3)
+		), active AS (
+			SELECT COUNT(*) AS total FROM scope WHERE NOT is_archived
+		)
+		SELECT
+			so.id, so.title, so.album_name,
+			COUNT(si.id), COUNT(DISTINCT si.setlist_id),
+			MIN(sc.used_at), MAX(sc.used_at),
+			ROUND(AVG(si.position + 1), 2)::float8,
+			COALESCE(ROUND(COUNT(DISTINCT si.setlist_id) FILTER (WHERE NOT sc.is_archived)::numeric / NULLIF((SELECT total FROM active), 0), 3), 0)::float8,
+			(SELECT COUNT(*) FROM scope)
+		FROM songs so
+		LEFT JOIN setlist_items si ON si.song_id = so.id AND si.item_type = 'song'
+			AND si.setlist_id IN (SELECT id FROM scope)
+		LEFT JOIN scope sc ON sc.id = si.setlist_id
+		WHERE so.band_id = $1 AND so.is_deleted = FALSE
+		GROUP BY so.id
+		ORDER BY COUNT(si.id) DESC, so.title ASC
+	`
+	rows, err := r.DB.Query(ctx, query, bandID, filter.From, filter.To, filter.ExcludeArchived)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	stats := make([]model.SongStats, 0)
+	setlistCount := 0
+	for rows.Next() {
+		var s model.SongStats
+		if err := rows.Scan(&s.SongID, &s.Title, &s.AlbumName, &s.TimesUsed, &s.SetlistCount,
+			&s.FirstUsedAt, &s.LastUsedAt, &s.AveragePosition, &s.SetlistShare, &setlistCount); err != nil {
+			return nil, 0, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, setlistCount, rows.Err()
+}
@@ -21,6 +21,21 @@ type RefreshTokenResponse struct {
 	Bands        []model.Band `json:"bands"`
 }
 
+type StreamTokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateStreamToken issues the token that opens the event streams of the band
+// from a browser, passed as ?token= to the stream URLs.
+func (s AuthService) CreateStreamToken(userID int, bandID int) (StreamTokenResponse, error) {
+	token, expiresAt, err := auth.GenerateStreamToken(s.JWTSecret, userID, bandID)
+	if err != nil {
+		return StreamTokenResponse{}, err
+	}
+	return StreamTokenResponse{Token: token, ExpiresAt: expiresAt}, nil
+}
+
 func (s AuthService) RefreshAccessToken(ctx context.Context, refreshToken string) (*RefreshTokenResponse, error) {
 	tokenHash, err := auth.HashRefreshToken(refreshToken)
 	if err != nil {
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	icsTimeFormat = "20060102T150405Z"
+	// icsLineLimit is the length in octets after which RFC 5545 folds a line.
+	icsLineLimit = 75
+)
+
+var icsTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+type calendarEvent struct {
+	UID         string
+	Summary     string
+	Location    string
+	Description string
+	Status      string
+	Start       time.Time
+	End         time.Time
+}
+
+// renderICS writes the events as an RFC 5545 calendar.
+func renderICS(name string, events []calendarEvent, stamp time.Time) []byte {
+	var b bytes.Buffer
+	icsLine(&b, "BEGIN:VCALENDAR")
+	icsLine(&b, "VERSION:2.0")
+	icsLine(&b, "PRODID:-//Setlist//Concerts//FR")
+	icsLine(&b, "CALSCALE:GREGORIAN")
+	icsLine(&b, "METHOD:PUBLISH")
+	icsLine(&b, "X-WR-CALNAME:"+icsText(name))
+
+	for _, event := range events {
+		icsLine(&b, "BEGIN:VEVENT")
+		icsLine(&b, "UID:"+event.UID)
+		icsLine(&b, "DTSTAMP:"+stamp.UTC().Format(icsTimeFormat))
+		icsLine(&b, "DTSTART:"+event.Start.UTC().Format(icsTimeFormat))
+		icsLine(&b, "DTEND:"+event.End.UTC().Format(icsTimeFormat))
+		icsLine(&b, "SUMMARY:"+icsText(event.Summary))
+		if event.Location != "" {
+			icsLine(&b, "LOCATION:"+icsText(event.Location))
+		}
+		if event.Description != "" {
+			icsLine(&b, "DESCRIPTION:"+icsText(event.Description))
+		}
+		if event.Status != "" {
+			icsLine(&b, "STATUS:"+event.Status)
+		}
+		icsLine(&b, "END:VEVENT")
+	}
+
+	icsLine(&b, "END:VCALENDAR")
+	return b.Bytes()
+}
+
+func icsText(s string) string {
+	return icsTextEscaper.Replace(s)
+}
+
+// icsLine writes a content line, folded every 75 octets without splitting a
+// UTF-8 character, and terminated by CRLF.
+func icsLine(b *bytes.Buffer, line string) {
+	limit := icsLineLimit
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space of a continuation line counts towards its length.
+		limit = icsLineLimit - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
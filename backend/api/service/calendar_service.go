@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"setlist/api/model"
+	"setlist/api/repository"
+	"strings"
+	"time"
+)
+
+const (
+	// calendarHistory keeps recent gigs in the feed so calendars don't drop
+	// them the morning after.
+	calendarHistory = 30 * 24 * time.Hour
+	// defaultEventDuration is used for gigs without a timed setlist.
+	defaultEventDuration = 2 * time.Hour
+)
+
+var ErrCalendarFeedNotFound = errors.New("calendar feed not found or revoked")
+
+var icsStatuses = map[string]string{
+	model.GigStatusTentative: "TENTATIVE",
+	model.GigStatusConfirmed: "CONFIRMED",
+	model.GigStatusCancelled: "CANCELLED",
+}
+
+type CalendarService struct {
+	FeedRepo    repository.CalendarFeedRepository
+	GigRepo     repository.GigRepository
+	SetlistRepo repository.SetlistRepository
+}
+
+// GetFeed returns the feed of the user for the band, creating it on first use.
+func (s CalendarService) GetFeed(ctx context.Context, userID int, bandID int) (model.CalendarFeed, error) {
+	feed, err := s.FeedRepo.Get(ctx, userID, bandID)
+	if isNotFound(err) {
+		return s.RegenerateFeed(ctx, userID, bandID)
+	}
+	if err != nil {
+		return model.CalendarFeed{}, err
+	}
+	feed.Path = calendarFeedPath(feed.Token)
+	return feed, nil
+}
+
+// RegenerateFeed gives the feed a new secret; the previous URL stops working.
+func (s CalendarService) RegenerateFeed(ctx context.Context, userID int, bandID int) (model.CalendarFeed, error) {
+	token, err := generateToken()
+	if err != nil {
+		return model.CalendarFeed{}, err
+	}
+
+	feed := model.CalendarFeed{UserID: userID, BandID: bandID, Token: token}
+	if err := s.FeedRepo.Save(ctx, &feed); err != nil {
+		return model.CalendarFeed{}, err
+	}
+	feed.Path = calendarFeedPath(feed.Token)
+	return feed, nil
+}
+
+// RenderFeed returns the ICS calendar behind a feed token: the band's gigs and
+// rehearsals from the last 30 days onwards.
+func (s CalendarService) RenderFeed(ctx context.Context, token string, now time.Time) (ExportFile, error) {
+	feed, err := s.FeedRepo.GetByToken(ctx, token)
+	if err != nil {
+		return ExportFile{}, mapNotFound(err, ErrCalendarFeedNotFound)
+	}
+
+	gigs, err := s.GigRepo.ListByBandID(ctx, feed.BandID)
+	if err != nil {
+		return ExportFile{}, err
+	}
+	upcoming := make([]model.Gig, 0, len(gigs))
+	ids := make([]int, 0, len(gigs))
+	for _, gig := range gigs {
+		if gig.StartsAt.After(now.Add(-calendarHistory)) {
+			upcoming = append(upcoming, gig)
+			ids = append(ids, gig.ID)
+		}
+	}
+
+	setlists, err := s.GigRepo.ListSetlists(ctx, ids)
+	if err != nil {
+		return ExportFile{}, err
+	}
+
+	timings := make(map[int]SetlistTiming)
+	events := make([]calendarEvent, 0, len(upcoming))
+	for _, gig := range upcoming {
+		for _, setlist := range setlists[gig.ID] {
+			if _, ok := timings[setlist.SetlistID]; ok {
+				continue
+			}
+			items, err := s.SetlistRepo.GetSetlistItemsBySetlistID(ctx, setlist.SetlistID)
+			if err != nil {
+				return ExportFile{}, err
+			}
+			timings[setlist.SetlistID] = ComputeTiming(items, nil)
+		}
+		events = append(events, gigEvent(gig, setlists[gig.ID], timings))
+	}
+
+	return ExportFile{
+		FileName:    exportFileName(feed.BandName, "_concerts", "ics"),
+		ContentType: "text/calendar; charset=utf-8",
+		Content:     renderICS(feed.BandName, events, now),
+	}, nil
+}
+
+func calendarFeedPath(token string) string {
+	return fmt.Sprintf("/api/calendar/%s.ics", token)
+}
+
+// gigEvent turns a gig into a calendar event. The event lasts as long as its
+// setlists, or until the end of the last set when set times are known.
+func gigEvent(gig model.Gig, setlists []model.GigSetlist, timings map[int]SetlistTiming) calendarEvent {
+	event := calendarEvent{
+		UID:     fmt.Sprintf("gig-%d@setlist", gig.ID),
+		Summary: gig.Name,
+		Status:  icsStatuses[gig.Status],
+		Start:   gig.StartsAt,
+	}
+	if gig.Kind == model.GigKindRehearsal {
+		event.Summary = "Répétition : " + gig.Name
+	}
+
+	var location []string
+	for _, part := range []*string{gig.Venue, gig.Address} {
+		if part != nil && *part != "" {
+			location = append(location, *part)
+		}
+	}
+	event.Location = strings.Join(location, ", ")
+
+	var description []string
+	total := 0
+	end := gig.StartsAt
+	for _, setlist := range setlists {
+		timing := timings[setlist.SetlistID]
+		total += timing.TotalDurationSeconds
+		description = append(description, fmt.Sprintf("%s : %d titre(s), %s",
+			setlist.Name, countSongs(timing), formatDuration(timing.TotalDurationSeconds)))
+		if setlist.StartsAt != nil {
+			if setEnd := setlist.StartsAt.Add(time.Duration(timing.TotalDurationSeconds) * time.Second); setEnd.After(end) {
+				end = setEnd
+			}
+		}
+	}
+	if len(setlists) > 0 {
+		description = append(description, "Durée totale : "+formatDuration(total))
+	}
+	if gig.Notes != nil && *gig.Notes != "" {
+		description = append(description, "", *gig.Notes)
+	}
+	event.Description = strings.Join(description, "\n")
+
+	if playedUntil := gig.StartsAt.Add(time.Duration(total) * time.Second); playedUntil.After(end) {
+		end = playedUntil
+	}
+	if total == 0 && !end.After(gig.StartsAt) {
+		end = gig.StartsAt.Add(defaultEventDuration)
+	}
+	event.End = end
+	return event
+}
+
+func countSongs(timing SetlistTiming) int {
+	count := 0
+	for _, item := range timing.Items {
+		if item.ItemType == model.ItemTypeSong {
+			count++
+		}
+	}
+	return count
+}
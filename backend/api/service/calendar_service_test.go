@@ -0,0 +1,136 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"setlist/api/model"
+	"setlist/api/repository/mocks"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCalendarService_GetFeed_CreatesMissingFeed(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockFeedRepo := mocks.NewMockCalendarFeedRepository(ctrl)
+	svc := CalendarService{FeedRepo: mockFeedRepo}
+
+	mockFeedRepo.EXPECT().Get(ctx, userID, 1).Return(model.CalendarFeed{}, pgx.ErrNoRows)
+	mockFeedRepo.EXPECT().Save(ctx, gomock.Any()).Return(nil)
+
+	feed, err := svc.GetFeed(ctx, userID, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(feed.Token) != 32 || feed.Path != "/api/calendar/"+feed.Token+".ics" {
+		t.Errorf("unexpected feed: %+v", feed)
+	}
+}
+
+func TestCalendarService_RenderFeed(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("renders upcoming gigs and rehearsals", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFeedRepo := mocks.NewMockCalendarFeedRepository(ctrl)
+		mockGigRepo := mocks.NewMockGigRepository(ctrl)
+		mockSetlistRepo := mocks.NewMockSetlistRepository(ctrl)
+		svc := CalendarService{FeedRepo: mockFeedRepo, GigRepo: mockGigRepo, SetlistRepo: mockSetlistRepo}
+
+		gigStart := time.Date(2026, 6, 21, 19, 0, 0, 0, time.UTC)
+		gigs := []model.Gig{
+			{ID: 3, Kind: model.GigKindGig, Name: "Vieux concert", StartsAt: now.Add(-60 * 24 * time.Hour), Status: model.GigStatusConfirmed},
+			{ID: 7, Kind: model.GigKindGig, Name: "Fête de la musique", StartsAt: gigStart, Status: model.GigStatusConfirmed,
+				Venue: strPtr("Place du marché"), Address: strPtr("1 rue Haute; Lyon"), Notes: strPtr("Loges au 1er étage")},
+			{ID: 8, Kind: model.GigKindRehearsal, Name: "Studio B", StartsAt: gigStart.Add(-48 * time.Hour), Status: model.GigStatusTentative},
+		}
+		mockFeedRepo.EXPECT().GetByToken(ctx, "tok").Return(model.CalendarFeed{BandID: 1, BandName: "Les Copains"}, nil)
+		mockGigRepo.EXPECT().ListByBandID(ctx, 1).Return(gigs, nil)
+		mockGigRepo.EXPECT().ListSetlists(ctx, []int{7, 8}).Return(map[int][]model.GigSetlist{
+			7: {{SetlistID: 10, Name: "Set principal"}},
+		}, nil)
+		mockSetlistRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, 10).Return([]model.SetlistItem{
+			{ID: 1, ItemType: model.ItemTypeSong, DurationSeconds: int32Ptr(1800)},
+			{ID: 2, ItemType: model.ItemTypeSong, DurationSeconds: int32Ptr(1800)},
+		}, nil)
+
+		file, err := svc.RenderFeed(ctx, "tok", now)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if file.ContentType != "text/calendar; charset=utf-8" {
+			t.Errorf("unexpected content type %q", file.ContentType)
+		}
+
+		ics := string(file.Content)
+		for _, want := range []string{
+			"BEGIN:VCALENDAR\r\n",
+			"X-WR-CALNAME:Les Copains\r\n",
+			"UID:gig-7@setlist\r\n",
+			"DTSTART:20260621T190000Z\r\n",
+			"DTEND:20260621T200000Z\r\n",
+			"LOCATION:Place du marché\\, 1 rue Haute\\; Lyon\r\n",
+			"STATUS:CONFIRMED\r\n",
+			"SUMMARY:Répétition : Studio B\r\n",
+			"STATUS:TENTATIVE\r\n",
+			"END:VCALENDAR\r\n",
+		} {
+			if !strings.Contains(ics, want) {
+				t.Errorf("expected %q in:\n%s", want, ics)
+			}
+		}
+		if strings.Contains(ics, "Vieux concert") {
+			t.Error("expected old gigs to be left out")
+		}
+		unfolded := strings.ReplaceAll(ics, "\r\n ", "")
+		if !strings.Contains(unfolded, `DESCRIPTION:Set principal : 2 titre(s)\, 1:00:00\nDurée totale : 1:00:00\n\nLoges au 1er étage`) {
+			t.Errorf("unexpected description in:\n%s", unfolded)
+		}
+	})
+
+	t.Run("unknown or revoked token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockFeedRepo := mocks.NewMockCalendarFeedRepository(ctrl)
+		svc := CalendarService{FeedRepo: mockFeedRepo}
+
+		mockFeedRepo.EXPECT().GetByToken(ctx, "old").Return(model.CalendarFeed{}, pgx.ErrNoRows)
+
+		if _, err := svc.RenderFeed(ctx, "old", now); !errors.Is(err, ErrCalendarFeedNotFound) {
+			t.Fatalf("expected ErrCalendarFeedNotFound, got %v", err)
+		}
+	})
+}
+
+func TestICSLine_Folding(t *testing.T) {
+	var b bytes.Buffer
+	icsLine(&b, "DESCRIPTION:"+strings.Repeat("é", 80))
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	if len(lines) < 3 {
+		t.Fatalf("expected the line to be folded, got %q", b.String())
+	}
+	for i, line := range lines {
+		if len(line) > icsLineLimit {
+			t.Errorf("line %d is %d octets long", i, len(line))
+		}
+		if i > 0 && !strings.HasPrefix(line, " ") {
+			t.Errorf("continuation line %d does not start with a space", i)
+		}
+	}
+	if got := strings.ReplaceAll(b.String(), "\r\n ", ""); got != "DESCRIPTION:"+strings.Repeat("é", 80)+"\r\n" {
+		t.Errorf("unfolding did not give back the line: %q", got)
+	}
+}
@@ -10,14 +10,24 @@ import (
 // mapNotFound converts a driver-level "no rows" error into the given domain
 // sentinel; any other error is returned unchanged so it surfaces as a 500.
 func mapNotFound(err error, sentinel error) error {
-	if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows) {
+	if isNotFound(err) {
 		return sentinel
 	}
 	return err
 }
 
+func isNotFound(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows) || errors.Is(err, sql.ErrNoRows)
+}
+
 // ValidationError carries a user-facing validation message across layers so
 // handlers can surface it as a 400 without string matching.
 type ValidationError struct{ Msg string }
 
 func (e *ValidationError) Error() string { return e.Msg }
+
+// VersionConflictError reports a write based on a stale version of a setlist
+// or song. Current holds the latest state so the client can merge and retry.
+type VersionConflictError struct{ Current any }
+
+func (e *VersionConflictError) Error() string { return "resource was modified by someone else" }
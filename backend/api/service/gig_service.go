@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"setlist/api/model"
+	"setlist/api/repository"
+	"setlist/cache"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// gigArchiveDelay leaves the night of the gig before its setlists are archived.
+const gigArchiveDelay = 12 * time.Hour
+
+var ErrGigNotFound = errors.New("gig not found or does not belong to the user's band")
+
+type GigService struct {
+	GigRepo     repository.GigRepository
+	SetlistRepo repository.SetlistRepository
+	Cache       *redis.Client
+}
+
+// GigPayload is used to create and to update a gig; an update replaces every
+// field and the list of setlists.
+type GigPayload struct {
+	Kind         string              `json:"kind"`
+	Name         string              `json:"name"`
+	Venue        *string             `json:"venue"`
+	Address      *string             `json:"address"`
+	StartsAt     *time.Time          `json:"starts_at"`
+	LoadInAt     *time.Time          `json:"load_in_at"`
+	SoundcheckAt *time.Time          `json:"soundcheck_at"`
+	Status       string              `json:"status"`
+	Notes        *string             `json:"notes"`
+	Setlists     []GigSetlistPayload `json:"setlists"`
+}
+
+type GigSetlistPayload struct {
+	SetlistID int        `json:"setlist_id"`
+	StartsAt  *time.Time `json:"starts_at"`
+}
+
+func (s GigService) Create(ctx context.Context, bandID int, payload GigPayload) (model.Gig, error) {
+	gig := model.Gig{BandID: bandID}
+	return s.save(ctx, gig, payload)
+}
+
+func (s GigService) Update(ctx context.Context, id int, bandID int, payload GigPayload) (model.Gig, error) {
+	gig, err := s.GigRepo.GetByID(ctx, id, bandID)
+	if err != nil {
+		return model.Gig{}, mapNotFound(err, ErrGigNotFound)
+	}
+	return s.save(ctx, gig, payload)
+}
+
+func (s GigService) Delete(ctx context.Context, id int, bandID int) error {
+	if err := s.GigRepo.Delete(ctx, id, bandID); err != nil {
+		return mapNotFound(err, ErrGigNotFound)
+	}
+	// The setlist list can be filtered on gig dates.
+	cache.Delete(ctx, s.Cache, cache.SetlistKey(bandID))
+	return nil
+}
+
+func (s GigService) Get(ctx context.Context, id int, bandID int) (model.Gig, error) {
+	gig, err := s.GigRepo.GetByID(ctx, id, bandID)
+	if err != nil {
+		return model.Gig{}, mapNotFound(err, ErrGigNotFound)
+	}
+	setlists, err := s.GigRepo.ListSetlists(ctx, []int{gig.ID})
+	if err != nil {
+		return model.Gig{}, err
+	}
+	gig.Setlists = withSetlists(setlists[gig.ID])
+	return gig, nil
+}
+
+func (s GigService) List(ctx context.Context, bandID int) ([]model.Gig, error) {
+	gigs, err := s.GigRepo.ListByBandID(ctx, bandID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, len(gigs))
+	for i, gig := range gigs {
+		ids[i] = gig.ID
+	}
+	setlists, err := s.GigRepo.ListSetlists(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range gigs {
+		gigs[i].Setlists = withSetlists(setlists[gigs[i].ID])
+	}
+	return gigs, nil
+}
+
+// ArchivePastGigs archives the setlists of the gigs that are over and returns
+// how many were archived.
+func (s GigService) ArchivePastGigs(ctx context.Context, now time.Time) (int, error) {
+	archived, err := s.GigRepo.ArchivePastSetlists(ctx, now.Add(-gigArchiveDelay))
+	if err != nil {
+		return 0, err
+	}
+	bands := make(map[int]bool)
+	for _, setlist := range archived {
+		if !bands[setlist.BandID] {
+			bands[setlist.BandID] = true
+			cache.Delete(ctx, s.Cache, cache.SetlistKey(setlist.BandID))
+		}
+	}
+	return len(archived), nil
+}
+
+// RunAutoArchive calls ArchivePastGigs every interval until ctx is done.
+func (s GigService) RunAutoArchive(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		count, err := s.ArchivePastGigs(ctx, time.Now())
+		if err != nil {
+			log.Printf("[gigs] Échec de l'archivage automatique des setlists : %v", err)
+		} else if count > 0 {
+			log.Printf("[gigs] %d setlist(s) archivée(s) après leur concert", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// save validates the payload and writes the gig with its setlists; a gig
+// without an ID is created.
+func (s GigService) save(ctx context.Context, gig model.Gig, payload GigPayload) (model.Gig, error) {
+	if err := applyGigPayload(&gig, payload); err != nil {
+		return model.Gig{}, err
+	}
+
+	setlists := make([]model.GigSetlist, 0, len(payload.Setlists))
+	seen := make(map[int]bool, len(payload.Setlists))
+	for i, entry := range payload.Setlists {
+		if seen[entry.SetlistID] {
+			return model.Gig{}, &ValidationError{Msg: "Une setlist ne peut être liée qu'une fois au même concert."}
+		}
+		seen[entry.SetlistID] = true
+
+		setlist, err := s.SetlistRepo.GetSetlistByID(ctx, entry.SetlistID, gig.BandID)
+		if err != nil {
+			return model.Gig{}, mapNotFound(err, ErrSetlistNotFound)
+		}
+		if setlist.IsTemplate {
+			return model.Gig{}, &ValidationError{Msg: "Un modèle ne peut pas être lié à un concert."}
+		}
+		setlists = append(setlists, model.GigSetlist{
+			SetlistID: setlist.ID,
+			Name:      setlist.Name,
+			Color:     setlist.Color,
+			Position:  i,
+			StartsAt:  entry.StartsAt,
+		})
+	}
+
+	tx, err := s.GigRepo.BeginTx(ctx)
+	if err != nil {
+		return model.Gig{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if gig.ID == 0 {
+		err = s.GigRepo.Create(ctx, tx, &gig)
+	} else {
+		err = s.GigRepo.Update(ctx, tx, &gig)
+	}
+	if err != nil {
+		return model.Gig{}, mapNotFound(err, ErrGigNotFound)
+	}
+	if err := s.GigRepo.ReplaceSetlists(ctx, tx, gig.ID, setlists); err != nil {
+		return model.Gig{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return model.Gig{}, err
+	}
+	cache.Delete(ctx, s.Cache, cache.SetlistKey(gig.BandID))
+
+	gig.Setlists = setlists
+	return gig, nil
+}
+
+func applyGigPayload(gig *model.Gig, payload GigPayload) error {
+	if payload.Name == "" {
+		return &ValidationError{Msg: "Le nom du concert est requis."}
+	}
+	if payload.StartsAt == nil {
+		return &ValidationError{Msg: "La date du concert est requise."}
+	}
+	kind := payload.Kind
+	if kind == "" {
+		kind = model.GigKindGig
+	}
+	if kind != model.GigKindGig && kind != model.GigKindRehearsal {
+		return &ValidationError{Msg: "Type d'événement invalide."}
+	}
+	status := payload.Status
+	if status == "" {
+		status = model.GigStatusTentative
+	}
+	switch status {
+	case model.GigStatusTentative, model.GigStatusConfirmed, model.GigStatusCancelled:
+	default:
+		return &ValidationError{Msg: "Statut de concert invalide."}
+	}
+
+	gig.Kind = kind
+	gig.Name = payload.Name
+	gig.Venue = payload.Venue
+	gig.Address = payload.Address
+	gig.StartsAt = *payload.StartsAt
+	gig.LoadInAt = payload.LoadInAt
+	gig.SoundcheckAt = payload.SoundcheckAt
+	gig.Status = status
+	gig.Notes = payload.Notes
+	return nil
+}
+
+func withSetlists(setlists []model.GigSetlist) []model.GigSetlist {
+	if setlists == nil {
+		return make([]model.GigSetlist, 0)
+	}
+	return setlists
+}
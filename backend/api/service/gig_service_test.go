@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"setlist/api/model"
+	"setlist/api/repository"
+	"setlist/api/repository/mocks"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGigService_Create(t *testing.T) {
+	ctx := context.Background()
+	startsAt := time.Date(2026, 6, 21, 20, 30, 0, 0, time.UTC)
+
+	t.Run("links the setlists in order inside a transaction", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockGigRepo := mocks.NewMockGigRepository(ctrl)
+		mockSetlistRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
+		svc := GigService{GigRepo: mockGigRepo, SetlistRepo: mockSetlistRepo}
+
+		mockSetlistRepo.EXPECT().GetSetlistByID(ctx, 10, 1).Return(model.Setlist{ID: 10, BandID: 1, Name: "Set 1"}, nil)
+		mockSetlistRepo.EXPECT().GetSetlistByID(ctx, 11, 1).Return(model.Setlist{ID: 11, BandID: 1, Name: "Set 2"}, nil)
+		mockGigRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockGigRepo.EXPECT().Create(ctx, mockTx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ repository.DBTX, gig *model.Gig) error {
+				if gig.BandID != 1 || gig.Status != model.GigStatusTentative || !gig.StartsAt.Equal(startsAt) {
+					t.Errorf("unexpected gig: %+v", gig)
+				}
+				gig.ID = 5
+				return nil
+			})
+		mockGigRepo.EXPECT().ReplaceSetlists(ctx, mockTx, 5, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ repository.DBTX, _ int, setlists []model.GigSetlist) error {
+				if len(setlists) != 2 || setlists[0].SetlistID != 10 || setlists[1].Position != 1 {
+					t.Errorf("unexpected setlists: %+v", setlists)
+				}
+				return nil
+			})
+		mockTx.EXPECT().Commit(ctx).Return(nil)
+
+		gig, err := svc.Create(ctx, 1, GigPayload{
+			Name:     "Fête de la musique",
+			StartsAt: &startsAt,
+			Setlists: []GigSetlistPayload{{SetlistID: 10}, {SetlistID: 11}},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if gig.ID != 5 || len(gig.Setlists) != 2 || gig.Setlists[1].Name != "Set 2" {
+			t.Errorf("unexpected gig: %+v", gig)
+		}
+	})
+
+	t.Run("rejects a setlist from another band", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockSetlistRepo := mocks.NewMockSetlistRepository(ctrl)
+		svc := GigService{GigRepo: mocks.NewMockGigRepository(ctrl), SetlistRepo: mockSetlistRepo}
+
+		mockSetlistRepo.EXPECT().GetSetlistByID(ctx, 99, 1).Return(model.Setlist{}, pgx.ErrNoRows)
+
+		_, err := svc.Create(ctx, 1, GigPayload{Name: "Concert", StartsAt: &startsAt, Setlists: []GigSetlistPayload{{SetlistID: 99}}})
+		if !errors.Is(err, ErrSetlistNotFound) {
+			t.Fatalf("expected ErrSetlistNotFound, got %v", err)
+		}
+	})
+
+	t.Run("rejects a template", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockSetlistRepo := mocks.NewMockSetlistRepository(ctrl)
+		svc := GigService{GigRepo: mocks.NewMockGigRepository(ctrl), SetlistRepo: mockSetlistRepo}
+
+		mockSetlistRepo.EXPECT().GetSetlistByID(ctx, 30, 1).Return(model.Setlist{ID: 30, BandID: 1, IsTemplate: true}, nil)
+		// No transaction is started.
+
+		_, err := svc.Create(ctx, 1, GigPayload{Name: "Concert", StartsAt: &startsAt, Setlists: []GigSetlistPayload{{SetlistID: 30}}})
+		if !isValidationError(err) {
+			t.Fatalf("expected a ValidationError, got %v", err)
+		}
+	})
+
+	t.Run("validates the payload", func(t *testing.T) {
+		svc := GigService{}
+		cases := map[string]GigPayload{
+			"missing name":   {StartsAt: &startsAt},
+			"missing date":   {Name: "Concert"},
+			"unknown status": {Name: "Concert", StartsAt: &startsAt, Status: "maybe"},
+		}
+		for name, payload := range cases {
+			if _, err := svc.Create(ctx, 1, payload); !isValidationError(err) {
+				t.Errorf("%s: expected a ValidationError, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("rejects a setlist linked twice", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockSetlistRepo := mocks.NewMockSetlistRepository(ctrl)
+		svc := GigService{GigRepo: mocks.NewMockGigRepository(ctrl), SetlistRepo: mockSetlistRepo}
+
+		mockSetlistRepo.EXPECT().GetSetlistByID(ctx, 10, 1).Return(model.Setlist{ID: 10, BandID: 1}, nil)
+
+		_, err := svc.Create(ctx, 1, GigPayload{Name: "Concert", StartsAt: &startsAt, Setlists: []GigSetlistPayload{{SetlistID: 10}, {SetlistID: 10}}})
+		if !isValidationError(err) {
+			t.Fatalf("expected a ValidationError, got %v", err)
+		}
+	})
+}
+
+func TestGigService_Update_NotFound(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockGigRepo := mocks.NewMockGigRepository(ctrl)
+	svc := GigService{GigRepo: mockGigRepo}
+
+	mockGigRepo.EXPECT().GetByID(ctx, 5, 1).Return(model.Gig{}, pgx.ErrNoRows)
+
+	if _, err := svc.Update(ctx, 5, 1, GigPayload{Name: "Concert"}); !errors.Is(err, ErrGigNotFound) {
+		t.Fatalf("expected ErrGigNotFound, got %v", err)
+	}
+}
+
+func TestGigService_ArchivePastGigs(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockGigRepo := mocks.NewMockGigRepository(ctrl)
+	svc := GigService{GigRepo: mockGigRepo}
+
+	now := time.Date(2026, 6, 22, 12, 0, 0, 0, time.UTC)
+	mockGigRepo.EXPECT().ArchivePastSetlists(ctx, now.Add(-gigArchiveDelay)).Return([]repository.ArchivedSetlist{
+		{SetlistID: 10, BandID: 1},
+		{SetlistID: 11, BandID: 1},
+	}, nil)
+
+	count, err := svc.ArchivePastGigs(ctx, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 archived setlists, got %d", count)
+	}
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"setlist/api/model"
+	"setlist/api/repository"
+	"setlist/realtime"
+	"time"
+)
+
+const LiveEventState = "live_state"
+
+var ErrNoLiveSession = errors.New("no live session is running for this setlist")
+
+// LiveService drives the "now playing" pointer of a setlist during a show.
+// Every change is published on the setlist's live topic so connected members
+// follow along.
+type LiveService struct {
+	LiveRepo    repository.LiveSessionRepository
+	SetlistRepo repository.SetlistRepository
+	Broker      *realtime.Broker
+}
+
+type JumpPayload struct {
+	ItemID int `json:"item_id"`
+}
+
+// LiveState is what members see during a show. Index and ItemCount only count
+// the items that are performed: set break and encore markers are skipped.
+type LiveState struct {
+	SetlistID            int                `json:"setlist_id"`
+	Active               bool               `json:"active"`
+	Current              *model.SetlistItem `json:"current"`
+	Next                 *model.SetlistItem `json:"next"`
+	Index                int                `json:"index"`
+	ItemCount            int                `json:"item_count"`
+	StartedAt            *time.Time         `json:"started_at,omitempty"`
+	ItemStartedAt        *time.Time         `json:"item_started_at,omitempty"`
+	ElapsedSeconds       int                `json:"elapsed_seconds"`
+	ItemElapsedSeconds   int                `json:"item_elapsed_seconds"`
+	PlannedOffsetSeconds int                `json:"planned_offset_seconds"`
+	ServerTime           time.Time          `json:"server_time"`
+}
+
+// Refresh recomputes the elapsed times for the given instant.
+func (st *LiveState) Refresh(now time.Time) {
+	st.ServerTime = now
+	if st.StartedAt != nil {
+		st.ElapsedSeconds = int(now.Sub(*st.StartedAt).Seconds())
+	}
+	if st.ItemStartedAt != nil {
+		st.ItemElapsedSeconds = int(now.Sub(*st.ItemStartedAt).Seconds())
+	}
+}
+
+func (s LiveService) GetState(ctx context.Context, setlistID int, bandID int) (LiveState, error) {
+	items, err := s.loadItems(ctx, setlistID, bandID)
+	if err != nil {
+		return LiveState{}, err
+	}
+	session, err := s.LiveRepo.Get(ctx, setlistID)
+	if err != nil {
+		if isNotFound(err) {
+			return inactiveState(setlistID, len(playableItems(items))), nil
+		}
+		return LiveState{}, err
+	}
+	return buildLiveState(session, items), nil
+}
+
+// Start opens the live session on the first performed item, or sends a running
+// session back to it.
+func (s LiveService) Start(ctx context.Context, setlistID int, bandID int, userID int) (LiveState, error) {
+	items, err := s.loadItems(ctx, setlistID, bandID)
+	if err != nil {
+		return LiveState{}, err
+	}
+	playable := playableItems(items)
+	if len(playable) == 0 {
+		return LiveState{}, &ValidationError{Msg: "La setlist ne contient aucun élément à jouer."}
+	}
+
+	session := model.LiveSession{SetlistID: setlistID, CurrentItemID: &playable[0].ID, StartedBy: &userID}
+	if err := s.LiveRepo.Start(ctx, &session); err != nil {
+		return LiveState{}, err
+	}
+	return s.publish(buildLiveState(session, items)), nil
+}
+
+// Next moves to the following item. At the end of the setlist the pointer
+// stays on the last item.
+func (s LiveService) Next(ctx context.Context, setlistID int, bandID int) (LiveState, error) {
+	return s.step(ctx, setlistID, bandID, 1)
+}
+
+func (s LiveService) Previous(ctx context.Context, setlistID int, bandID int) (LiveState, error) {
+	return s.step(ctx, setlistID, bandID, -1)
+}
+
+func (s LiveService) Jump(ctx context.Context, setlistID int, bandID int, payload JumpPayload) (LiveState, error) {
+	items, session, err := s.loadSession(ctx, setlistID, bandID)
+	if err != nil {
+		return LiveState{}, err
+	}
+	if playableIndex(playableItems(items), payload.ItemID) < 0 {
+		return LiveState{}, ErrItemNotFound
+	}
+	return s.moveTo(ctx, session, items, payload.ItemID)
+}
+
+func (s LiveService) Stop(ctx context.Context, setlistID int, bandID int) (LiveState, error) {
+	items, _, err := s.loadSession(ctx, setlistID, bandID)
+	if err != nil {
+		return LiveState{}, err
+	}
+	if err := s.LiveRepo.Delete(ctx, setlistID); err != nil {
+		return LiveState{}, err
+	}
+	return s.publish(inactiveState(setlistID, len(playableItems(items)))), nil
+}
+
+func (s LiveService) step(ctx context.Context, setlistID int, bandID int, delta int) (LiveState, error) {
+	items, session, err := s.loadSession(ctx, setlistID, bandID)
+	if err != nil {
+		return LiveState{}, err
+	}
+	playable := playableItems(items)
+	if len(playable) == 0 {
+		return LiveState{}, &ValidationError{Msg: "La setlist ne contient aucun élément à jouer."}
+	}
+
+	// When the current item was deleted from the setlist, start over from the
+	// first item rather than guessing where the band is.
+	target := 0
+	if session.CurrentItemID != nil {
+		if i := playableIndex(playable, *session.CurrentItemID); i >= 0 {
+			target = min(max(i+delta, 0), len(playable)-1)
+			if target == i {
+				return buildLiveState(session, items), nil
+			}
+		}
+	}
+	return s.moveTo(ctx, session, items, playable[target].ID)
+}
+
+func (s LiveService) moveTo(ctx context.Context, session model.LiveSession, items []model.SetlistItem, itemID int) (LiveState, error) {
+	session, err := s.LiveRepo.MoveTo(ctx, session.SetlistID, itemID)
+	if err != nil {
+		return LiveState{}, mapNotFound(err, ErrNoLiveSession)
+	}
+	return s.publish(buildLiveState(session, items)), nil
+}
+
+// loadItems returns the items of a setlist that can be played live, which a
+// template cannot.
+func (s LiveService) loadItems(ctx context.Context, setlistID int, bandID int) ([]model.SetlistItem, error) {
+	setlist, err := s.SetlistRepo.GetSetlistByID(ctx, setlistID, bandID)
+	if err != nil {
+		return nil, mapNotFound(err, ErrSetlistNotFound)
+	}
+	if setlist.IsTemplate {
+		return nil, &ValidationError{Msg: "Un modèle ne peut pas être joué en live."}
+	}
+	return s.SetlistRepo.GetSetlistItemsBySetlistID(ctx, setlistID)
+}
+
+func (s LiveService) loadSession(ctx context.Context, setlistID int, bandID int) ([]model.SetlistItem, model.LiveSession, error) {
+	items, err := s.loadItems(ctx, setlistID, bandID)
+	if err != nil {
+		return nil, model.LiveSession{}, err
+	}
+	session, err := s.LiveRepo.Get(ctx, setlistID)
+	if err != nil {
+		return nil, model.LiveSession{}, mapNotFound(err, ErrNoLiveSession)
+	}
+	return items, session, nil
+}
+
+func (s LiveService) publish(state LiveState) LiveState {
+	event, err := realtime.NewEvent(LiveEventState, state)
+	if err != nil {
+		log.Printf("[realtime] Impossible d'encoder l'état du live de la setlist %d : %v", state.SetlistID, err)
+		return state
+	}
+	s.Broker.Publish(realtime.LiveTopic(state.SetlistID), event)
+	return state
+}
+
+func inactiveState(setlistID int, itemCount int) LiveState {
+	return LiveState{SetlistID: setlistID, ItemCount: itemCount, ServerTime: time.Now()}
+}
+
+func buildLiveState(session model.LiveSession, items []model.SetlistItem) LiveState {
+	playable := playableItems(items)
+	state := LiveState{
+		SetlistID:     session.SetlistID,
+		Active:        true,
+		Index:         -1,
+		ItemCount:     len(playable),
+		StartedAt:     &session.StartedAt,
+		ItemStartedAt: &session.ItemStartedAt,
+	}
+
+	if session.CurrentItemID != nil {
+		if i := playableIndex(playable, *session.CurrentItemID); i >= 0 {
+			state.Index = i
+			state.Current = &playable[i]
+			if i+1 < len(playable) {
+				state.Next = &playable[i+1]
+			}
+			for _, timed := range ComputeTiming(items, nil).Items {
+				if timed.ItemID == state.Current.ID {
+					state.PlannedOffsetSeconds = timed.StartOffsetSeconds
+					break
+				}
+			}
+		}
+	}
+	state.Refresh(time.Now())
+	return state
+}
+
+func playableItems(items []model.SetlistItem) []model.SetlistItem {
+	playable := make([]model.SetlistItem, 0, len(items))
+	for _, item := range items {
+		if !isZeroLengthMarker(item.ItemType) {
+			playable = append(playable, item)
+		}
+	}
+	return playable
+}
+
+func playableIndex(playable []model.SetlistItem, itemID int) int {
+	for i, item := range playable {
+		if item.ID == itemID {
+			return i
+		}
+	}
+	return -1
+}
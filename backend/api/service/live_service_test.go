@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"setlist/api/model"
+	"setlist/api/repository/mocks"
+	"setlist/realtime"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/mock/gomock"
+)
+
+func liveFixture() []model.SetlistItem {
+	return []model.SetlistItem{
+		{ID: 1, Position: 0, ItemType: model.ItemTypeSong, Title: strPtr("Intro"), DurationSeconds: int32Ptr(120)},
+		{ID: 2, Position: 1, ItemType: model.ItemTypeSetBreak},
+		{ID: 3, Position: 2, ItemType: model.ItemTypeSong, Title: strPtr("Ballade"), DurationSeconds: int32Ptr(200)},
+		{ID: 4, Position: 3, ItemType: model.ItemTypeEncore},
+		{ID: 5, Position: 4, ItemType: model.ItemTypeSong, Title: strPtr("Final"), DurationSeconds: int32Ptr(180)},
+	}
+}
+
+func liveSession(currentItemID int) model.LiveSession {
+	now := time.Now()
+	return model.LiveSession{SetlistID: 10, CurrentItemID: &currentItemID, StartedAt: now.Add(-5 * time.Minute), ItemStartedAt: now.Add(-time.Minute)}
+}
+
+func newLiveService(ctrl *gomock.Controller, broker *realtime.Broker) (LiveService, *mocks.MockLiveSessionRepository, *mocks.MockSetlistRepository) {
+	mockLiveRepo := mocks.NewMockLiveSessionRepository(ctrl)
+	mockSetlistRepo := mocks.NewMockSetlistRepository(ctrl)
+	return LiveService{LiveRepo: mockLiveRepo, SetlistRepo: mockSetlistRepo, Broker: broker}, mockLiveRepo, mockSetlistRepo
+}
+
+func TestLiveService_GetState(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reports an inactive session", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc, mockLiveRepo, mockSetlistRepo := newLiveService(ctrl, nil)
+		mockSetlistRepo.EXPECT().GetSetlistByID(ctx, 10, 1).Return(model.Setlist{ID: 10}, nil)
+		mockSetlistRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, 10).Return(liveFixture(), nil)
+		mockLiveRepo.EXPECT().Get(ctx, 10).Return(model.LiveSession{}, pgx.ErrNoRows)
+
+		state, err := svc.GetState(ctx, 10, 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if state.Active || state.Current != nil || state.ItemCount != 3 {
+			t.Errorf("unexpected state: %+v", state)
+		}
+	})
+
+	t.Run("returns the current and next items skipping section markers", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc, mockLiveRepo, mockSetlistRepo := newLiveService(ctrl, nil)
+		mockSetlistRepo.EXPECT().GetSetlistByID(ctx, 10, 1).Return(model.Setlist{ID: 10}, nil)
+		mockSetlistRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, 10).Return(liveFixture(), nil)
+		mockLiveRepo.EXPECT().Get(ctx, 10).Return(liveSession(3), nil)
+
+		state, err := svc.GetState(ctx, 10, 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !state.Active || state.Current.ID != 3 || state.Next.ID != 5 || state.Index != 1 {
+			t.Errorf("unexpected state: %+v", state)
+		}
+		if state.PlannedOffsetSeconds != 120 {
+			t.Errorf("expected planned offset 120, got %d", state.PlannedOffsetSeconds)
+		}
+		if state.ElapsedSeconds < 299 || state.ItemElapsedSeconds < 59 {
+			t.Errorf("unexpected elapsed times: %d, %d", state.ElapsedSeconds, state.ItemElapsedSeconds)
+		}
+	})
+
+	t.Run("rejects a setlist of another band", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc, _, mockSetlistRepo := newLiveService(ctrl, nil)
+		mockSetlistRepo.EXPECT().GetSetlistByID(ctx, 10, 2).Return(model.Setlist{}, pgx.ErrNoRows)
+
+		if _, err := svc.GetState(ctx, 10, 2); !errors.Is(err, ErrSetlistNotFound) {
+			t.Errorf("expected ErrSetlistNotFound, got %v", err)
+		}
+	})
+}
+
+func TestLiveService_Start(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	broker := realtime.NewBroker(nil)
+	events, unsubscribe := broker.Subscribe(realtime.LiveTopic(10))
+	defer unsubscribe()
+
+	svc, mockLiveRepo, mockSetlistRepo := newLiveService(ctrl, broker)
+	items := append([]model.SetlistItem{{ID: 9, Position: 0, ItemType: model.ItemTypeSetBreak}}, liveFixture()...)
+	mockSetlistRepo.EXPECT().GetSetlistByID(ctx, 10, 1).Return(model.Setlist{ID: 10}, nil)
+	mockSetlistRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, 10).Return(items, nil)
+	mockLiveRepo.EXPECT().Start(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, session *model.LiveSession) error {
+		if *session.CurrentItemID != 1 || *session.StartedBy != userID {
+			t.Errorf("unexpected session: %+v", session)
+		}
+		session.StartedAt, session.ItemStartedAt = time.Now(), time.Now()
+		return nil
+	})
+
+	state, err := svc.Start(ctx, 10, 1, userID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state.Current.ID != 1 || state.Next.ID != 3 {
+		t.Errorf("unexpected state: %+v", state)
+	}
+
+	select {
+	case event := <-events:
+		if event.Type != LiveEventState {
+			t.Errorf("unexpected event type %q", event.Type)
+		}
+	default:
+		t.Error("expected the new state to be published")
+	}
+}
+
+func TestLiveService_Step(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		current  int
+		delta    int
+		expected int
+		moves    bool
+	}{
+		{"next skips the set break", 1, 1, 3, true},
+		{"previous skips the encore marker", 5, -1, 3, true},
+		{"next stays on the last item", 5, 1, 5, false},
+		{"previous stays on the first item", 1, -1, 1, false},
+		{"restarts from the first item when the current one was deleted", 42, 1, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			svc, mockLiveRepo, mockSetlistRepo := newLiveService(ctrl, nil)
+			mockSetlistRepo.EXPECT().GetSetlistByID(ctx, 10, 1).Return(model.Setlist{ID: 10}, nil)
+			mockSetlistRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, 10).Return(liveFixture(), nil)
+			mockLiveRepo.EXPECT().Get(ctx, 10).Return(liveSession(tt.current), nil)
+			if tt.moves {
+				mockLiveRepo.EXPECT().MoveTo(ctx, 10, tt.expected).Return(liveSession(tt.expected), nil)
+			}
+
+			var state LiveState
+			var err error
+			if tt.delta > 0 {
+				state, err = svc.Next(ctx, 10, 1)
+			} else {
+				state, err = svc.Previous(ctx, 10, 1)
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if state.Current == nil || state.Current.ID != tt.expected {
+				t.Errorf("expected current item %d, got %+v", tt.expected, state.Current)
+			}
+		})
+	}
+}
+
+func TestLiveService_Jump(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rejects a section marker", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc, mockLiveRepo, mockSetlistRepo := newLiveService(ctrl, nil)
+		mockSetlistRepo.EXPECT().GetSetlistByID(ctx, 10, 1).Return(model.Setlist{ID: 10}, nil)
+		mockSetlistRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, 10).Return(liveFixture(), nil)
+		mockLiveRepo.EXPECT().Get(ctx, 10).Return(liveSession(1), nil)
+
+		if _, err := svc.Jump(ctx, 10, 1, JumpPayload{ItemID: 2}); !errors.Is(err, ErrItemNotFound) {
+			t.Errorf("expected ErrItemNotFound, got %v", err)
+		}
+	})
+
+	t.Run("fails without a running session", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc, mockLiveRepo, mockSetlistRepo := newLiveService(ctrl, nil)
+		mockSetlistRepo.EXPECT().GetSetlistByID(ctx, 10, 1).Return(model.Setlist{ID: 10}, nil)
+		mockSetlistRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, 10).Return(liveFixture(), nil)
+		mockLiveRepo.EXPECT().Get(ctx, 10).Return(model.LiveSession{}, pgx.ErrNoRows)
+
+		if _, err := svc.Jump(ctx, 10, 1, JumpPayload{ItemID: 5}); !errors.Is(err, ErrNoLiveSession) {
+			t.Errorf("expected ErrNoLiveSession, got %v", err)
+		}
+	})
+}
+
+func TestLiveService_RejectsTemplate(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svc, _, mockSetlistRepo := newLiveService(ctrl, nil)
+	mockSetlistRepo.EXPECT().GetSetlistByID(ctx, 30, 1).Return(model.Setlist{ID: 30, IsTemplate: true}, nil)
+	// No live session is started.
+
+	if _, err := svc.Start(ctx, 30, 1, userID); !isValidationError(err) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+}
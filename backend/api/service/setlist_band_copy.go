@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"setlist/api/model"
+	"setlist/cache"
+	"strings"
+)
+
+const (
+	// BandCopyMatched marks a song or interlude of the target band reused
+	// because it has the same title.
+	BandCopyMatched = "matched"
+	// BandCopyCreated marks a song or interlude created in the target band.
+	BandCopyCreated = "created"
+)
+
+// CopyToBandPayload names the band to copy a setlist into; the copy keeps
+// the name of the setlist unless Name is set.
+type CopyToBandPayload struct {
+	BandID int    `json:"band_id"`
+	Name   string `json:"name"`
+}
+
+// BandCopyEntry tells what became of a song or interlude of the setlist in
+// the target band.
+type BandCopyEntry struct {
+	SourceID int    `json:"source_id"`
+	TargetID int    `json:"target_id"`
+	Title    string `json:"title"`
+	Action   string `json:"action"`
+}
+
+// BandCopyReport is the setlist created in the target band and how its songs
+// and interludes were matched or created.
+type BandCopyReport struct {
+	Setlist    model.Setlist   `json:"setlist"`
+	Songs      []BandCopyEntry `json:"songs"`
+	Interludes []BandCopyEntry `json:"interludes"`
+}
+
+// CopyToBand copies a setlist of the band into another band the user is a
+// member of. The songs and interludes it references are matched by title
+// with those of the target band, case aside, or created there.
+func (s SetlistService) CopyToBand(ctx context.Context, setlistID int, bandID int, userID int, payload CopyToBandPayload) (BandCopyReport, error) {
+	if payload.BandID == bandID {
+		return BandCopyReport{}, &ValidationError{Msg: "Choisissez un autre groupe que celui de la setlist."}
+	}
+	member, err := s.UserRepo.IsUserInBand(ctx, userID, payload.BandID)
+	if err != nil {
+		return BandCopyReport{}, err
+	}
+	if !member {
+		return BandCopyReport{}, ErrBandNotFoundOrNotMember
+	}
+
+	source, err := s.SetlistRepo.GetSetlistByID(ctx, setlistID, bandID)
+	if err != nil {
+		return BandCopyReport{}, mapNotFound(err, ErrSetlistNotFound)
+	}
+	items, err := s.SetlistRepo.GetSetlistItemsBySetlistID(ctx, setlistID)
+	if err != nil {
+		return BandCopyReport{}, err
+	}
+	name := strings.TrimSpace(payload.Name)
+	if name == "" {
+		name = source.Name
+	}
+
+	targetSongs, err := s.SongRepo.GetAllSongsByBandID(ctx, payload.BandID)
+	if err != nil {
+		return BandCopyReport{}, err
+	}
+	songsByTitle := make(map[string]int, len(targetSongs))
+	for _, song := range targetSongs {
+		songsByTitle[titleKey(song.Title)] = song.ID
+	}
+	targetInterludes, err := s.InterludeRepo.GetAllInterludesByBandID(ctx, payload.BandID)
+	if err != nil {
+		return BandCopyReport{}, err
+	}
+	interludesByTitle := make(map[string]int, len(targetInterludes))
+	for _, interlude := range targetInterludes {
+		interludesByTitle[titleKey(interlude.Title)] = interlude.ID
+	}
+
+	tx, err := s.SetlistRepo.BeginTx(ctx)
+	if err != nil {
+		return BandCopyReport{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	report := BandCopyReport{Songs: make([]BandCopyEntry, 0), Interludes: make([]BandCopyEntry, 0)}
+	songIDs := make(map[int32]int32)
+	interludeIDs := make(map[int32]int32)
+	for i, item := range items {
+		switch {
+		case item.SongID != nil:
+			targetID, ok := songIDs[*item.SongID]
+			if !ok {
+				entry := BandCopyEntry{SourceID: int(*item.SongID), Title: itemTitle(item), Action: BandCopyMatched}
+				if id, found := songsByTitle[titleKey(entry.Title)]; found {
+					entry.TargetID = id
+				} else {
+					song, err := s.sourceSong(ctx, item, bandID)
+					if err != nil {
+						return BandCopyReport{}, err
+					}
+					song.BandID = payload.BandID
+					created, err := s.SongRepo.InsertSong(ctx, tx, song)
+					if err != nil {
+						return BandCopyReport{}, err
+					}
+					entry.TargetID, entry.Action = created.ID, BandCopyCreated
+					songsByTitle[titleKey(entry.Title)] = created.ID
+				}
+				targetID = int32(entry.TargetID)
+				songIDs[*item.SongID] = targetID
+				report.Songs = append(report.Songs, entry)
+			}
+			items[i].SongID = &targetID
+
+		case item.InterludeID != nil:
+			targetID, ok := interludeIDs[*item.InterludeID]
+			if !ok {
+				entry := BandCopyEntry{SourceID: int(*item.InterludeID), Title: itemTitle(item), Action: BandCopyMatched}
+				if id, found := interludesByTitle[titleKey(entry.Title)]; found {
+					entry.TargetID = id
+				} else {
+					interlude, err := s.InterludeRepo.GetInterludeByID(ctx, entry.SourceID, bandID)
+					if err != nil {
+						return BandCopyReport{}, mapNotFound(err, ErrItemNotFound)
+					}
+					interlude.BandID = payload.BandID
+					created, err := s.InterludeRepo.InsertInterlude(ctx, tx, interlude)
+					if err != nil {
+						return BandCopyReport{}, err
+					}
+					entry.TargetID, entry.Action = created.ID, BandCopyCreated
+					interludesByTitle[titleKey(entry.Title)] = created.ID
+				}
+				targetID = int32(entry.TargetID)
+				interludeIDs[*item.InterludeID] = targetID
+				report.Interludes = append(report.Interludes, entry)
+			}
+			items[i].InterludeID = &targetID
+		}
+	}
+
+	report.Setlist, err = s.SetlistRepo.CreateSetlist(ctx, tx, name, source.Color, payload.BandID, source.IsTemplate)
+	if err != nil {
+		return BandCopyReport{}, err
+	}
+	if err := s.SetlistRepo.CopyItemsToNewSetlist(ctx, tx, report.Setlist.ID, items); err != nil {
+		return BandCopyReport{}, err
+	}
+	if err := s.recordRevision(ctx, tx, report.Setlist.ID, userID, RevisionActionCopyToBand); err != nil {
+		return BandCopyReport{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return BandCopyReport{}, err
+	}
+
+	cache.Delete(ctx, s.Cache, cache.SetlistKey(payload.BandID))
+	cache.Delete(ctx, s.Cache, cache.SongKey(payload.BandID))
+	return report, nil
+}
+
+// sourceSong returns the song of an item ready to be created in another
+// band. A song deleted from the library since is rebuilt from the item.
+func (s SetlistService) sourceSong(ctx context.Context, item model.SetlistItem, bandID int) (model.Song, error) {
+	song, err := s.SongRepo.GetSongByID(ctx, int(*item.SongID), bandID)
+	if err == nil {
+		return song, nil
+	}
+	if !isNotFound(err) {
+		return model.Song{}, err
+	}
+	return model.Song{
+		Title:           itemTitle(item),
+		DurationSeconds: item.DurationSeconds,
+		Tempo:           item.Tempo,
+		SongKey:         item.SongKey,
+		Instrumentation: json.RawMessage("null"),
+	}, nil
+}
+
+func titleKey(title string) string {
+	return strings.ToLower(strings.TrimSpace(title))
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"setlist/api/model"
+	"setlist/api/repository/mocks"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestSetlistService_CopyToBand(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("matches songs by title and creates the missing ones", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockSongRepo := mocks.NewMockSongRepository(ctrl)
+		mockInterludeRepo := mocks.NewMockInterludeRepository(ctrl)
+		mockUserRepo := mocks.NewMockUserRepository(ctrl)
+		mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo, SongRepo: mockSongRepo, InterludeRepo: mockInterludeRepo, UserRepo: mockUserRepo, RevisionRepo: mockRevisionRepo}
+
+		shared, fresh, speech := int32(5), int32(6), int32(7)
+		items := []model.SetlistItem{
+			{ID: 1, Position: 0, ItemType: model.ItemTypeSong, SongID: &shared, Title: strPtr("Hey Jude")},
+			{ID: 2, Position: 1, ItemType: model.ItemTypeInterlude, InterludeID: &speech, Title: strPtr("Présentation")},
+			{ID: 3, Position: 2, ItemType: model.ItemTypeSong, SongID: &fresh, Title: strPtr("Inédit")},
+			{ID: 4, Position: 3, ItemType: model.ItemTypeSong, SongID: &shared, Title: strPtr("Hey Jude")},
+		}
+
+		mockUserRepo.EXPECT().IsUserInBand(ctx, userID, 2).Return(true, nil)
+		mockRepo.EXPECT().GetSetlistByID(ctx, 10, 1).Return(model.Setlist{ID: 10, BandID: 1, Name: "Tournée", Color: "#123456"}, nil)
+		mockRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, 10).Return(items, nil)
+		mockSongRepo.EXPECT().GetAllSongsByBandID(ctx, 2).Return([]model.Song{{ID: 50, Title: " hey jude"}}, nil)
+		mockInterludeRepo.EXPECT().GetAllInterludesByBandID(ctx, 2).Return([]model.Interlude{}, nil)
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockInterludeRepo.EXPECT().GetInterludeByID(ctx, 7, 1).Return(model.Interlude{ID: 7, BandID: 1, Title: "Présentation"}, nil)
+		mockInterludeRepo.EXPECT().InsertInterlude(ctx, mockTx, model.Interlude{ID: 7, BandID: 2, Title: "Présentation"}).
+			Return(model.Interlude{ID: 70, BandID: 2, Title: "Présentation"}, nil)
+		mockSongRepo.EXPECT().GetSongByID(ctx, 6, 1).Return(model.Song{ID: 6, BandID: 1, Title: "Inédit"}, nil)
+		mockSongRepo.EXPECT().InsertSong(ctx, mockTx, model.Song{ID: 6, BandID: 2, Title: "Inédit"}).
+			Return(model.Song{ID: 60, BandID: 2, Title: "Inédit"}, nil)
+		mockRepo.EXPECT().CreateSetlist(ctx, mockTx, "Tournée", "#123456", 2, false).Return(model.Setlist{ID: 20, BandID: 2}, nil)
+		mockRepo.EXPECT().CopyItemsToNewSetlist(ctx, mockTx, 20, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ any, _ int, copied []model.SetlistItem) error {
+				if *copied[0].SongID != 50 || *copied[1].InterludeID != 70 || *copied[2].SongID != 60 || *copied[3].SongID != 50 {
+					t.Errorf("items not remapped to the target band: %+v", copied)
+				}
+				return nil
+			})
+		mockRevisionRepo.EXPECT().Record(ctx, mockTx, 20, userID, RevisionActionCopyToBand).Return(nil)
+		mockTx.EXPECT().Commit(ctx).Return(nil)
+
+		report, err := svc.CopyToBand(ctx, 10, 1, userID, CopyToBandPayload{BandID: 2})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if report.Setlist.ID != 20 || len(report.Songs) != 2 || len(report.Interludes) != 1 {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+		if report.Songs[0] != (BandCopyEntry{SourceID: 5, TargetID: 50, Title: "Hey Jude", Action: BandCopyMatched}) {
+			t.Errorf("unexpected song entry: %+v", report.Songs[0])
+		}
+		if report.Songs[1].Action != BandCopyCreated || report.Interludes[0].Action != BandCopyCreated {
+			t.Errorf("expected created entries, got %+v and %+v", report.Songs[1], report.Interludes[0])
+		}
+	})
+
+	t.Run("requires membership of the target band", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockUserRepo := mocks.NewMockUserRepository(ctrl)
+		svc := SetlistService{UserRepo: mockUserRepo}
+
+		mockUserRepo.EXPECT().IsUserInBand(ctx, userID, 3).Return(false, nil)
+
+		if _, err := svc.CopyToBand(ctx, 10, 1, userID, CopyToBandPayload{BandID: 3}); !errors.Is(err, ErrBandNotFoundOrNotMember) {
+			t.Fatalf("expected ErrBandNotFoundOrNotMember, got %v", err)
+		}
+	})
+
+	t.Run("rejects the band of the setlist", func(t *testing.T) {
+		svc := SetlistService{}
+		if _, err := svc.CopyToBand(ctx, 10, 1, userID, CopyToBandPayload{BandID: 1}); !isValidationError(err) {
+			t.Fatalf("expected a ValidationError, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"setlist/api/model"
+	"strings"
+)
+
+const ExportFormatChordPro = "chordpro"
+
+// inlineChordRegex spots chords already written the ChordPro way, e.g. [Am].
+var inlineChordRegex = regexp.MustCompile(`\[[A-G][^\]\s]*\]`)
+
+// renderChordProExport concatenates the songs of the setlist, in setlist
+// order, into a single ChordPro songbook. Interludes, set headings and
+// intermissions become comments between the songs so the running order is
+// still readable from the songbook; so does the name of each group, before
+// its first item.
+func renderChordProExport(details SetlistDetails, timing SetlistTiming, songs map[int32]model.Song) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# %s\n# %s\n", details.Name, exportSummary(timing))
+
+	sets := setsByFirstItem(timing)
+	groups := groupsByFirstItem(timing)
+	songCount := 0
+	for _, item := range details.Items {
+		if set, ok := sets[item.ID]; ok {
+			b.WriteString("\n")
+			chordProDirective(&b, "comment", fmt.Sprintf("%s · %s", setTitle(set), formatDuration(set.DurationSeconds)))
+		}
+		if group, ok := groups[item.ID]; ok {
+			b.WriteString("\n")
+			chordProDirective(&b, "comment_box", groupTitle(group))
+		}
+
+		switch item.ItemType {
+		case model.ItemTypeSong:
+			b.WriteString("\n")
+			if songCount > 0 {
+				b.WriteString("{new_song}\n")
+			}
+			songCount++
+			chordProDirective(&b, "title", itemTitle(item))
+			chordProDirective(&b, "key", valueOrEmpty(item.SongKey))
+			if item.Tempo != nil {
+				chordProDirective(&b, "tempo", fmt.Sprintf("%d", *item.Tempo))
+			}
+			if item.DurationSeconds != nil {
+				chordProDirective(&b, "duration", formatDuration(int(*item.DurationSeconds)))
+			}
+			if item.Notes != nil && *item.Notes != "" {
+				chordProComments(&b, "comment_italic", *item.Notes)
+			}
+			if item.SongID != nil {
+				writeChordProSheet(&b, songs[*item.SongID])
+			}
+		case model.ItemTypeInterlude:
+			b.WriteString("\n")
+			chordProDirective(&b, "comment", "Interlude : "+interludeLabel(item))
+			chordProComments(&b, "comment_italic", valueOrEmpty(item.Script))
+		case model.ItemTypeIntermission:
+			b.WriteString("\n")
+			chordProDirective(&b, "comment", fmt.Sprintf("%s · %s", itemTitle(item), formatItemDuration(item.DurationSeconds)))
+		case model.ItemTypePlaceholder:
+			b.WriteString("\n")
+			chordProDirective(&b, "comment", "À définir : "+itemTitle(item))
+		}
+	}
+	return b.Bytes()
+}
+
+// writeChordProSheet prints the body of a song. Chords that are already
+// written inline replace the lyrics; a chord chart is kept in a tab block so
+// that chords stay aligned above the words.
+func writeChordProSheet(b *bytes.Buffer, song model.Song) {
+	lyrics := chordProText(valueOrEmpty(song.Lyrics))
+	chords := chordProText(valueOrEmpty(song.Chords))
+
+	if chords != "" && inlineChordRegex.MatchString(chords) {
+		fmt.Fprintf(b, "\n%s\n", chords)
+		return
+	}
+	if lyrics != "" {
+		fmt.Fprintf(b, "\n{start_of_verse}\n%s\n{end_of_verse}\n", lyrics)
+	}
+	if chords != "" {
+		fmt.Fprintf(b, "\n{start_of_tab}\n%s\n{end_of_tab}\n", chords)
+	}
+}
+
+// chordProDirective writes a single-line directive, skipping empty values.
+func chordProDirective(b *bytes.Buffer, name, value string) {
+	value = strings.Join(strings.Fields(value), " ")
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(b, "{%s: %s}\n", name, strings.NewReplacer("{", "(", "}", ")").Replace(value))
+}
+
+// chordProComments writes a multi-line text as one comment per line.
+func chordProComments(b *bytes.Buffer, name, text string) {
+	for _, line := range strings.Split(chordProText(text), "\n") {
+		chordProDirective(b, name, line)
+	}
+}
+
+func chordProText(s string) string {
+	return strings.TrimSpace(strings.ReplaceAll(s, "\r\n", "\n"))
+}
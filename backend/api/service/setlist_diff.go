@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"setlist/api/model"
+)
+
+// DiffOptions picks what the current setlist is compared with: another
+// setlist of the band, or one of its own revisions. ToRevisionID compares two
+// revisions instead of a revision and the current state.
+type DiffOptions struct {
+	AgainstID    *int
+	RevisionID   *int
+	ToRevisionID *int
+}
+
+type DiffItem struct {
+	ItemType    string  `json:"item_type"`
+	SongID      *int32  `json:"song_id,omitempty"`
+	InterludeID *int32  `json:"interlude_id,omitempty"`
+	Title       *string `json:"title,omitempty"`
+	Occurrence  int     `json:"occurrence"`
+	Position    int     `json:"position"`
+}
+
+type MovedItem struct {
+	DiffItem
+	FromPosition int `json:"from_position"`
+}
+
+type NotesChange struct {
+	DiffItem
+	Before *string `json:"before"`
+	After  *string `json:"after"`
+}
+
+type SetlistDiff struct {
+	Added                 []DiffItem    `json:"added"`
+	Removed               []DiffItem    `json:"removed"`
+	Moved                 []MovedItem   `json:"moved"`
+	NotesChanged          []NotesChange `json:"notes_changed"`
+	BaseDurationSeconds   int           `json:"base_duration_seconds"`
+	TargetDurationSeconds int           `json:"target_duration_seconds"`
+	DurationDeltaSeconds  int           `json:"duration_delta_seconds"`
+}
+
+func (s SetlistService) Diff(ctx context.Context, id int, bandID int, opts DiffOptions) (SetlistDiff, error) {
+	var base, target []model.SetlistItem
+
+	switch {
+	case opts.AgainstID != nil:
+		other, err := s.GetDetails(ctx, *opts.AgainstID, bandID)
+		if err != nil {
+			return SetlistDiff{}, err
+		}
+		base = other.Items
+	case opts.RevisionID != nil:
+		revision, err := s.GetRevision(ctx, id, bandID, *opts.RevisionID)
+		if err != nil {
+			return SetlistDiff{}, err
+		}
+		base = revision.Snapshot.Items
+	default:
+		return SetlistDiff{}, &ValidationError{Msg: "Indiquez une setlist ou une révision à comparer."}
+	}
+
+	if opts.ToRevisionID != nil {
+		revision, err := s.GetRevision(ctx, id, bandID, *opts.ToRevisionID)
+		if err != nil {
+			return SetlistDiff{}, err
+		}
+		target = revision.Snapshot.Items
+	} else {
+		current, err := s.GetDetails(ctx, id, bandID)
+		if err != nil {
+			return SetlistDiff{}, err
+		}
+		target = current.Items
+	}
+
+	return DiffItems(base, target), nil
+}
+
+// itemIdentity identifies an item across setlists by what is performed rather
+// than by item ID. Section markers are identified by their type and label.
+func itemIdentity(item model.SetlistItem) string {
+	switch {
+	case item.SongID != nil:
+		return fmt.Sprintf("song:%d", *item.SongID)
+	case item.InterludeID != nil:
+		return fmt.Sprintf("interlude:%d", *item.InterludeID)
+	default:
+		return item.ItemType + ":" + valueOrEmpty(item.Label)
+	}
+}
+
+type keyedItem struct {
+	key  string
+	item DiffItem
+	src  model.SetlistItem
+}
+
+// keyItems numbers repeated identities, so a song played twice is matched
+// occurrence by occurrence.
+func keyItems(items []model.SetlistItem) []keyedItem {
+	seen := make(map[string]int)
+	keyed := make([]keyedItem, len(items))
+	for i, item := range items {
+		identity := itemIdentity(item)
+		seen[identity]++
+		keyed[i] = keyedItem{
+			key: fmt.Sprintf("%s#%d", identity, seen[identity]),
+			item: DiffItem{
+				ItemType:    item.ItemType,
+				SongID:      item.SongID,
+				InterludeID: item.InterludeID,
+				Title:       item.Title,
+				Occurrence:  seen[identity],
+				Position:    i,
+			},
+			src: item,
+		}
+	}
+	return keyed
+}
+
+// DiffItems lists what changed from base to target. Items present in both
+// are moved when they are not part of the longest common subsequence of the
+// two orders, so inserting one song does not report every later one as moved.
+func DiffItems(base, target []model.SetlistItem) SetlistDiff {
+	diff := SetlistDiff{
+		Added:                 make([]DiffItem, 0),
+		Removed:               make([]DiffItem, 0),
+		Moved:                 make([]MovedItem, 0),
+		NotesChanged:          make([]NotesChange, 0),
+		BaseDurationSeconds:   ComputeTiming(base, nil).TotalDurationSeconds,
+		TargetDurationSeconds: ComputeTiming(target, nil).TotalDurationSeconds,
+	}
+	diff.DurationDeltaSeconds = diff.TargetDurationSeconds - diff.BaseDurationSeconds
+
+	baseKeyed, targetKeyed := keyItems(base), keyItems(target)
+	baseByKey := make(map[string]keyedItem, len(baseKeyed))
+	for _, k := range baseKeyed {
+		baseByKey[k.key] = k
+	}
+	targetKeys := make(map[string]bool, len(targetKeyed))
+	for _, k := range targetKeyed {
+		targetKeys[k.key] = true
+	}
+
+	var commonBase, commonTarget []string
+	for _, k := range baseKeyed {
+		if targetKeys[k.key] {
+			commonBase = append(commonBase, k.key)
+		} else {
+			diff.Removed = append(diff.Removed, k.item)
+		}
+	}
+	for _, k := range targetKeyed {
+		if _, ok := baseByKey[k.key]; ok {
+			commonTarget = append(commonTarget, k.key)
+		} else {
+			diff.Added = append(diff.Added, k.item)
+		}
+	}
+
+	inOrder := longestCommonSubsequence(commonBase, commonTarget)
+	for _, k := range targetKeyed {
+		before, ok := baseByKey[k.key]
+		if !ok {
+			continue
+		}
+		if !inOrder[k.key] {
+			diff.Moved = append(diff.Moved, MovedItem{DiffItem: k.item, FromPosition: before.item.Position})
+		}
+		if valueOrEmpty(before.src.Notes) != valueOrEmpty(k.src.Notes) {
+			diff.NotesChanged = append(diff.NotesChanged, NotesChange{DiffItem: k.item, Before: before.src.Notes, After: k.src.Notes})
+		}
+	}
+	return diff
+}
+
+func longestCommonSubsequence(a, b []string) map[string]bool {
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	common := make(map[string]bool)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			common[a[i]] = true
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return common
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"setlist/api/model"
+	"setlist/api/repository/mocks"
+
+	"go.uber.org/mock/gomock"
+)
+
+func songItem(songID int32, duration int32, notes *string) model.SetlistItem {
+	return model.SetlistItem{ItemType: model.ItemTypeSong, SongID: &songID, DurationSeconds: &duration, Notes: notes}
+}
+
+func TestDiffItems(t *testing.T) {
+	interludeID := int32(9)
+	base := []model.SetlistItem{
+		songItem(1, 200, nil),
+		songItem(2, 180, strPtr("Capo 2")),
+		songItem(3, 240, nil),
+		{ItemType: model.ItemTypeInterlude, InterludeID: &interludeID, DurationSeconds: int32Ptr(60)},
+		songItem(4, 300, nil),
+		songItem(1, 200, nil), // reprise
+	}
+	target := []model.SetlistItem{
+		songItem(5, 210, nil), // new opener
+		songItem(4, 300, nil), // moved up from the end of the set
+		songItem(1, 200, nil),
+		songItem(2, 180, strPtr("Capo 3")),
+		songItem(3, 240, nil),
+		songItem(1, 200, nil),
+	}
+
+	diff := DiffItems(base, target)
+
+	if len(diff.Added) != 1 || *diff.Added[0].SongID != 5 || diff.Added[0].Position != 0 {
+		t.Errorf("unexpected added items: %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].InterludeID == nil || diff.Removed[0].Position != 3 {
+		t.Errorf("unexpected removed items: %+v", diff.Removed)
+	}
+	if len(diff.Moved) != 1 || *diff.Moved[0].SongID != 4 || diff.Moved[0].FromPosition != 4 || diff.Moved[0].Position != 1 {
+		t.Errorf("expected only song 4 to be moved, got %+v", diff.Moved)
+	}
+	if len(diff.NotesChanged) != 1 || *diff.NotesChanged[0].Before != "Capo 2" || *diff.NotesChanged[0].After != "Capo 3" {
+		t.Errorf("unexpected notes changes: %+v", diff.NotesChanged)
+	}
+	if diff.BaseDurationSeconds != 1180 || diff.TargetDurationSeconds != 1330 || diff.DurationDeltaSeconds != 150 {
+		t.Errorf("unexpected runtime delta: %d -> %d (%+d)", diff.BaseDurationSeconds, diff.TargetDurationSeconds, diff.DurationDeltaSeconds)
+	}
+}
+
+func TestDiffItems_MatchesRepeatedSongsByOccurrence(t *testing.T) {
+	base := []model.SetlistItem{songItem(1, 100, nil), songItem(2, 100, nil), songItem(1, 100, nil)}
+	target := []model.SetlistItem{songItem(1, 100, nil), songItem(2, 100, nil)}
+
+	diff := DiffItems(base, target)
+	if len(diff.Removed) != 1 || diff.Removed[0].Occurrence != 2 || len(diff.Moved) != 0 {
+		t.Errorf("expected the second occurrence to be removed, got %+v", diff)
+	}
+}
+
+func TestSetlistService_Diff(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("compares with another setlist of the band", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo}
+
+		against := 11
+		mockRepo.EXPECT().GetSetlistByID(ctx, 11, 1).Return(model.Setlist{ID: 11, BandID: 1}, nil)
+		mockRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, 11).Return([]model.SetlistItem{songItem(1, 100, nil)}, nil)
+		mockRepo.EXPECT().GetSetlistByID(ctx, 10, 1).Return(model.Setlist{ID: 10, BandID: 1}, nil)
+		mockRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, 10).Return([]model.SetlistItem{songItem(1, 100, nil), songItem(2, 50, nil)}, nil)
+
+		diff, err := svc.Diff(ctx, 10, 1, DiffOptions{AgainstID: &against})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(diff.Added) != 1 || diff.DurationDeltaSeconds != 50 {
+			t.Errorf("unexpected diff: %+v", diff)
+		}
+	})
+
+	t.Run("requires something to compare with", func(t *testing.T) {
+		var ve *ValidationError
+		if _, err := (SetlistService{}).Diff(ctx, 10, 1, DiffOptions{}); !errors.As(err, &ve) {
+			t.Fatalf("expected a ValidationError, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"log"
+	"setlist/api/model"
+	"setlist/realtime"
+)
+
+// Events published on a setlist's topic after each committed change, so the
+// members editing it at the same time can update their view.
+const (
+	SetlistEventUpdated        = "setlist_updated"
+	SetlistEventRestored       = "setlist_restored"
+	SetlistEventItemAdded      = "item_added"
+	SetlistEventItemsAdded     = "items_added"
+	SetlistEventItemUpdated    = "item_updated"
+	SetlistEventItemRemoved    = "item_removed"
+	SetlistEventItemsRemoved   = "items_removed"
+	SetlistEventItemsReordered = "items_reordered"
+	SetlistEventGroupChanged   = "group_changed"
+)
+
+// SetlistChange is the payload of a setlist event. Only the fields relevant to
+// the event are set; AuthorID lets an editor ignore its own changes.
+// A restore carries no data: the whole setlist has to be reloaded. Items added
+// in the middle of the setlist push the following items down. A group change
+// carries the items of the group with their new group; ungrouped items have
+// none.
+type SetlistChange struct {
+	SetlistID int                 `json:"setlist_id"`
+	AuthorID  int                 `json:"author_id"`
+	Setlist   *model.Setlist      `json:"setlist,omitempty"`
+	Item      *model.SetlistItem  `json:"item,omitempty"`
+	Items     []model.SetlistItem `json:"items,omitempty"`
+	ItemID    *int                `json:"item_id,omitempty"`
+	ItemIDs   []int               `json:"item_ids,omitempty"`
+}
+
+func (s SetlistService) publishChange(eventType string, change SetlistChange) {
+	if s.Broker == nil {
+		return
+	}
+	event, err := realtime.NewEvent(eventType, change)
+	if err != nil {
+		log.Printf("[realtime] Impossible d'encoder l'événement %q de la setlist %d : %v", eventType, change.SetlistID, err)
+		return
+	}
+	s.Broker.Publish(realtime.SetlistTopic(change.SetlistID), event)
+}
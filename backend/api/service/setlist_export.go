@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"setlist/api/model"
+	"strings"
+)
+
+var ErrInvalidExportFormat = errors.New("unsupported export format or layout")
+
+// ExportFile is a rendered setlist ready to be sent as a download.
+type ExportFile struct {
+	FileName    string
+	ContentType string
+	Content     []byte
+}
+
+var nonAlphanumericRegex = regexp.MustCompile(`[^a-zA-Z0-9]`)
+
+// exportFileName mirrors the file names the PWA gives its own exports.
+func exportFileName(setlistName, suffix, extension string) string {
+	return strings.ToLower(nonAlphanumericRegex.ReplaceAllString(setlistName+suffix, "_")) + "." + extension
+}
+
+// formatDuration renders seconds as m:ss, or h:mm:ss for an hour and more.
+func formatDuration(seconds int) string {
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds%3600/60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+func formatItemDuration(seconds *int32) string {
+	if seconds == nil {
+		return "-"
+	}
+	return formatDuration(int(*seconds))
+}
+
+func setTitle(set SetTiming) string {
+	if set.Label != nil && *set.Label != "" {
+		return *set.Label
+	}
+	if set.Kind == SectionKindEncore {
+		return "Rappel"
+	}
+	return fmt.Sprintf("Set %d", set.Number)
+}
+
+// setsByFirstItem indexes the sets by the item that opens them. A setlist
+// without any section marker has a single implicit set, which exports do not
+// need to announce, so nil is returned in that case.
+func setsByFirstItem(timing SetlistTiming) map[int]SetTiming {
+	if len(timing.Sets) < 2 {
+		return nil
+	}
+	sets := make(map[int]SetTiming, len(timing.Sets))
+	for _, set := range timing.Sets {
+		sets[set.FirstItemID] = set
+	}
+	return sets
+}
+
+// groupsByFirstItem indexes the groups by the item that opens them.
+func groupsByFirstItem(timing SetlistTiming) map[int]GroupTiming {
+	groups := make(map[int]GroupTiming, len(timing.Groups))
+	for _, group := range timing.Groups {
+		groups[group.FirstItemID] = group
+	}
+	return groups
+}
+
+func groupTitle(group GroupTiming) string {
+	return fmt.Sprintf("%s · %s", group.Name, formatDuration(group.DurationSeconds))
+}
+
+func itemTitle(item model.SetlistItem) string {
+	if item.Title != nil && *item.Title != "" {
+		return *item.Title
+	}
+	switch item.ItemType {
+	case model.ItemTypeInterlude:
+		return "Interlude"
+	case model.ItemTypeIntermission:
+		return "Entracte"
+	case model.ItemTypeEncore:
+		return "Rappel"
+	case model.ItemTypeSetBreak:
+		return "Pause"
+	case model.ItemTypePlaceholder:
+		return "Emplacement libre"
+	}
+	return ""
+}
+
+func valueOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"setlist/api/model"
+	"setlist/api/repository"
+	"setlist/api/repository/mocks"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/mock/gomock"
+)
+
+func strPtr(s string) *string { return &s }
+
+func exportFixture() (model.Setlist, []model.SetlistItem) {
+	songID := int32(5)
+	interludeID := int32(7)
+	setlist := model.Setlist{ID: 10, BandID: 1, Name: "Fête de la Musique"}
+	items := []model.SetlistItem{
+		{ID: 1, Position: 0, ItemType: model.ItemTypeSong, SongID: &songID, Title: strPtr("Intro"), DurationSeconds: int32Ptr(200), SongKey: strPtr("Am"), Tempo: int32Ptr(120), Notes: strPtr("Départ au clic")},
+		{ID: 2, Position: 1, ItemType: model.ItemTypeInterlude, InterludeID: &interludeID, Title: strPtr("Présentations"), Speaker: strPtr("Léa"), DurationSeconds: int32Ptr(60)},
+		{ID: 3, Position: 2, ItemType: model.ItemTypeEncore},
+		{ID: 4, Position: 3, ItemType: model.ItemTypeSong, SongID: &songID, Title: strPtr("Intro"), DurationSeconds: int32Ptr(200)},
+	}
+	return setlist, items
+}
+
+func TestSetlistService_ExportPDF(t *testing.T) {
+	ctx := context.Background()
+	setlist, items := exportFixture()
+
+	for _, layout := range []string{"", PDFLayoutStage, PDFLayoutCompact} {
+		t.Run("renders the "+layout+" layout", func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockSetlistRepository(ctrl)
+			svc := SetlistService{SetlistRepo: mockRepo}
+
+			mockRepo.EXPECT().GetSetlistByID(ctx, setlist.ID, setlist.BandID).Return(setlist, nil)
+			mockRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, setlist.ID).Return(items, nil)
+
+			file, err := svc.ExportPDF(ctx, setlist.ID, setlist.BandID, PDFExportOptions{Layout: layout})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if file.ContentType != "application/pdf" || !bytes.HasPrefix(file.Content, []byte("%PDF-")) {
+				t.Errorf("expected a PDF document, got %s", file.ContentType)
+			}
+			if file.FileName[:len("f_te_de_la_musique")] != "f_te_de_la_musique" {
+				t.Errorf("unexpected file name %q", file.FileName)
+			}
+		})
+	}
+
+	t.Run("reads musician parts from the song instrumentation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockSongRepo := mocks.NewMockSongRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo, SongRepo: mockSongRepo}
+
+		instrumentation := json.RawMessage(`{"Léa": "Chant", "Max": ["Basse", "Choeurs"]}`)
+		mockRepo.EXPECT().GetSetlistByID(ctx, setlist.ID, setlist.BandID).Return(setlist, nil)
+		mockRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, setlist.ID).Return(items, nil)
+		// The song appears twice but is only loaded once.
+		mockSongRepo.EXPECT().GetSongByID(ctx, 5, setlist.BandID).Return(model.Song{ID: 5, Instrumentation: instrumentation}, nil)
+
+		file, err := svc.ExportPDF(ctx, setlist.ID, setlist.BandID, PDFExportOptions{Layout: PDFLayoutMusician, Musician: "Max"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if file.FileName != "f_te_de_la_musique_max.pdf" {
+			t.Errorf("unexpected file name %q", file.FileName)
+		}
+	})
+
+	t.Run("rejects an unknown layout", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo}
+
+		mockRepo.EXPECT().GetSetlistByID(ctx, setlist.ID, setlist.BandID).Return(setlist, nil)
+		mockRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, setlist.ID).Return(items, nil)
+
+		_, err := svc.ExportPDF(ctx, setlist.ID, setlist.BandID, PDFExportOptions{Layout: "poster"})
+		if !errors.Is(err, ErrInvalidExportFormat) {
+			t.Fatalf("expected ErrInvalidExportFormat, got %v", err)
+		}
+	})
+}
+
+func TestMusicianParts(t *testing.T) {
+	parts := musicianParts(json.RawMessage(`{"Léa": "Chant", "Max": ["Basse", "Choeurs"], "Tom": 3}`))
+	if parts["Léa"] != "Chant" || parts["Max"] != "Basse, Choeurs" {
+		t.Errorf("unexpected parts: %v", parts)
+	}
+	if _, ok := parts["Tom"]; ok {
+		t.Error("expected non-text parts to be ignored")
+	}
+	if len(musicianParts(json.RawMessage("null"))) != 0 {
+		t.Error("expected no parts for a song without instrumentation")
+	}
+}
+
+func TestSetlistService_Export(t *testing.T) {
+	ctx := context.Background()
+	setlist, items := exportFixture()
+
+	cases := []struct {
+		format      string
+		fileName    string
+		contentType string
+		contains    []string
+	}{
+		{"", "f_te_de_la_musique.txt", "text/plain; charset=utf-8", []string{
+			"Fête de la Musique\nDurée totale : 7:40\n",
+			"Set 1 · 4:20\n  1. Intro (3:20, Am, 120 bpm) [3:20]\n     Départ au clic\n  - Léa : Présentations (1:00) [4:20]\n",
+			"Rappel · 3:20\n  2. Intro (3:20) [7:40]\n",
+		}},
+		{ExportFormatMarkdown, "f_te_de_la_musique.md", "text/markdown; charset=utf-8", []string{
+			"# Fête de la Musique\n",
+			"## Rappel · 3:20\n",
+			"| 1 | Intro | Am | 120 | 3:20 | Départ au clic | 3:20 |\n",
+		}},
+		{ExportFormatCSV, "f_te_de_la_musique.csv", "text/csv; charset=utf-8", []string{
+			"\ufeffPosition,Set,Titre,Tonalité,Tempo,Durée,Notes,Cumul\n",
+			"1,Set 1,Intro,Am,120,3:20,Départ au clic,3:20\n",
+			"2,Set 1,Léa : Présentations,,,1:00,,4:20\n",
+			"4,Rappel,Intro,,,3:20,,7:40\n",
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run("renders the "+tc.fileName+" export", func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockSetlistRepository(ctrl)
+			svc := SetlistService{SetlistRepo: mockRepo}
+
+			mockRepo.EXPECT().GetSetlistByID(ctx, setlist.ID, setlist.BandID).Return(setlist, nil)
+			mockRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, setlist.ID).Return(items, nil)
+
+			file, err := svc.Export(ctx, setlist.ID, setlist.BandID, ExportOptions{Format: tc.format})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if file.FileName != tc.fileName || file.ContentType != tc.contentType {
+				t.Errorf("unexpected file %q (%s)", file.FileName, file.ContentType)
+			}
+			for _, want := range tc.contains {
+				if !bytes.Contains(file.Content, []byte(want)) {
+					t.Errorf("expected export to contain %q, got:\n%s", want, file.Content)
+				}
+			}
+		})
+	}
+
+	t.Run("rejects an unknown format", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo}
+
+		mockRepo.EXPECT().GetSetlistByID(ctx, setlist.ID, setlist.BandID).Return(setlist, nil)
+		mockRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, setlist.ID).Return(items, nil)
+
+		_, err := svc.Export(ctx, setlist.ID, setlist.BandID, ExportOptions{Format: "xlsx"})
+		if !errors.Is(err, ErrInvalidExportFormat) {
+			t.Fatalf("expected ErrInvalidExportFormat, got %v", err)
+		}
+	})
+}
+
+func TestSetlistService_Export_Groups(t *testing.T) {
+	ctx := context.Background()
+	setlist := model.Setlist{ID: 10, BandID: 1, Name: "Bal"}
+	groupID := 3
+	medley := "Medley disco"
+	items := []model.SetlistItem{
+		{ID: 1, Position: 0, ItemType: model.ItemTypeSong, Title: strPtr("Ouverture"), DurationSeconds: int32Ptr(180)},
+		{ID: 2, Position: 1, ItemType: model.ItemTypeSong, Title: strPtr("Funkytown"), DurationSeconds: int32Ptr(120), TransitionDurationSeconds: 10, GroupID: &groupID, GroupName: &medley},
+		{ID: 3, Position: 2, ItemType: model.ItemTypeSong, Title: strPtr("Le Freak"), DurationSeconds: int32Ptr(110), GroupID: &groupID, GroupName: &medley},
+	}
+
+	cases := []struct {
+		format   string
+		contains string
+	}{
+		{ExportFormatText, "  1. Ouverture (3:00) [3:00]\n     Medley disco (4:00) [7:00]\n  2. ↳ Funkytown (2:00) [5:00]\n  3. ↳ Le Freak (1:50) [7:00]\n"},
+		{ExportFormatMarkdown, "|  | **Medley disco** |  |  | 4:00 |  | 7:00 |\n| 2 | ↳ Funkytown |"},
+		{ExportFormatCSV, ",,Medley disco,,,4:00,,7:00\n2,,↳ Funkytown,,,2:00,,5:00\n"},
+		{ExportFormatChordPro, "{comment_box: Medley disco · 4:00}\n\n{new_song}\n{title: Funkytown}"},
+	}
+
+	for _, tc := range cases {
+		t.Run("renders the group in the "+tc.format+" export", func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockSetlistRepository(ctrl)
+			mockSongRepo := mocks.NewMockSongRepository(ctrl)
+			svc := SetlistService{SetlistRepo: mockRepo, SongRepo: mockSongRepo}
+
+			mockRepo.EXPECT().GetSetlistByID(ctx, setlist.ID, setlist.BandID).Return(setlist, nil)
+			mockRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, setlist.ID).Return(items, nil)
+
+			file, err := svc.Export(ctx, setlist.ID, setlist.BandID, ExportOptions{Format: tc.format})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.Contains(file.Content, []byte(tc.contains)) {
+				t.Errorf("expected export to contain %q, got:\n%s", tc.contains, file.Content)
+			}
+		})
+	}
+}
+
+func TestCSVCell(t *testing.T) {
+	cases := map[string]string{
+		"=HYPERLINK(\"http://x\")": "'=HYPERLINK(\"http://x\")",
+		"+33 6 12":                 "'+33 6 12",
+		"-":                        "-",
+		"-2+3":                     "'-2+3",
+		"@SUM(A1)":                 "'@SUM(A1)",
+		"Intro":                    "Intro",
+		"":                         "",
+	}
+	for in, want := range cases {
+		if got := csvCell(in); got != want {
+			t.Errorf("csvCell(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMarkdownCell(t *testing.T) {
+	if got := markdownCell("Basse | Choeurs\r\nreprise "); got != "Basse \\| Choeurs<br>reprise" {
+		t.Errorf("unexpected cell %q", got)
+	}
+}
+
+func TestSetlistService_Export_ChordPro(t *testing.T) {
+	ctx := context.Background()
+	setlist, items := exportFixture()
+	items[1].Script = strPtr("Merci d'être venus !\nOn enchaîne.")
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The song is read through the real repository so that its chords only
+	// reach the songbook if the query selects them.
+	mockRepo := mocks.NewMockSetlistRepository(ctrl)
+	songRepo := repository.PgSongRepository{DB: songRowDB{row: map[string]any{
+		"id":     5,
+		"lyrics": strPtr("Hello {world}"),
+		"chords": strPtr("Am    C\r\nG     F"),
+	}}}
+	svc := SetlistService{SetlistRepo: mockRepo, SongRepo: songRepo}
+
+	mockRepo.EXPECT().GetSetlistByID(ctx, setlist.ID, setlist.BandID).Return(setlist, nil)
+	mockRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, setlist.ID).Return(items, nil)
+
+	file, err := svc.Export(ctx, setlist.ID, setlist.BandID, ExportOptions{Format: ExportFormatChordPro})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if file.FileName != "f_te_de_la_musique.cho" {
+		t.Errorf("unexpected file name %q", file.FileName)
+	}
+
+	content := string(file.Content)
+	for _, want := range []string{
+		"{comment: Set 1 · 4:20}\n\n{title: Intro}\n{key: Am}\n{tempo: 120}\n{duration: 3:20}\n{comment_italic: Départ au clic}\n",
+		"{start_of_verse}\nHello {world}\n{end_of_verse}\n",
+		"{start_of_tab}\nAm    C\nG     F\n{end_of_tab}\n",
+		"{comment: Interlude : Léa : Présentations}\n{comment_italic: Merci d'être venus !}\n{comment_italic: On enchaîne.}\n",
+		"{comment: Rappel · 3:20}\n\n{new_song}\n{title: Intro}\n",
+	} {
+		if !bytes.Contains(file.Content, []byte(want)) {
+			t.Errorf("expected songbook to contain %q, got:\n%s", want, content)
+		}
+	}
+}
+
+// songRowDB serves one row of the songs table: every column a query selects
+// is read from the row by name, and a column the query leaves out stays
+// empty.
+type songRowDB struct {
+	repository.DBTX
+	row map[string]any
+}
+
+func (db songRowDB) QueryRow(_ context.Context, query string, _ ...interface{}) pgx.Row {
+	start, end := strings.Index(query, "SELECT")+len("SELECT"), strings.Index(query, "FROM")
+	columns := strings.Split(query[start:end], ",")
+	for i := range columns {
+		columns[i] = strings.TrimSpace(columns[i])
+	}
+	return songRow{columns: columns, values: db.row}
+}
+
+type songRow struct {
+	columns []string
+	values  map[string]any
+}
+
+func (r songRow) Scan(dest ...any) error {
+	if len(dest) != len(r.columns) {
+		return fmt.Errorf("%d columns scanned into %d values", len(r.columns), len(dest))
+	}
+	for i, column := range r.columns {
+		if value, ok := r.values[column]; ok {
+			reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(value))
+		}
+	}
+	return nil
+}
+
+func TestWriteChordProSheet_InlineChords(t *testing.T) {
+	var b bytes.Buffer
+	writeChordProSheet(&b, model.Song{Lyrics: strPtr("Hello"), Chords: strPtr("[Am]Hello [C]world")})
+	if b.String() != "\n[Am]Hello [C]world\n" {
+		t.Errorf("expected inline chords to replace the lyrics, got %q", b.String())
+	}
+}
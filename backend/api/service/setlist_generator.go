@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"setlist/api/model"
+	"slices"
+	"strings"
+	"time"
+)
+
+// GeneratePayload describes the setlist to build from the band's songs.
+// Include, Exclude, OpenerID and CloserID hold song IDs. Without a seed, one
+// is picked and returned so the result can be generated again.
+type GeneratePayload struct {
+	TargetDurationSeconds int    `json:"target_duration_seconds"`
+	Include               []int  `json:"include"`
+	Exclude               []int  `json:"exclude"`
+	OpenerID              *int   `json:"opener_id"`
+	CloserID              *int   `json:"closer_id"`
+	AvoidSameKey          bool   `json:"avoid_same_key"`
+	AlternateTempo        bool   `json:"alternate_tempo"`
+	Seed                  *int64 `json:"seed"`
+}
+
+// GeneratedSetlist is a candidate setlist; nothing is saved. Its items use
+// the layout of setlist items so they can be timed and displayed the same way.
+type GeneratedSetlist struct {
+	Seed                  int64               `json:"seed"`
+	Items                 []model.SetlistItem `json:"items"`
+	TotalDurationSeconds  int                 `json:"total_duration_seconds"`
+	TargetDurationSeconds int                 `json:"target_duration_seconds"`
+	Warnings              []string            `json:"warnings"`
+}
+
+func (s SetlistService) Generate(ctx context.Context, bandID int, payload GeneratePayload) (GeneratedSetlist, error) {
+	songs, err := s.SongRepo.GetAllSongsByBandID(ctx, bandID)
+	if err != nil {
+		return GeneratedSetlist{}, err
+	}
+
+	seed := time.Now().UnixNano()
+	if payload.Seed != nil {
+		seed = *payload.Seed
+	}
+	return generateSetlist(songs, payload, seed)
+}
+
+// generateSetlist picks songs until the target runtime is reached: the opener,
+// the closer and the included songs first, then the rest of the library in an
+// order shuffled from the seed. Songs without a duration are only used when
+// asked for. The middle of the set is then ordered greedily to follow the
+// rules; when no song fits a rule, the next one is used anyway and a warning
+// is returned. The same songs, payload and seed always give the same result.
+func generateSetlist(library []model.Song, payload GeneratePayload, seed int64) (GeneratedSetlist, error) {
+	if payload.TargetDurationSeconds <= 0 {
+		return GeneratedSetlist{}, &ValidationError{Msg: "La durée cible doit être positive."}
+	}
+	if payload.OpenerID != nil && payload.CloserID != nil && *payload.OpenerID == *payload.CloserID {
+		return GeneratedSetlist{}, &ValidationError{Msg: "L'ouverture et la clôture doivent être deux chansons différentes."}
+	}
+
+	songs := slices.Clone(library)
+	slices.SortFunc(songs, func(a, b model.Song) int { return a.ID - b.ID })
+	byID := make(map[int]model.Song, len(songs))
+	for _, song := range songs {
+		byID[song.ID] = song
+	}
+
+	unknownSong := func(id int) error {
+		return &ValidationError{Msg: fmt.Sprintf("La chanson %d n'existe pas dans ce groupe.", id)}
+	}
+	excluded := make(map[int]bool, len(payload.Exclude))
+	for _, id := range payload.Exclude {
+		if _, ok := byID[id]; !ok {
+			return GeneratedSetlist{}, unknownSong(id)
+		}
+		excluded[id] = true
+	}
+	used := make(map[int]bool)
+	pick := func(id int) (model.Song, error) {
+		song, ok := byID[id]
+		if !ok {
+			return model.Song{}, unknownSong(id)
+		}
+		if excluded[id] {
+			return model.Song{}, &ValidationError{Msg: "Une chanson demandée fait aussi partie des exclusions : " + song.Title + "."}
+		}
+		used[id] = true
+		return song, nil
+	}
+
+	result := GeneratedSetlist{Seed: seed, TargetDurationSeconds: payload.TargetDurationSeconds, Warnings: make([]string, 0)}
+	total := 0
+	var opener, closer *model.Song
+	for _, slot := range []struct {
+		id   *int
+		song **model.Song
+	}{{payload.OpenerID, &opener}, {payload.CloserID, &closer}} {
+		if slot.id == nil {
+			continue
+		}
+		song, err := pick(*slot.id)
+		if err != nil {
+			return GeneratedSetlist{}, err
+		}
+		*slot.song = &song
+		total += songDuration(song)
+	}
+
+	rng := rand.New(rand.NewPCG(uint64(seed), uint64(seed)>>32))
+	var middle []model.Song
+	for _, id := range payload.Include {
+		if used[id] {
+			continue
+		}
+		song, err := pick(id)
+		if err != nil {
+			return GeneratedSetlist{}, err
+		}
+		middle = append(middle, song)
+		total += songDuration(song)
+	}
+	rng.Shuffle(len(middle), func(i, j int) { middle[i], middle[j] = middle[j], middle[i] })
+
+	pool := make([]model.Song, 0, len(songs))
+	for _, song := range songs {
+		if !used[song.ID] && !excluded[song.ID] && song.DurationSeconds != nil {
+			pool = append(pool, song)
+		}
+	}
+	rng.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+	for _, song := range pool {
+		if total+songDuration(song) <= payload.TargetDurationSeconds {
+			middle = append(middle, song)
+			total += songDuration(song)
+		}
+	}
+
+	if total > payload.TargetDurationSeconds {
+		result.Warnings = append(result.Warnings, "Les chansons imposées dépassent la durée cible.")
+	}
+
+	rules := setlistRules{avoidSameKey: payload.AvoidSameKey, alternateTempo: payload.AlternateTempo}
+	rules.medianTempo = medianTempo(opener, closer, middle)
+	order, broken := rules.order(opener, middle)
+	if closer != nil && len(order) > 0 && !rules.allows(&order[len(order)-1], *closer) {
+		broken++
+	}
+	if broken > 0 {
+		result.Warnings = append(result.Warnings, "Certaines règles d'enchaînement n'ont pas pu être respectées.")
+	}
+
+	if opener != nil {
+		order = append([]model.Song{*opener}, order...)
+	}
+	if closer != nil {
+		order = append(order, *closer)
+	}
+	result.Items = make([]model.SetlistItem, len(order))
+	for i, song := range order {
+		result.Items[i] = generatedItem(song, i)
+		if song.DurationSeconds == nil {
+			result.Warnings = append(result.Warnings, "Durée inconnue pour "+song.Title+".")
+		}
+	}
+	result.TotalDurationSeconds = ComputeTiming(result.Items, nil).TotalDurationSeconds
+	return result, nil
+}
+
+type setlistRules struct {
+	avoidSameKey   bool
+	alternateTempo bool
+	medianTempo    int32
+}
+
+// order lays the songs out one after the other, each time taking the first
+// remaining song that may follow the previous one. It returns how many times
+// no remaining song fitted.
+func (r setlistRules) order(first *model.Song, songs []model.Song) ([]model.Song, int) {
+	remaining := slices.Clone(songs)
+	ordered := make([]model.Song, 0, len(songs))
+	previous := first
+	broken := 0
+	for len(remaining) > 0 {
+		next := slices.IndexFunc(remaining, func(song model.Song) bool { return r.allows(previous, song) })
+		if next < 0 {
+			next = 0
+			broken++
+		}
+		ordered = append(ordered, remaining[next])
+		remaining = slices.Delete(remaining, next, next+1)
+		previous = &ordered[len(ordered)-1]
+	}
+	return ordered, broken
+}
+
+// allows reports whether next may follow previous. Songs without a key or a
+// tempo never break the corresponding rule.
+func (r setlistRules) allows(previous *model.Song, next model.Song) bool {
+	if previous == nil {
+		return true
+	}
+	if r.avoidSameKey && previous.SongKey != nil && next.SongKey != nil &&
+		strings.EqualFold(strings.TrimSpace(*previous.SongKey), strings.TrimSpace(*next.SongKey)) {
+		return false
+	}
+	if r.alternateTempo && previous.Tempo != nil && next.Tempo != nil &&
+		(*previous.Tempo >= r.medianTempo) == (*next.Tempo >= r.medianTempo) {
+		return false
+	}
+	return true
+}
+
+// medianTempo splits the selected songs into fast and slow ones.
+func medianTempo(opener, closer *model.Song, songs []model.Song) int32 {
+	var tempos []int32
+	for _, song := range append(slices.Clone(songs), derefSongs(opener, closer)...) {
+		if song.Tempo != nil {
+			tempos = append(tempos, *song.Tempo)
+		}
+	}
+	if len(tempos) == 0 {
+		return 0
+	}
+	slices.Sort(tempos)
+	return tempos[len(tempos)/2]
+}
+
+func derefSongs(songs ...*model.Song) []model.Song {
+	var result []model.Song
+	for _, song := range songs {
+		if song != nil {
+			result = append(result, *song)
+		}
+	}
+	return result
+}
+
+func songDuration(song model.Song) int {
+	if song.DurationSeconds == nil {
+		return 0
+	}
+	return int(*song.DurationSeconds)
+}
+
+func generatedItem(song model.Song, position int) model.SetlistItem {
+	songID := int32(song.ID)
+	title := song.Title
+	return model.SetlistItem{
+		Position:        position,
+		ItemType:        model.ItemTypeSong,
+		SongID:          &songID,
+		Title:           &title,
+		DurationSeconds: song.DurationSeconds,
+		Tempo:           song.Tempo,
+		SongKey:         song.SongKey,
+	}
+}
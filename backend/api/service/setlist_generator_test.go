@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"setlist/api/model"
+	"setlist/api/repository/mocks"
+
+	"go.uber.org/mock/gomock"
+)
+
+func generatorLibrary() []model.Song {
+	song := func(id int, title string, duration, tempo int32, key string) model.Song {
+		return model.Song{ID: id, Title: title, DurationSeconds: int32Ptr(duration), Tempo: int32Ptr(tempo), SongKey: strPtr(key)}
+	}
+	return []model.Song{
+		song(1, "Ouverture", 180, 140, "E"),
+		song(2, "Ballade", 240, 70, "G"),
+		song(3, "Rock", 200, 150, "A"),
+		song(4, "Slow", 260, 65, "A"),
+		song(5, "Punk", 150, 180, "E"),
+		song(6, "Folk", 210, 90, "D"),
+		song(7, "Funk", 220, 110, "G"),
+		song(8, "Final", 300, 160, "D"),
+		{ID: 9, Title: "Inédit"},
+	}
+}
+
+func generatedIDs(generated GeneratedSetlist) []int {
+	ids := make([]int, len(generated.Items))
+	for i, item := range generated.Items {
+		ids[i] = int(*item.SongID)
+	}
+	return ids
+}
+
+func TestGenerateSetlist_DeterministicWithSeed(t *testing.T) {
+	payload := GeneratePayload{TargetDurationSeconds: 1200, AvoidSameKey: true}
+
+	first, err := generateSetlist(generatorLibrary(), payload, 42)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The library order must not matter either.
+	reversed := generatorLibrary()
+	slices.Reverse(reversed)
+	second, err := generateSetlist(reversed, payload, 42)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(generatedIDs(first), generatedIDs(second)) {
+		t.Errorf("expected the same songs for the same seed, got %v and %v", generatedIDs(first), generatedIDs(second))
+	}
+	if first.Seed != 42 || first.TotalDurationSeconds > 1200 || len(first.Items) == 0 {
+		t.Errorf("unexpected result: %+v", first)
+	}
+}
+
+func TestGenerateSetlist_Constraints(t *testing.T) {
+	opener, closer := 1, 8
+	payload := GeneratePayload{
+		TargetDurationSeconds: 1500,
+		Include:               []int{4},
+		Exclude:               []int{3, 5},
+		OpenerID:              &opener,
+		CloserID:              &closer,
+	}
+
+	for seed := int64(0); seed < 20; seed++ {
+		generated, err := generateSetlist(generatorLibrary(), payload, seed)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids := generatedIDs(generated)
+		if ids[0] != opener || ids[len(ids)-1] != closer {
+			t.Fatalf("seed %d: expected opener and closer at both ends, got %v", seed, ids)
+		}
+		if !slices.Contains(ids, 4) {
+			t.Fatalf("seed %d: expected the included song in %v", seed, ids)
+		}
+		if slices.Contains(ids, 3) || slices.Contains(ids, 5) || slices.Contains(ids, 9) {
+			t.Fatalf("seed %d: unexpected excluded or untimed song in %v", seed, ids)
+		}
+		if generated.TotalDurationSeconds > payload.TargetDurationSeconds {
+			t.Fatalf("seed %d: total %d over target", seed, generated.TotalDurationSeconds)
+		}
+	}
+}
+
+func TestGenerateSetlist_Rules(t *testing.T) {
+	payload := GeneratePayload{TargetDurationSeconds: 3600, AvoidSameKey: true, AlternateTempo: true}
+
+	for seed := int64(0); seed < 20; seed++ {
+		generated, err := generateSetlist(generatorLibrary(), payload, seed)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(generated.Warnings) > 0 {
+			continue
+		}
+		for i := 1; i < len(generated.Items); i++ {
+			previous, next := generated.Items[i-1], generated.Items[i]
+			if *previous.SongKey == *next.SongKey {
+				t.Fatalf("seed %d: two songs in %s back to back", seed, *next.SongKey)
+			}
+			if (*previous.Tempo >= 140) == (*next.Tempo >= 140) {
+				t.Fatalf("seed %d: tempos %d and %d do not alternate", seed, *previous.Tempo, *next.Tempo)
+			}
+		}
+	}
+}
+
+func TestGenerateSetlist_Errors(t *testing.T) {
+	same, unknown := 1, 42
+	namesSong := func(id int) func(error) bool {
+		return func(err error) bool {
+			return isValidationError(err) && strings.Contains(err.Error(), strconv.Itoa(id))
+		}
+	}
+	cases := []struct {
+		name    string
+		payload GeneratePayload
+		check   func(error) bool
+	}{
+		{"missing target", GeneratePayload{}, isValidationError},
+		{"same opener and closer", GeneratePayload{TargetDurationSeconds: 600, OpenerID: &same, CloserID: &same}, isValidationError},
+		{"included and excluded", GeneratePayload{TargetDurationSeconds: 600, Include: []int{2}, Exclude: []int{2}}, isValidationError},
+		{"unknown included song", GeneratePayload{TargetDurationSeconds: 600, Include: []int{99}}, namesSong(99)},
+		{"unknown opener", GeneratePayload{TargetDurationSeconds: 600, OpenerID: &unknown}, namesSong(unknown)},
+		{"unknown excluded song", GeneratePayload{TargetDurationSeconds: 600, Exclude: []int{77}}, namesSong(77)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := generateSetlist(generatorLibrary(), tc.payload, 1)
+			if !tc.check(err) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSetlistService_Generate(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockSongRepo := mocks.NewMockSongRepository(ctrl)
+	svc := SetlistService{SongRepo: mockSongRepo}
+	mockSongRepo.EXPECT().GetAllSongsByBandID(ctx, 1).Return(generatorLibrary(), nil)
+
+	seed := int64(7)
+	included := 9
+	generated, err := svc.Generate(ctx, 1, GeneratePayload{TargetDurationSeconds: 900, Include: []int{included}, Seed: &seed})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if generated.Seed != seed {
+		t.Errorf("expected seed %d, got %d", seed, generated.Seed)
+	}
+	if len(generated.Warnings) != 1 || !strings.Contains(generated.Warnings[0], "Inédit") {
+		t.Errorf("expected a warning about the untimed song, got %v", generated.Warnings)
+	}
+}
+
+func isValidationError(err error) bool {
+	var ve *ValidationError
+	return errors.As(err, &ve)
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"setlist/api/model"
+	"setlist/cache"
+	"strings"
+	"unicode/utf8"
+)
+
+var ErrGroupNotFound = errors.New("item group not found in this setlist")
+
+const maxGroupNameLength = 255
+
+// CreateGroupPayload names a group, such as a medley, and lists its items,
+// which must follow each other in the setlist.
+type CreateGroupPayload struct {
+	Name    string `json:"name"`
+	ItemIDs []int  `json:"item_ids"`
+}
+
+type RenameGroupPayload struct {
+	Name string `json:"name"`
+}
+
+// CreateGroup groups consecutive items of a setlist so they are moved, timed
+// and exported as one block. Section markers cannot be grouped and an item
+// belongs to one group at most.
+func (s SetlistService) CreateGroup(ctx context.Context, setlistID int, bandID int, userID int, payload CreateGroupPayload) (model.SetlistItemGroup, error) {
+	name, err := validateGroupName(payload.Name)
+	if err != nil {
+		return model.SetlistItemGroup{}, err
+	}
+	if len(payload.ItemIDs) < 2 {
+		return model.SetlistItemGroup{}, &ValidationError{Msg: "Un medley regroupe au moins deux éléments."}
+	}
+	if _, err := s.bandSetlist(ctx, setlistID, bandID); err != nil {
+		return model.SetlistItemGroup{}, err
+	}
+
+	tx, err := s.SetlistRepo.BeginTx(ctx)
+	if err != nil {
+		return model.SetlistItemGroup{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := s.lockUnlocked(ctx, tx, setlistID); err != nil {
+		return model.SetlistItemGroup{}, err
+	}
+	items, err := s.SetlistRepo.GetItems(ctx, tx, setlistID)
+	if err != nil {
+		return model.SetlistItemGroup{}, err
+	}
+	members, err := groupMembers(items, payload.ItemIDs)
+	if err != nil {
+		return model.SetlistItemGroup{}, err
+	}
+	group, err := s.SetlistRepo.CreateItemGroup(ctx, tx, setlistID, name, payload.ItemIDs)
+	if err != nil {
+		return model.SetlistItemGroup{}, err
+	}
+	if err := s.recordRevision(ctx, tx, setlistID, userID, RevisionActionGroupItems); err != nil {
+		return model.SetlistItemGroup{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return model.SetlistItemGroup{}, err
+	}
+
+	for i := range members {
+		members[i].GroupID = &group.ID
+		members[i].GroupName = &group.Name
+	}
+	s.publishChange(SetlistEventGroupChanged, SetlistChange{SetlistID: setlistID, AuthorID: userID, Items: members})
+	cache.Delete(ctx, s.Cache, cache.SetlistKey(bandID))
+	return group, nil
+}
+
+func (s SetlistService) RenameGroup(ctx context.Context, setlistID int, groupID int, bandID int, userID int, payload RenameGroupPayload) (model.SetlistItemGroup, error) {
+	name, err := validateGroupName(payload.Name)
+	if err != nil {
+		return model.SetlistItemGroup{}, err
+	}
+	if _, err := s.bandSetlist(ctx, setlistID, bandID); err != nil {
+		return model.SetlistItemGroup{}, err
+	}
+
+	tx, err := s.SetlistRepo.BeginTx(ctx)
+	if err != nil {
+		return model.SetlistItemGroup{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := s.lockUnlocked(ctx, tx, setlistID); err != nil {
+		return model.SetlistItemGroup{}, err
+	}
+	group, err := s.SetlistRepo.RenameItemGroup(ctx, tx, groupID, setlistID, bandID, name)
+	if err != nil {
+		return model.SetlistItemGroup{}, mapNotFound(err, ErrGroupNotFound)
+	}
+	if err := s.recordRevision(ctx, tx, setlistID, userID, RevisionActionUpdateGroup); err != nil {
+		return model.SetlistItemGroup{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return model.SetlistItemGroup{}, err
+	}
+
+	if items, err := s.SetlistRepo.GetSetlistItemsBySetlistID(ctx, setlistID); err == nil {
+		s.publishChange(SetlistEventGroupChanged, SetlistChange{SetlistID: setlistID, AuthorID: userID, Items: itemsOfGroup(items, groupID)})
+	}
+	cache.Delete(ctx, s.Cache, cache.SetlistKey(bandID))
+	return group, nil
+}
+
+// DeleteGroup ungroups the items of a group; they stay where they are.
+func (s SetlistService) DeleteGroup(ctx context.Context, setlistID int, groupID int, bandID int, userID int) error {
+	if _, err := s.bandSetlist(ctx, setlistID, bandID); err != nil {
+		return err
+	}
+
+	tx, err := s.SetlistRepo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := s.lockUnlocked(ctx, tx, setlistID); err != nil {
+		return err
+	}
+	items, err := s.SetlistRepo.GetItems(ctx, tx, setlistID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.SetlistRepo.DeleteItemGroup(ctx, tx, groupID, setlistID, bandID); err != nil {
+		return mapNotFound(err, ErrGroupNotFound)
+	}
+	if err := s.recordRevision(ctx, tx, setlistID, userID, RevisionActionUngroupItems); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	members := itemsOfGroup(items, groupID)
+	for i := range members {
+		members[i].GroupID = nil
+		members[i].GroupName = nil
+	}
+	s.publishChange(SetlistEventGroupChanged, SetlistChange{SetlistID: setlistID, AuthorID: userID, Items: members})
+	cache.Delete(ctx, s.Cache, cache.SetlistKey(bandID))
+	return nil
+}
+
+func validateGroupName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", &ValidationError{Msg: "Le nom du medley est requis."}
+	}
+	if utf8.RuneCountInString(name) > maxGroupNameLength {
+		return "", &ValidationError{Msg: "Le nom du medley ne peut pas dépasser 255 caractères."}
+	}
+	return name, nil
+}
+
+// groupMembers returns the items of the setlist listed in itemIDs, in setlist
+// order, once checked that they can form a group together.
+func groupMembers(items []model.SetlistItem, itemIDs []int) ([]model.SetlistItem, error) {
+	wanted := make(map[int]bool, len(itemIDs))
+	for _, id := range itemIDs {
+		if wanted[id] {
+			return nil, &ValidationError{Msg: "Un élément apparaît plusieurs fois dans le medley."}
+		}
+		wanted[id] = true
+	}
+
+	members := make([]model.SetlistItem, 0, len(itemIDs))
+	last := -1
+	for i, item := range items {
+		if !wanted[item.ID] {
+			continue
+		}
+		if model.IsSectionMarker(item.ItemType) {
+			return nil, &ValidationError{Msg: "Une pause, un rappel ou un entracte ne peut pas faire partie d'un medley."}
+		}
+		if item.GroupID != nil {
+			return nil, &ValidationError{Msg: "Un élément fait déjà partie d'un autre medley."}
+		}
+		if last >= 0 && i != last+1 {
+			return nil, &ValidationError{Msg: "Les éléments d'un medley doivent se suivre dans la setlist."}
+		}
+		last = i
+		members = append(members, item)
+	}
+	if len(members) != len(wanted) {
+		return nil, ErrItemNotFound
+	}
+	return members, nil
+}
+
+func itemsOfGroup(items []model.SetlistItem, groupID int) []model.SetlistItem {
+	members := make([]model.SetlistItem, 0)
+	for _, item := range items {
+		if item.GroupID != nil && *item.GroupID == groupID {
+			members = append(members, item)
+		}
+	}
+	return members
+}
+
+// keepGroupsTogether moves the members of each group right after the first
+// of them found in the new order, so that a group moves as a whole. Members
+// keep the order they are given in among themselves.
+func keepGroupsTogether(items []model.SetlistItem, itemIDs []int) []int {
+	groupOf := make(map[int]int)
+	for _, item := range items {
+		if item.GroupID != nil {
+			groupOf[item.ID] = *item.GroupID
+		}
+	}
+	members := make(map[int][]int)
+	for _, id := range itemIDs {
+		if groupID, ok := groupOf[id]; ok {
+			members[groupID] = append(members[groupID], id)
+		}
+	}
+
+	order := make([]int, 0, len(itemIDs))
+	placed := make(map[int]bool)
+	for _, id := range itemIDs {
+		groupID, ok := groupOf[id]
+		if !ok {
+			order = append(order, id)
+			continue
+		}
+		if !placed[groupID] {
+			placed[groupID] = true
+			order = append(order, members[groupID]...)
+		}
+	}
+	return order
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"setlist/api/model"
+	"setlist/api/repository"
+	"setlist/api/repository/mocks"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSetlistService_CreateGroup(t *testing.T) {
+	ctx := context.Background()
+	bandID := 1
+	otherGroup := 9
+	items := []model.SetlistItem{
+		{ID: 1, ItemType: model.ItemTypeSong},
+		{ID: 2, ItemType: model.ItemTypeSong},
+		{ID: 3, ItemType: model.ItemTypeSong},
+		{ID: 4, ItemType: model.ItemTypeSetBreak},
+		{ID: 5, ItemType: model.ItemTypeSong, GroupID: &otherGroup},
+		{ID: 6, ItemType: model.ItemTypeSong, GroupID: &otherGroup},
+	}
+
+	t.Run("groups consecutive items", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo, RevisionRepo: mockRevisionRepo}
+
+		mockRepo.EXPECT().GetSetlistByID(ctx, 10, bandID).Return(model.Setlist{ID: 10}, nil)
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().LockSetlists(ctx, mockTx, 10).Return(nil)
+		mockRepo.EXPECT().GetItems(ctx, mockTx, 10).Return(items, nil)
+		mockRepo.EXPECT().CreateItemGroup(ctx, mockTx, 10, "Medley", []int{3, 2}).Return(model.SetlistItemGroup{ID: 7, SetlistID: 10, Name: "Medley"}, nil)
+		mockRevisionRepo.EXPECT().Record(ctx, mockTx, 10, userID, RevisionActionGroupItems).Return(nil)
+		mockTx.EXPECT().Commit(ctx).Return(nil)
+
+		group, err := svc.CreateGroup(ctx, 10, bandID, userID, CreateGroupPayload{Name: "  Medley ", ItemIDs: []int{3, 2}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if group.ID != 7 || group.Name != "Medley" {
+			t.Errorf("unexpected group: %+v", group)
+		}
+	})
+
+	cases := []struct {
+		name    string
+		itemIDs []int
+	}{
+		{"rejects items that do not follow each other", []int{1, 3}},
+		{"rejects section markers", []int{3, 4}},
+		{"rejects items of another group", []int{5, 6}},
+		{"rejects a repeated item", []int{1, 1}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockSetlistRepository(ctrl)
+			mockTx := mocks.NewMockTx(ctrl)
+			svc := SetlistService{SetlistRepo: mockRepo}
+
+			mockRepo.EXPECT().GetSetlistByID(ctx, 10, bandID).Return(model.Setlist{ID: 10}, nil)
+			mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+			mockTx.EXPECT().Rollback(ctx).Return(nil)
+			mockRepo.EXPECT().LockSetlists(ctx, mockTx, 10).Return(nil)
+			mockRepo.EXPECT().GetItems(ctx, mockTx, 10).Return(items, nil)
+			// No CreateItemGroup call expected.
+
+			_, err := svc.CreateGroup(ctx, 10, bandID, userID, CreateGroupPayload{Name: "Medley", ItemIDs: tc.itemIDs})
+			if !isValidationError(err) {
+				t.Fatalf("expected a ValidationError, got %v", err)
+			}
+		})
+	}
+
+	t.Run("rejects items of another setlist", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo}
+
+		mockRepo.EXPECT().GetSetlistByID(ctx, 10, bandID).Return(model.Setlist{ID: 10}, nil)
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().LockSetlists(ctx, mockTx, 10).Return(nil)
+		mockRepo.EXPECT().GetItems(ctx, mockTx, 10).Return(items, nil)
+
+		_, err := svc.CreateGroup(ctx, 10, bandID, userID, CreateGroupPayload{Name: "Medley", ItemIDs: []int{3, 42}})
+		if !errors.Is(err, ErrItemNotFound) {
+			t.Fatalf("expected ErrItemNotFound, got %v", err)
+		}
+	})
+
+	t.Run("requires a name and two items", func(t *testing.T) {
+		svc := SetlistService{}
+		for _, payload := range []CreateGroupPayload{{Name: " ", ItemIDs: []int{1, 2}}, {Name: "Medley", ItemIDs: []int{1}}} {
+			if _, err := svc.CreateGroup(ctx, 10, bandID, userID, payload); !isValidationError(err) {
+				t.Errorf("expected a ValidationError for %+v, got %v", payload, err)
+			}
+		}
+	})
+
+	t.Run("rejects a locked setlist", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo}
+
+		mockRepo.EXPECT().GetSetlistByID(ctx, 10, bandID).Return(model.Setlist{ID: 10}, nil)
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().LockSetlists(ctx, mockTx, 10).Return(repository.ErrSetlistLocked)
+
+		_, err := svc.CreateGroup(ctx, 10, bandID, userID, CreateGroupPayload{Name: "Medley", ItemIDs: []int{1, 2}})
+		if !errors.Is(err, ErrSetlistLocked) {
+			t.Fatalf("expected ErrSetlistLocked, got %v", err)
+		}
+	})
+}
+
+func TestSetlistService_DeleteGroup_NotFound(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSetlistRepository(ctrl)
+	mockTx := mocks.NewMockTx(ctrl)
+	svc := SetlistService{SetlistRepo: mockRepo}
+
+	mockRepo.EXPECT().GetSetlistByID(ctx, 10, 1).Return(model.Setlist{ID: 10}, nil)
+	mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+	mockTx.EXPECT().Rollback(ctx).Return(nil)
+	mockRepo.EXPECT().LockSetlists(ctx, mockTx, 10).Return(nil)
+	mockRepo.EXPECT().GetItems(ctx, mockTx, 10).Return([]model.SetlistItem{}, nil)
+	mockRepo.EXPECT().DeleteItemGroup(ctx, mockTx, 7, 10, 1).Return(pgx.ErrNoRows)
+
+	if err := svc.DeleteGroup(ctx, 10, 7, 1, userID); !errors.Is(err, ErrGroupNotFound) {
+		t.Fatalf("expected ErrGroupNotFound, got %v", err)
+	}
+}
+
+func TestKeepGroupsTogether(t *testing.T) {
+	first, second := 1, 2
+	items := []model.SetlistItem{
+		{ID: 1, GroupID: &first}, {ID: 2, GroupID: &first}, {ID: 3}, {ID: 4, GroupID: &second}, {ID: 5, GroupID: &second},
+	}
+
+	cases := []struct {
+		name  string
+		order []int
+		want  []int
+	}{
+		{"keeps an order where groups stay together", []int{3, 1, 2, 4, 5}, []int{3, 1, 2, 4, 5}},
+		{"brings the members of a group to the first of them", []int{2, 3, 4, 1, 5}, []int{2, 1, 3, 4, 5}},
+		{"keeps the order of the members within a group", []int{5, 4, 3, 2, 1}, []int{5, 4, 3, 2, 1}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := keepGroupsTogether(items, tc.order)
+			if len(got) != len(tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("expected %v, got %v", tc.want, got)
+				}
+			}
+		})
+	}
+}
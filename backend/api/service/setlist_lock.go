@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"setlist/api/model"
+	"setlist/api/repository"
+	"setlist/cache"
+)
+
+// ErrSetlistLocked rejects a change to the items of a locked setlist.
+var ErrSetlistLocked = errors.New("setlist is locked")
+
+// SetLocked locks or unlocks a setlist. While locked, its items cannot be
+// added, moved, edited or removed; its name, color and archive state can
+// still change.
+func (s SetlistService) SetLocked(ctx context.Context, setlistID int, bandID int, userID int, locked bool) (model.Setlist, error) {
+	tx, err := s.SetlistRepo.BeginTx(ctx)
+	if err != nil {
+		return model.Setlist{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	setlist, err := s.SetlistRepo.SetSetlistLock(ctx, tx, setlistID, bandID, locked, userID)
+	if err != nil {
+		return model.Setlist{}, mapNotFound(err, ErrSetlistNotFound)
+	}
+
+	action := RevisionActionUnlock
+	if locked {
+		action = RevisionActionLock
+	}
+	if err := s.recordRevision(ctx, tx, setlistID, userID, action); err != nil {
+		return model.Setlist{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return model.Setlist{}, err
+	}
+
+	s.publishChange(SetlistEventUpdated, SetlistChange{SetlistID: setlistID, AuthorID: userID, Setlist: &setlist})
+	cache.Delete(ctx, s.Cache, cache.SetlistKey(bandID))
+	return setlist, nil
+}
+
+// lockUnlocked locks the setlists until the end of the transaction and checks,
+// under the lock, that their items may change: a setlist locked meanwhile is
+// seen before anything is written.
+func (s SetlistService) lockUnlocked(ctx context.Context, tx repository.DBTX, setlistIDs ...int) error {
+	err := s.SetlistRepo.LockSetlists(ctx, tx, setlistIDs...)
+	if errors.Is(err, repository.ErrSetlistLocked) {
+		return ErrSetlistLocked
+	}
+	return mapNotFound(err, ErrSetlistNotFound)
+}
+
+// bandSetlist returns the setlist if it belongs to the band.
+func (s SetlistService) bandSetlist(ctx context.Context, setlistID int, bandID int) (model.Setlist, error) {
+	setlist, err := s.SetlistRepo.GetSetlistByID(ctx, setlistID, bandID)
+	return setlist, mapNotFound(err, ErrSetlistNotFound)
+}
+
+// itemSetlistID returns the setlist of an item of the band.
+func (s SetlistService) itemSetlistID(ctx context.Context, itemID int, bandID int) (int, error) {
+	setlistID, err := s.SetlistRepo.GetItemSetlistID(ctx, itemID, bandID)
+	return setlistID, mapNotFound(err, ErrItemNotFound)
+}
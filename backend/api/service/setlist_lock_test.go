@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"setlist/api/model"
+	"setlist/api/repository"
+	"setlist/api/repository/mocks"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestSetlistService_SetLocked(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSetlistRepository(ctrl)
+	mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+	mockTx := mocks.NewMockTx(ctrl)
+	svc := SetlistService{SetlistRepo: mockRepo, RevisionRepo: mockRevisionRepo}
+
+	locker := userID
+	mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil).Times(2)
+	mockTx.EXPECT().Rollback(ctx).Return(nil).Times(2)
+	mockTx.EXPECT().Commit(ctx).Return(nil).Times(2)
+	mockRepo.EXPECT().SetSetlistLock(ctx, mockTx, 10, 1, true, userID).Return(model.Setlist{ID: 10, BandID: 1, IsLocked: true, LockedBy: &locker}, nil)
+	mockRevisionRepo.EXPECT().Record(ctx, mockTx, 10, userID, RevisionActionLock).Return(nil)
+	mockRepo.EXPECT().SetSetlistLock(ctx, mockTx, 10, 1, false, userID).Return(model.Setlist{ID: 10, BandID: 1}, nil)
+	mockRevisionRepo.EXPECT().Record(ctx, mockTx, 10, userID, RevisionActionUnlock).Return(nil)
+
+	locked, err := svc.SetLocked(ctx, 10, 1, userID, true)
+	if err != nil {
+		t.Fatalf("unexpected error locking: %v", err)
+	}
+	if !locked.IsLocked || locked.LockedBy == nil || *locked.LockedBy != userID {
+		t.Errorf("unexpected locked setlist: %+v", locked)
+	}
+	if unlocked, err := svc.SetLocked(ctx, 10, 1, userID, false); err != nil || unlocked.IsLocked {
+		t.Fatalf("expected the setlist to be unlocked, got %+v, %v", unlocked, err)
+	}
+}
+
+// The lock is read under the row lock of the transaction, so a setlist locked
+// after the request began is still seen before anything is written.
+func TestSetlistService_LockedSetlistRejectsChanges(t *testing.T) {
+	ctx := context.Background()
+
+	newService := func(t *testing.T) SetlistService {
+		ctrl := gomock.NewController(t)
+		t.Cleanup(ctrl.Finish)
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
+		mockRepo.EXPECT().GetItemSetlistID(ctx, 3, 1).Return(10, nil).AnyTimes()
+		mockRepo.EXPECT().GetSetlistByID(ctx, 10, 1).Return(model.Setlist{ID: 10, BandID: 1}, nil).AnyTimes()
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().LockSetlists(ctx, mockTx, 10).Return(repository.ErrSetlistLocked)
+		// No write and no Commit expected.
+		return SetlistService{SetlistRepo: mockRepo}
+	}
+
+	t.Run("AddItem", func(t *testing.T) {
+		svc := newService(t)
+		_, err := svc.AddItem(ctx, 10, 1, userID, AddItemPayload{ItemType: model.ItemTypeSetBreak})
+		if !errors.Is(err, ErrSetlistLocked) {
+			t.Fatalf("expected ErrSetlistLocked, got %v", err)
+		}
+	})
+
+	t.Run("UpdateOrder", func(t *testing.T) {
+		svc := newService(t)
+		if _, err := svc.UpdateOrder(ctx, 10, 1, userID, UpdateOrderPayload{ItemIDs: []int{3}}); !errors.Is(err, ErrSetlistLocked) {
+			t.Fatalf("expected ErrSetlistLocked, got %v", err)
+		}
+	})
+
+	t.Run("UpdateItem", func(t *testing.T) {
+		svc := newService(t)
+		if _, err := svc.UpdateItem(ctx, 3, 1, userID, UpdateItemPayload{Notes: strPtr("Capo 2")}); !errors.Is(err, ErrSetlistLocked) {
+			t.Fatalf("expected ErrSetlistLocked, got %v", err)
+		}
+	})
+
+	t.Run("DeleteItem", func(t *testing.T) {
+		svc := newService(t)
+		if err := svc.DeleteItem(ctx, 3, 1, userID); !errors.Is(err, ErrSetlistLocked) {
+			t.Fatalf("expected ErrSetlistLocked, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,496 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"setlist/api/model"
+	"setlist/pdf"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	PDFLayoutStage    = "stage"
+	PDFLayoutCompact  = "compact"
+	PDFLayoutMusician = "musician"
+)
+
+const (
+	pdfMargin       = 42.0
+	pdfBottomMargin = 36.0
+	pdfContentWidth = pdf.PageWidth - 2*pdfMargin
+	// pdfGroupIndent shifts the items of a group under its heading.
+	pdfGroupIndent = 14.0
+)
+
+type PDFExportOptions struct {
+	Layout       string
+	Musician     string
+	PlannedStart *time.Time
+}
+
+var (
+	pdfGrey             = pdf.Hex("#64748B")
+	pdfIntermissionFill = pdf.Hex("#E2E8F0")
+	pdfHighlightColors  = []pdf.Color{
+		pdf.Hex("#6EE7B7"), pdf.Hex("#FBBF24"), pdf.Hex("#F87171"),
+		pdf.Hex("#60A5FA"), pdf.Hex("#A78BFA"), pdf.Hex("#F472B6"),
+	}
+)
+
+func (s SetlistService) ExportPDF(ctx context.Context, id int, bandID int, opts PDFExportOptions) (ExportFile, error) {
+	details, err := s.GetDetails(ctx, id, bandID)
+	if err != nil {
+		return ExportFile{}, err
+	}
+	timing := ComputeTiming(details.Items, opts.PlannedStart)
+
+	var doc *pdf.Document
+	suffix := ""
+	switch opts.Layout {
+	case "", PDFLayoutStage:
+		doc = renderStagePDF(details, timing)
+		suffix = "_live"
+	case PDFLayoutCompact:
+		doc = renderCompactPDF(details, timing)
+		suffix = "_compact"
+	case PDFLayoutMusician:
+		parts, err := s.loadMusicianParts(ctx, details.Items, bandID)
+		if err != nil {
+			return ExportFile{}, err
+		}
+		musicians := []string{opts.Musician}
+		if opts.Musician == "" {
+			musicians = collectMusicians(details.Items, parts)
+		}
+		doc = renderMusicianPDF(details, timing, parts, musicians)
+		suffix = "_musiciens"
+		if opts.Musician != "" {
+			suffix = "_" + opts.Musician
+		}
+	default:
+		return ExportFile{}, ErrInvalidExportFormat
+	}
+
+	content, err := doc.Bytes()
+	if err != nil {
+		return ExportFile{}, err
+	}
+	return ExportFile{
+		FileName:    exportFileName(details.Name, suffix, "pdf"),
+		ContentType: "application/pdf",
+		Content:     content,
+	}, nil
+}
+
+// loadSongs fetches every song of the setlist once. Songs deleted since they
+// were added are left out.
+func (s SetlistService) loadSongs(ctx context.Context, items []model.SetlistItem, bandID int) (map[int32]model.Song, error) {
+	songs := make(map[int32]model.Song)
+	missing := make(map[int32]bool)
+	for _, item := range items {
+		if item.SongID == nil {
+			continue
+		}
+		if _, done := songs[*item.SongID]; done || missing[*item.SongID] {
+			continue
+		}
+		song, err := s.SongRepo.GetSongByID(ctx, int(*item.SongID), bandID)
+		if err != nil {
+			if isNotFound(err) {
+				missing[*item.SongID] = true
+				continue
+			}
+			return nil, err
+		}
+		songs[*item.SongID] = song
+	}
+	return songs, nil
+}
+
+// loadMusicianParts returns, for each song of the setlist, the part of every
+// musician listed in the song's instrumentation.
+func (s SetlistService) loadMusicianParts(ctx context.Context, items []model.SetlistItem, bandID int) (map[int32]map[string]string, error) {
+	songs, err := s.loadSongs(ctx, items, bandID)
+	if err != nil {
+		return nil, err
+	}
+	parts := make(map[int32]map[string]string, len(songs))
+	for id, song := range songs {
+		parts[id] = musicianParts(song.Instrumentation)
+	}
+	return parts, nil
+}
+
+// musicianParts reads an instrumentation object keyed by musician, whose
+// values are either a single part or a list of parts. Anything else is
+// ignored.
+func musicianParts(raw json.RawMessage) map[string]string {
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &entries); err != nil {
+		return nil
+	}
+	parts := make(map[string]string, len(entries))
+	for musician, value := range entries {
+		var single string
+		if err := json.Unmarshal(value, &single); err == nil {
+			parts[musician] = single
+			continue
+		}
+		var list []string
+		if err := json.Unmarshal(value, &list); err == nil {
+			parts[musician] = strings.Join(list, ", ")
+		}
+	}
+	return parts
+}
+
+func collectMusicians(items []model.SetlistItem, parts map[int32]map[string]string) []string {
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if item.ItemType == model.ItemTypeInterlude && item.Speaker != nil && *item.Speaker != "" {
+			seen[*item.Speaker] = true
+		}
+		if item.SongID != nil {
+			for musician := range parts[*item.SongID] {
+				seen[musician] = true
+			}
+		}
+	}
+	musicians := make([]string, 0, len(seen))
+	for musician := range seen {
+		musicians = append(musicians, musician)
+	}
+	sort.Strings(musicians)
+	if len(musicians) == 0 {
+		musicians = append(musicians, "")
+	}
+	return musicians
+}
+
+type pdfCursor struct {
+	doc *pdf.Document
+	y   float64
+}
+
+func newPDFCursor() *pdfCursor {
+	c := &pdfCursor{doc: pdf.New()}
+	c.newPage()
+	return c
+}
+
+func (c *pdfCursor) newPage() {
+	c.doc.AddPage()
+	c.y = pdfMargin
+}
+
+// reserve starts a new page when the next block would not fit on this one.
+func (c *pdfCursor) reserve(height float64) {
+	if c.y+height > pdf.PageHeight-pdfBottomMargin {
+		c.newPage()
+	}
+}
+
+func (c *pdfCursor) centered(font pdf.Font, size float64, color pdf.Color, text string) {
+	text = pdf.Truncate(font, size, text, pdfContentWidth)
+	x := (pdf.PageWidth - pdf.TextWidth(font, size, text)) / 2
+	c.y += size
+	c.doc.Text(x, c.y, font, size, color, text)
+}
+
+func (c *pdfCursor) paragraph(x float64, font pdf.Font, size float64, color pdf.Color, text string) {
+	lineHeight := size * 1.25
+	for _, line := range pdf.WrapText(font, size, text, pdf.PageWidth-pdfMargin-x) {
+		c.reserve(lineHeight)
+		c.y += lineHeight
+		c.doc.Text(x, c.y, font, size, color, line)
+	}
+}
+
+func (c *pdfCursor) header(details SetlistDetails, timing SetlistTiming, subtitle string) {
+	c.centered(pdf.Bold, 22, pdf.Black, details.Name)
+	if subtitle != "" {
+		c.y += 6
+		c.centered(pdf.Bold, 14, pdfGrey, subtitle)
+	}
+	c.y += 6
+	summary := "Durée totale : " + formatDuration(timing.TotalDurationSeconds)
+	if timing.PlannedStart != nil {
+		summary += fmt.Sprintf(" (%s - %s)", timing.PlannedStart.Format("15:04"), timing.PlannedEnd.Format("15:04"))
+	}
+	c.centered(pdf.Regular, 11, pdfGrey, summary)
+	c.y += 18
+}
+
+func (c *pdfCursor) setHeading(set SetTiming, size float64) {
+	c.reserve(size*3 + 20)
+	c.y += size + 6
+	title := fmt.Sprintf("%s · %s", setTitle(set), formatDuration(set.DurationSeconds))
+	c.doc.Text(pdfMargin, c.y, pdf.Bold, size, pdfGrey, title)
+	c.y += 5
+	c.doc.Line(pdfMargin, c.y, pdf.PageWidth-pdfMargin, c.y, 0.8, pdfGrey)
+	c.y += 6
+}
+
+// groupHeading announces a group, such as a medley, with its combined
+// duration before its first item.
+func (c *pdfCursor) groupHeading(group GroupTiming, size float64) {
+	c.reserve(size*3 + 10)
+	c.y += size*1.3 + 4
+	c.doc.Text(pdfMargin, c.y, pdf.Italic, size, pdfGrey, pdf.Truncate(pdf.Italic, size, groupTitle(group), pdfContentWidth))
+}
+
+// groupIndent is the left edge of an item's title, further right for the
+// items of a group.
+func groupIndent(item model.SetlistItem, x float64) float64 {
+	if item.GroupID != nil {
+		return x + pdfGroupIndent
+	}
+	return x
+}
+
+func (c *pdfCursor) highlighted(text string, size float64, fill pdf.Color) {
+	width := pdf.TextWidth(pdf.Bold, size, text)
+	c.y += size * 1.3
+	c.doc.FillRect(pdfMargin-2, c.y-size, width+8, size*1.3, fill)
+	c.doc.Text(pdfMargin+2, c.y, pdf.Bold, size, pdf.Black, text)
+}
+
+type speakerPalette map[string]pdf.Color
+
+func (p speakerPalette) colorFor(key string) pdf.Color {
+	if color, ok := p[key]; ok {
+		return color
+	}
+	color := pdfHighlightColors[len(p)%len(pdfHighlightColors)]
+	p[key] = color
+	return color
+}
+
+func interludeLabel(item model.SetlistItem) string {
+	title := itemTitle(item)
+	if item.Speaker != nil && *item.Speaker != "" {
+		return *item.Speaker + " : " + title
+	}
+	return title
+}
+
+// renderStagePDF is the large-font version meant to be taped to the stage:
+// one column, no notes, sets clearly separated.
+func renderStagePDF(details SetlistDetails, timing SetlistTiming) *pdf.Document {
+	const size = 20.0
+	c := newPDFCursor()
+	c.header(details, timing, "")
+
+	sets := setsByFirstItem(timing)
+	groups := groupsByFirstItem(timing)
+	palette := speakerPalette{}
+	songNumber := 0
+	for _, item := range details.Items {
+		if set, ok := sets[item.ID]; ok {
+			c.setHeading(set, 14)
+		}
+		if group, ok := groups[item.ID]; ok {
+			c.groupHeading(group, 14)
+		}
+		x := groupIndent(item, pdfMargin)
+		switch item.ItemType {
+		case model.ItemTypeSong:
+			songNumber++
+			c.reserve(size * 1.8)
+			c.y += size * 1.3
+			text := pdf.Truncate(pdf.Bold, size, fmt.Sprintf("%d. %s", songNumber, itemTitle(item)), pdf.PageWidth-pdfMargin-x)
+			c.doc.Text(x, c.y, pdf.Bold, size, pdf.Black, text)
+		case model.ItemTypeInterlude:
+			c.reserve(size * 1.8)
+			label := pdf.Truncate(pdf.Bold, size, interludeLabel(item), pdfContentWidth)
+			c.highlighted(label, size, palette.colorFor(valueOrEmpty(item.Speaker)+itemTitle(item)))
+		case model.ItemTypeIntermission:
+			c.reserve(size * 2)
+			c.y += 6
+			c.highlighted(fmt.Sprintf("%s · %s", itemTitle(item), formatItemDuration(item.DurationSeconds)), size*0.8, pdfIntermissionFill)
+		case model.ItemTypePlaceholder:
+			c.reserve(size * 1.8)
+			c.y += size * 1.3
+			c.doc.Text(x, c.y, pdf.Italic, size, pdfGrey, pdf.Truncate(pdf.Italic, size, "? "+itemTitle(item), pdf.PageWidth-pdfMargin-x))
+		default:
+			continue
+		}
+		c.y += size * 0.5
+	}
+	return c.doc
+}
+
+// renderCompactPDF fits the whole show on as few pages as possible, with the
+// key, tempo, duration and start time of every item.
+func renderCompactPDF(details SetlistDetails, timing SetlistTiming) *pdf.Document {
+	const size = 10.0
+	columns := []struct {
+		title string
+		x     float64
+	}{
+		{"#", pdfMargin}, {"Titre", pdfMargin + 22}, {"Tonalité", 340}, {"Tempo", 400}, {"Durée", 450}, {"Début", 500},
+	}
+	titleWidth := columns[2].x - columns[1].x - 8
+
+	c := newPDFCursor()
+	c.header(details, timing, "")
+
+	tableHeader := func() {
+		c.y += size
+		for _, col := range columns {
+			c.doc.Text(col.x, c.y, pdf.Bold, size-1, pdfGrey, col.title)
+		}
+		c.y += 4
+		c.doc.Line(pdfMargin, c.y, pdf.PageWidth-pdfMargin, c.y, 0.5, pdfGrey)
+		c.y += 2
+	}
+	tableHeader()
+
+	sets := setsByFirstItem(timing)
+	groups := groupsByFirstItem(timing)
+	songNumber := 0
+	for i, item := range details.Items {
+		if set, ok := sets[item.ID]; ok {
+			c.setHeading(set, 11)
+		}
+		if item.ItemType == model.ItemTypeSetBreak || item.ItemType == model.ItemTypeEncore {
+			continue
+		}
+
+		group, opensGroup := groups[item.ID]
+		pages := c.doc.PageCount()
+		if opensGroup {
+			c.reserve(size * 3.1)
+		} else {
+			c.reserve(size * 1.6)
+		}
+		if c.doc.PageCount() != pages {
+			tableHeader()
+		}
+		if opensGroup {
+			c.y += size * 1.5
+			c.doc.Text(columns[1].x, c.y, pdf.Italic, size, pdfGrey, pdf.Truncate(pdf.Italic, size, group.Name, titleWidth))
+			c.doc.Text(columns[4].x, c.y, pdf.Italic, size, pdfGrey, formatDuration(group.DurationSeconds))
+		}
+		c.y += size * 1.5
+
+		font := pdf.Regular
+		number := ""
+		switch item.ItemType {
+		case model.ItemTypeSong:
+			songNumber++
+			number = fmt.Sprintf("%d", songNumber)
+			font = pdf.Bold
+		case model.ItemTypeIntermission:
+			font = pdf.Italic
+		}
+		title := itemTitle(item)
+		if item.ItemType == model.ItemTypeInterlude {
+			title = interludeLabel(item)
+		}
+
+		start := formatDuration(timing.Items[i].StartOffsetSeconds)
+		if timing.Items[i].StartsAt != nil {
+			start = timing.Items[i].StartsAt.Format("15:04")
+		}
+		tempo := ""
+		if item.Tempo != nil {
+			tempo = fmt.Sprintf("%d", *item.Tempo)
+		}
+
+		c.doc.Text(columns[0].x, c.y, pdf.Regular, size, pdfGrey, number)
+		x := groupIndent(item, columns[1].x)
+		c.doc.Text(x, c.y, font, size, pdf.Black, pdf.Truncate(font, size, title, titleWidth-(x-columns[1].x)))
+		c.doc.Text(columns[2].x, c.y, pdf.Regular, size, pdf.Black, valueOrEmpty(item.SongKey))
+		c.doc.Text(columns[3].x, c.y, pdf.Regular, size, pdf.Black, tempo)
+		c.doc.Text(columns[4].x, c.y, pdf.Regular, size, pdf.Black, formatItemDuration(item.DurationSeconds))
+		c.doc.Text(columns[5].x, c.y, pdf.Regular, size, pdf.Black, start)
+
+		if item.Notes != nil && *item.Notes != "" {
+			c.paragraph(columns[1].x, pdf.Italic, size-2, pdfGrey, *item.Notes)
+		}
+	}
+	return c.doc
+}
+
+// renderMusicianPDF prints one section per musician with their part on every
+// song and their own interludes highlighted. An empty musician name prints
+// every part instead.
+func renderMusicianPDF(details SetlistDetails, timing SetlistTiming, parts map[int32]map[string]string, musicians []string) *pdf.Document {
+	const size = 14.0
+	c := newPDFCursor()
+	sets := setsByFirstItem(timing)
+	groups := groupsByFirstItem(timing)
+
+	for m, musician := range musicians {
+		if m > 0 {
+			c.newPage()
+		}
+		c.header(details, timing, musician)
+
+		songNumber := 0
+		for _, item := range details.Items {
+			if set, ok := sets[item.ID]; ok {
+				c.setHeading(set, 12)
+			}
+			if group, ok := groups[item.ID]; ok {
+				c.groupHeading(group, 12)
+			}
+			switch item.ItemType {
+			case model.ItemTypeSong:
+				songNumber++
+				c.reserve(size * 2.5)
+				c.y += size * 1.3
+				title := fmt.Sprintf("%d. %s", songNumber, itemTitle(item))
+				if item.SongKey != nil && *item.SongKey != "" {
+					title += " (" + *item.SongKey + ")"
+				}
+				x := groupIndent(item, pdfMargin)
+				c.doc.Text(x, c.y, pdf.Bold, size, pdf.Black, pdf.Truncate(pdf.Bold, size, title, pdf.PageWidth-pdfMargin-x))
+				if item.SongID != nil {
+					c.musicianPart(parts[*item.SongID], musician, size-2)
+				}
+			case model.ItemTypeInterlude:
+				c.reserve(size * 2.5)
+				isOwn := musician == "" || (item.Speaker != nil && *item.Speaker == musician)
+				if isOwn {
+					c.highlighted(pdf.Truncate(pdf.Bold, size, interludeLabel(item), pdfContentWidth), size, pdfHighlightColors[1])
+				} else {
+					c.y += size * 1.3
+					c.doc.Text(pdfMargin, c.y, pdf.Regular, size, pdfGrey, pdf.Truncate(pdf.Regular, size, interludeLabel(item), pdfContentWidth))
+				}
+			case model.ItemTypeIntermission:
+				c.reserve(size * 2)
+				c.y += size * 1.3
+				c.doc.Text(pdfMargin, c.y, pdf.Italic, size, pdfGrey, fmt.Sprintf("%s · %s", itemTitle(item), formatItemDuration(item.DurationSeconds)))
+			default:
+				continue
+			}
+			if item.Notes != nil && *item.Notes != "" {
+				c.paragraph(pdfMargin+12, pdf.Italic, size-3, pdfGrey, *item.Notes)
+			}
+			c.y += size * 0.4
+		}
+	}
+	return c.doc
+}
+
+func (c *pdfCursor) musicianPart(parts map[string]string, musician string, size float64) {
+	if musician != "" {
+		if part, ok := parts[musician]; ok && part != "" {
+			c.paragraph(pdfMargin+12, pdf.Regular, size, pdf.Black, part)
+		}
+		return
+	}
+	names := make([]string, 0, len(parts))
+	for name := range parts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c.paragraph(pdfMargin+12, pdf.Regular, size, pdf.Black, name+" : "+parts[name])
+	}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"setlist/api/model"
+	"setlist/api/repository"
+	"setlist/cache"
+)
+
+const (
+	RevisionActionCreate       = "create"
+	RevisionActionDuplicate    = "duplicate"
+	RevisionActionUpdate       = "update"
+	RevisionActionAddItem      = "add_item"
+	RevisionActionUpdateOrder  = "update_order"
+	RevisionActionUpdateItem   = "update_item"
+	RevisionActionDeleteItem   = "delete_item"
+	RevisionActionRestore      = "restore"
+	RevisionActionRepair       = "repair_positions"
+	RevisionActionMoveItems    = "move_items"
+	RevisionActionCopyItems    = "copy_items"
+	RevisionActionCopyToBand   = "copy_from_band"
+	RevisionActionLock         = "lock"
+	RevisionActionUnlock       = "unlock"
+	RevisionActionGroupItems   = "group_items"
+	RevisionActionUpdateGroup  = "update_group"
+	RevisionActionUngroupItems = "ungroup_items"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found for this setlist")
+
+// RevisionSnapshot is the state of a setlist stored with each revision.
+type RevisionSnapshot struct {
+	Name       string              `json:"name"`
+	Color      string              `json:"color"`
+	IsArchived bool                `json:"is_archived"`
+	Items      []model.SetlistItem `json:"items"`
+}
+
+type RevisionDetails struct {
+	model.SetlistRevision
+	Snapshot RevisionSnapshot `json:"snapshot"`
+}
+
+// recordRevision stores the state of the setlist after a change, within the
+// transaction of the change so that every change committed has its revision.
+func (s SetlistService) recordRevision(ctx context.Context, db repository.DBTX, setlistID int, userID int, action string) error {
+	return s.RevisionRepo.Record(ctx, db, setlistID, userID, action)
+}
+
+func (s SetlistService) ListRevisions(ctx context.Context, setlistID int, bandID int) ([]model.SetlistRevision, error) {
+	if _, err := s.SetlistRepo.GetSetlistByID(ctx, setlistID, bandID); err != nil {
+		return nil, mapNotFound(err, ErrSetlistNotFound)
+	}
+	return s.RevisionRepo.ListBySetlistID(ctx, setlistID)
+}
+
+func (s SetlistService) GetRevision(ctx context.Context, setlistID int, bandID int, revisionID int) (RevisionDetails, error) {
+	if _, err := s.SetlistRepo.GetSetlistByID(ctx, setlistID, bandID); err != nil {
+		return RevisionDetails{}, mapNotFound(err, ErrSetlistNotFound)
+	}
+	revision, err := s.RevisionRepo.GetByID(ctx, setlistID, revisionID)
+	if err != nil {
+		return RevisionDetails{}, mapNotFound(err, ErrRevisionNotFound)
+	}
+
+	var snapshot RevisionSnapshot
+	if err := json.Unmarshal(revision.Snapshot, &snapshot); err != nil {
+		return RevisionDetails{}, err
+	}
+	revision.Snapshot = nil
+	return RevisionDetails{SetlistRevision: revision, Snapshot: snapshot}, nil
+}
+
+// RestoreRevision puts the setlist back in the state of a revision: its name,
+// color and items are replaced in a single transaction, the same way
+// Duplicate copies items. The restore is itself recorded as a new revision so
+// it can be undone.
+func (s SetlistService) RestoreRevision(ctx context.Context, setlistID int, bandID int, userID int, revisionID int) (SetlistDetails, error) {
+	revision, err := s.GetRevision(ctx, setlistID, bandID, revisionID)
+	if err != nil {
+		return SetlistDetails{}, err
+	}
+
+	tx, err := s.SetlistRepo.BeginTx(ctx)
+	if err != nil {
+		return SetlistDetails{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := s.lockUnlocked(ctx, tx, setlistID); err != nil {
+		return SetlistDetails{}, err
+	}
+	setlist, err := s.bandSetlist(ctx, setlistID, bandID)
+	if err != nil {
+		return SetlistDetails{}, err
+	}
+
+	items := revision.Snapshot.Items
+	for i := range items {
+		items[i].Position = i
+	}
+	missing, err := s.SetlistRepo.MissingItemReferences(ctx, tx, bandID, items)
+	if err != nil {
+		return SetlistDetails{}, err
+	}
+	if len(missing) > 0 {
+		title := "Un élément"
+		if missing[0].Title != nil {
+			title = "« " + *missing[0].Title + " »"
+		}
+		return SetlistDetails{}, &ValidationError{Msg: title + " de cette version n'existe plus : elle ne peut pas être restaurée."}
+	}
+
+	setlist.Name = revision.Snapshot.Name
+	setlist.Color = revision.Snapshot.Color
+	if _, err := s.SetlistRepo.UpdateSetlist(ctx, tx, setlist, nil); err != nil {
+		return SetlistDetails{}, err
+	}
+	if err := s.SetlistRepo.DeleteItemsBySetlistID(ctx, tx, setlistID); err != nil {
+		return SetlistDetails{}, err
+	}
+	if err := s.SetlistRepo.CopyItemsToNewSetlist(ctx, tx, setlistID, items); err != nil {
+		return SetlistDetails{}, err
+	}
+	if err := s.recordRevision(ctx, tx, setlistID, userID, RevisionActionRestore); err != nil {
+		return SetlistDetails{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return SetlistDetails{}, err
+	}
+
+	s.publishChange(SetlistEventRestored, SetlistChange{SetlistID: setlistID, AuthorID: userID})
+	cache.Delete(ctx, s.Cache, cache.SetlistKey(bandID))
+	return s.GetDetails(ctx, setlistID, bandID)
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"setlist/api/model"
+	"setlist/api/repository/mocks"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSetlistService_RestoreRevision(t *testing.T) {
+	ctx := context.Background()
+	setlistID := 10
+	bandID := 1
+	current := model.Setlist{ID: setlistID, BandID: bandID, Name: "Festival (v2)", Color: "#000000"}
+
+	t.Run("replaces name, color and items in one transaction", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo, RevisionRepo: mockRevisionRepo}
+
+		songA, songB := int32(5), int32(6)
+		snapshot, _ := json.Marshal(RevisionSnapshot{
+			Name:  "Festival",
+			Color: "#FF0000",
+			Items: []model.SetlistItem{
+				{ID: 1, Position: 4, ItemType: model.ItemTypeSong, SongID: &songB},
+				{ID: 2, Position: 7, ItemType: model.ItemTypeSong, SongID: &songA},
+			},
+		})
+
+		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(current, nil).Times(3)
+		mockRevisionRepo.EXPECT().GetByID(ctx, setlistID, 42).Return(model.SetlistRevision{ID: 42, SetlistID: setlistID, Snapshot: snapshot}, nil)
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().LockSetlists(ctx, mockTx, setlistID).Return(nil)
+		mockRepo.EXPECT().UpdateSetlist(ctx, mockTx, gomock.Any(), gomock.Nil()).DoAndReturn(
+			func(_ context.Context, _ any, setlist model.Setlist, _ *int) (model.Setlist, error) {
+				if setlist.Name != "Festival" || setlist.Color != "#FF0000" {
+					t.Errorf("expected the snapshot name and color, got %+v", setlist)
+				}
+				return setlist, nil
+			})
+		mockRepo.EXPECT().MissingItemReferences(ctx, mockTx, bandID, gomock.Any()).Return([]model.SetlistItem{}, nil)
+		mockRepo.EXPECT().DeleteItemsBySetlistID(ctx, mockTx, setlistID).Return(nil)
+		mockRepo.EXPECT().CopyItemsToNewSetlist(ctx, mockTx, setlistID, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ any, _ int, items []model.SetlistItem) error {
+				if len(items) != 2 || *items[0].SongID != songB || items[0].Position != 0 || items[1].Position != 1 {
+					t.Errorf("expected the snapshot items renumbered in order, got %+v", items)
+				}
+				return nil
+			})
+		mockRevisionRepo.EXPECT().Record(ctx, mockTx, setlistID, userID, RevisionActionRestore).Return(nil)
+		mockTx.EXPECT().Commit(ctx).Return(nil)
+		mockRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, setlistID).Return([]model.SetlistItem{}, nil)
+
+		if _, err := svc.RestoreRevision(ctx, setlistID, bandID, userID, 42); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("rejects a version whose interlude was deleted", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo, RevisionRepo: mockRevisionRepo}
+
+		interludeID := int32(7)
+		deleted := model.SetlistItem{ID: 1, ItemType: model.ItemTypeInterlude, InterludeID: &interludeID, Title: strPtr("Présentations")}
+		snapshot, _ := json.Marshal(RevisionSnapshot{Name: "Festival", Items: []model.SetlistItem{deleted}})
+
+		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(current, nil).Times(2)
+		mockRevisionRepo.EXPECT().GetByID(ctx, setlistID, 42).Return(model.SetlistRevision{ID: 42, SetlistID: setlistID, Snapshot: snapshot}, nil)
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().LockSetlists(ctx, mockTx, setlistID).Return(nil)
+		mockRepo.EXPECT().MissingItemReferences(ctx, mockTx, bandID, gomock.Any()).Return([]model.SetlistItem{deleted}, nil)
+		// Nothing is written or committed.
+
+		_, err := svc.RestoreRevision(ctx, setlistID, bandID, userID, 42)
+		if !isValidationError(err) || !strings.Contains(err.Error(), "Présentations") {
+			t.Fatalf("expected a ValidationError naming the interlude, got %v", err)
+		}
+	})
+
+	t.Run("unknown revision", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo, RevisionRepo: mockRevisionRepo}
+
+		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(current, nil)
+		mockRevisionRepo.EXPECT().GetByID(ctx, setlistID, 99).Return(model.SetlistRevision{}, pgx.ErrNoRows)
+		// No transaction is started.
+
+		if _, err := svc.RestoreRevision(ctx, setlistID, bandID, userID, 99); !errors.Is(err, ErrRevisionNotFound) {
+			t.Fatalf("expected ErrRevisionNotFound, got %v", err)
+		}
+	})
+}
+
+func TestSetlistService_RecordRevisionFailureRollsBackChange(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSetlistRepository(ctrl)
+	mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+	mockTx := mocks.NewMockTx(ctrl)
+	svc := SetlistService{SetlistRepo: mockRepo, RevisionRepo: mockRevisionRepo}
+
+	recordErr := errors.New("db down")
+	mockRepo.EXPECT().GetItemSetlistID(ctx, 3, 1).Return(10, nil)
+	mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+	mockTx.EXPECT().Rollback(ctx).Return(nil)
+	mockRepo.EXPECT().LockSetlists(ctx, mockTx, 10).Return(nil)
+	mockRepo.EXPECT().DeleteSetlistItem(ctx, mockTx, 3, 10).Return(nil)
+	mockRevisionRepo.EXPECT().Record(ctx, mockTx, 10, userID, RevisionActionDeleteItem).Return(recordErr)
+	// No Commit: the deletion is rolled back with its revision.
+
+	if err := svc.DeleteItem(ctx, 3, 1, userID); !errors.Is(err, recordErr) {
+		t.Fatalf("expected the history failure to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"setlist/api/model"
+	"setlist/api/repository"
+	"setlist/cache"
+	"strconv"
+	"time"
+)
+
+const maxSetlistPageSize = 100
+
+// SetlistQuery filters, sorts and pages the setlists of a band. The zero value
+// lists the first page of every setlist, newest first; pages hold
+// maxSetlistPageSize setlists unless Limit asks for fewer.
+type SetlistQuery struct {
+	Archived *bool
+	Search   string
+	Color    string
+	GigFrom  *time.Time
+	GigTo    *time.Time
+	Sort     string
+	Cursor   string
+	Limit    int
+}
+
+// SetlistPage is one page of setlists. NextCursor is empty on the last page.
+type SetlistPage struct {
+	Setlists   []model.Setlist `json:"setlists"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// List returns the setlists of the band matching the query. Every query shape
+// is cached as a field of the band's setlist key, so the invalidation done
+// after each change drops all of them at once.
+func (s SetlistService) List(ctx context.Context, bandID int, query SetlistQuery) (SetlistPage, error) {
+	filter, err := setlistFilter(query)
+	if err != nil {
+		return SetlistPage{}, err
+	}
+
+	key, field := cache.SetlistKey(bandID), query.cacheField()
+	if data, ok := cache.GetField(ctx, s.Cache, key, field); ok {
+		var page SetlistPage
+		if err := json.Unmarshal([]byte(data), &page); err == nil {
+			return page, nil
+		}
+	}
+
+	// One more row tells whether there is a next page.
+	filter.Limit++
+	setlists, err := s.SetlistRepo.SearchSetlists(ctx, bandID, filter)
+	if err != nil {
+		return SetlistPage{}, err
+	}
+
+	page := SetlistPage{Setlists: setlists}
+	if len(setlists) == filter.Limit {
+		page.Setlists = setlists[:len(setlists)-1]
+		page.NextCursor = encodeSetlistCursor(page.Setlists[len(page.Setlists)-1], filter.Sort)
+	}
+
+	if data, err := json.Marshal(page); err == nil {
+		cache.SetField(ctx, s.Cache, key, field, string(data), setlistCacheTTL)
+	}
+	return page, nil
+}
+
+func setlistFilter(query SetlistQuery) (repository.SetlistFilter, error) {
+	filter := repository.SetlistFilter{
+		Archived: query.Archived,
+		Search:   query.Search,
+		Color:    query.Color,
+		GigFrom:  query.GigFrom,
+		GigTo:    query.GigTo,
+		Sort:     query.Sort,
+		Limit:    query.Limit,
+	}
+
+	switch filter.Sort {
+	case "":
+		filter.Sort = repository.SetlistSortCreatedDesc
+	case repository.SetlistSortCreatedDesc, repository.SetlistSortCreatedAsc, repository.SetlistSortNameAsc, repository.SetlistSortNameDesc:
+	default:
+		return filter, &ValidationError{Msg: "Tri inconnu : utilisez created_desc, created_asc, name_asc ou name_desc."}
+	}
+	if filter.Color != "" && !hexColorRegex.MatchString(filter.Color) {
+		return filter, ErrInvalidColor
+	}
+	if filter.GigFrom != nil && filter.GigTo != nil && !filter.GigTo.After(*filter.GigFrom) {
+		return filter, &ValidationError{Msg: "La fin de la période doit être après son début."}
+	}
+	if filter.Limit < 0 || filter.Limit > maxSetlistPageSize {
+		return filter, &ValidationError{Msg: fmt.Sprintf("La taille de page doit être comprise entre 1 et %d.", maxSetlistPageSize)}
+	}
+	if filter.Limit == 0 {
+		filter.Limit = maxSetlistPageSize
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeSetlistCursor(query.Cursor, filter.Sort)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = &cursor
+	}
+	return filter, nil
+}
+
+// cacheField identifies the query shape; url.Values sorts its keys so equal
+// queries always give the same field.
+func (q SetlistQuery) cacheField() string {
+	values := url.Values{}
+	if q.Archived != nil {
+		values.Set("archived", strconv.FormatBool(*q.Archived))
+	}
+	values.Set("q", q.Search)
+	values.Set("color", q.Color)
+	if q.GigFrom != nil {
+		values.Set("gig_from", q.GigFrom.UTC().Format(time.RFC3339))
+	}
+	if q.GigTo != nil {
+		values.Set("gig_to", q.GigTo.UTC().Format(time.RFC3339))
+	}
+	values.Set("sort", q.Sort)
+	values.Set("cursor", q.Cursor)
+	values.Set("limit", strconv.Itoa(q.Limit))
+	return values.Encode()
+}
+
+// A cursor is opaque to clients: the sort it was made for, the sort value of
+// the last setlist of the page and its ID.
+type setlistCursor struct {
+	Sort string `json:"s"`
+	repository.SetlistCursor
+}
+
+func encodeSetlistCursor(last model.Setlist, sort string) string {
+	cursor := setlistCursor{Sort: sort, SetlistCursor: repository.SetlistCursor{Value: last.Name, ID: last.ID}}
+	if sort == repository.SetlistSortCreatedDesc || sort == repository.SetlistSortCreatedAsc {
+		cursor.Value = last.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSetlistCursor(raw string, sort string) (repository.SetlistCursor, error) {
+	invalid := &ValidationError{Msg: "Curseur de pagination invalide."}
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return repository.SetlistCursor{}, invalid
+	}
+	var cursor setlistCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Sort != sort {
+		return repository.SetlistCursor{}, invalid
+	}
+	return cursor.SetlistCursor, nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"setlist/api/model"
+	"setlist/api/repository"
+	"setlist/api/repository/mocks"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestSetlistService_List(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("without a limit returns a first page, newest first", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo}
+
+		setlists := make([]model.Setlist, maxSetlistPageSize+1)
+		for i := range setlists {
+			setlists[i] = model.Setlist{ID: len(setlists) - i}
+		}
+		mockRepo.EXPECT().SearchSetlists(ctx, 1, repository.SetlistFilter{Sort: repository.SetlistSortCreatedDesc, Limit: maxSetlistPageSize + 1}).
+			Return(setlists, nil)
+
+		page, err := svc.List(ctx, 1, SetlistQuery{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(page.Setlists) != maxSetlistPageSize || page.NextCursor == "" {
+			t.Errorf("expected a full page and a cursor, got %d setlists and cursor %q", len(page.Setlists), page.NextCursor)
+		}
+	})
+
+	t.Run("passes the filters and pages with a cursor", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo}
+
+		archived := false
+		from := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+		to := from.AddDate(0, 1, 0)
+		query := SetlistQuery{Archived: &archived, Search: "été", Color: "#FF0000", GigFrom: &from, GigTo: &to, Sort: repository.SetlistSortNameAsc, Limit: 2}
+
+		mockRepo.EXPECT().SearchSetlists(ctx, 1, repository.SetlistFilter{
+			Archived: &archived, Search: "été", Color: "#FF0000", GigFrom: &from, GigTo: &to,
+			Sort: repository.SetlistSortNameAsc, Limit: 3,
+		}).Return([]model.Setlist{{ID: 4, Name: "A"}, {ID: 9, Name: "B"}, {ID: 5, Name: "C"}}, nil)
+
+		page, err := svc.List(ctx, 1, query)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(page.Setlists) != 2 || page.NextCursor == "" {
+			t.Fatalf("expected a full page and a cursor, got %+v", page)
+		}
+
+		query.Cursor = page.NextCursor
+		mockRepo.EXPECT().SearchSetlists(ctx, 1, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ int, filter repository.SetlistFilter) ([]model.Setlist, error) {
+				if filter.After == nil || *filter.After != (repository.SetlistCursor{Value: "B", ID: 9}) {
+					t.Errorf("unexpected cursor %+v", filter.After)
+				}
+				return []model.Setlist{{ID: 5, Name: "C"}}, nil
+			})
+
+		page, err = svc.List(ctx, 1, query)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(page.Setlists) != 1 || page.NextCursor != "" {
+			t.Errorf("expected the last page, got %+v", page)
+		}
+	})
+
+	t.Run("rejects invalid queries", func(t *testing.T) {
+		svc := SetlistService{}
+		from := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+		cursor := encodeSetlistCursor(model.Setlist{ID: 3, Name: "A"}, repository.SetlistSortNameAsc)
+
+		for name, query := range map[string]SetlistQuery{
+			"unknown sort":           {Sort: "position"},
+			"limit too large":        {Limit: maxSetlistPageSize + 1},
+			"garbled cursor":         {Cursor: "%%%"},
+			"cursor of another sort": {Cursor: cursor},
+			"empty gig period":       {GigFrom: &from, GigTo: &from},
+		} {
+			if _, err := svc.List(ctx, 1, query); !isValidationError(err) {
+				t.Errorf("%s: expected a ValidationError, got %v", name, err)
+			}
+		}
+		if _, err := svc.List(ctx, 1, SetlistQuery{Color: "red"}); !errors.Is(err, ErrInvalidColor) {
+			t.Errorf("expected ErrInvalidColor, got %v", err)
+		}
+	})
+}
@@ -2,24 +2,35 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"fmt"
 	"regexp"
 	"setlist/api/model"
 	"setlist/api/repository"
 	"setlist/cache"
+	"setlist/realtime"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
-const setlistCacheTTL = 30 * time.Minute
+const (
+	setlistCacheTTL = 30 * time.Minute
+	// maxBatchItems bounds the number of items added in a single call.
+	maxBatchItems = 100
+)
 
 type SetlistService struct {
 	SetlistRepo   repository.SetlistRepository
 	InterludeRepo repository.InterludeRepository
 	SongRepo      repository.SongRepository
+	RevisionRepo  repository.SetlistRevisionRepository
+	UserRepo      repository.UserRepository
 	Cache         *redis.Client
+	Broker        *realtime.Broker
+	// TrashRetention is how long deleted setlists stay in the trash before
+	// RunTrashPurge deletes them for good; zero keeps them until purged.
+	TrashRetention time.Duration
 }
 
 var (
@@ -28,17 +39,25 @@ var (
 	ErrInvalidItemType     = errors.New("invalid item type")
 	ErrSetlistNameRequired = errors.New("setlist name cannot be empty")
 	ErrInvalidColor        = errors.New("invalid color format")
+	ErrInvalidDuration     = errors.New("duration cannot be negative")
 )
 
+// CreateSetlistPayload starts an empty setlist, or a copy of the items of a
+// template when TemplateID is set; the color then defaults to the template's.
 type CreateSetlistPayload struct {
-	Name  string `json:"name"`
-	Color string `json:"color"`
+	Name       string `json:"name"`
+	Color      string `json:"color"`
+	TemplateID *int   `json:"template_id"`
 }
 
+// UpdateSetlistPayload and UpdateOrderPayload take the version the client
+// last read; the change is rejected with a VersionConflictError when the
+// setlist has changed since. Without a version, the last write wins.
 type UpdateSetlistPayload struct {
 	Name       *string `json:"name"`
 	Color      *string `json:"color"`
 	IsArchived *bool   `json:"is_archived"`
+	Version    *int    `json:"version"`
 }
 
 type SetlistDetails struct {
@@ -47,17 +66,37 @@ type SetlistDetails struct {
 }
 
 type AddItemPayload struct {
-	ItemType string `json:"item_type"`
-	ItemID   int    `json:"item_id"`
-	Notes    string `json:"notes"`
+	ItemType        string `json:"item_type"`
+	ItemID          int    `json:"item_id"`
+	Notes           string `json:"notes"`
+	NotesPrivate    bool   `json:"notes_private"`
+	Label           string `json:"label"`
+	DurationSeconds *int   `json:"duration_seconds"`
+	Position        *int   `json:"position"`
+}
+
+// AddItemsPayload adds several items at once; the Position of each item is
+// ignored in favour of the batch's.
+type AddItemsPayload struct {
+	Position *int             `json:"position"`
+	Items    []AddItemPayload `json:"items"`
 }
 
 type UpdateOrderPayload struct {
 	ItemIDs []int `json:"item_ids"`
+	Version *int  `json:"version"`
 }
 
+// UpdateItemPayload changes an item. Every field is optional and kept when
+// omitted: "" clears the notes, and 0 or "" removes the overrides of the
+// song's duration, tempo and key.
 type UpdateItemPayload struct {
-	Notes string `json:"notes"`
+	Notes                     *string `json:"notes"`
+	NotesPrivate              *bool   `json:"notes_private"`
+	TransitionDurationSeconds *int    `json:"transition_duration_seconds"`
+	DurationSeconds           *int    `json:"duration_seconds"`
+	Tempo                     *int    `json:"tempo"`
+	SongKey                   *string `json:"song_key"`
 }
 
 type DuplicateSetlistPayload struct {
@@ -67,7 +106,10 @@ type DuplicateSetlistPayload struct {
 
 var hexColorRegex = regexp.MustCompile(`^#(?:[0-9a-fA-F]{3}){1,2}$`)
 
-func (s SetlistService) Create(ctx context.Context, payload CreateSetlistPayload, bandID int) (model.Setlist, error) {
+func (s SetlistService) Create(ctx context.Context, payload CreateSetlistPayload, bandID int, userID int) (model.Setlist, error) {
+	if payload.TemplateID != nil {
+		return s.createFromTemplate(ctx, payload, bandID, userID)
+	}
 	if payload.Name == "" {
 		return model.Setlist{}, ErrSetlistNameRequired
 	}
@@ -75,20 +117,35 @@ func (s SetlistService) Create(ctx context.Context, payload CreateSetlistPayload
 		return model.Setlist{}, ErrInvalidColor
 	}
 
-	created, err := s.SetlistRepo.CreateSetlist(ctx, s.SetlistRepo.GetDB(), payload.Name, payload.Color, bandID)
+	tx, err := s.SetlistRepo.BeginTx(ctx)
+	if err != nil {
+		return model.Setlist{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	created, err := s.SetlistRepo.CreateSetlist(ctx, tx, payload.Name, payload.Color, bandID, false)
 	if err != nil {
 		return model.Setlist{}, err
 	}
+	if err := s.recordRevision(ctx, tx, created.ID, userID, RevisionActionCreate); err != nil {
+		return model.Setlist{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return model.Setlist{}, err
+	}
 
 	cache.Delete(ctx, s.Cache, cache.SetlistKey(bandID))
 	return created, nil
 }
 
-func (s SetlistService) Update(ctx context.Context, id int, bandID int, payload UpdateSetlistPayload) (model.Setlist, error) {
+func (s SetlistService) Update(ctx context.Context, id int, bandID int, userID int, payload UpdateSetlistPayload) (model.Setlist, error) {
 	setlist, err := s.SetlistRepo.GetSetlistByID(ctx, id, bandID)
 	if err != nil {
 		return model.Setlist{}, mapNotFound(err, ErrSetlistNotFound)
 	}
+	if payload.Version != nil && *payload.Version != setlist.Version {
+		return model.Setlist{}, s.versionConflict(ctx, id, bandID)
+	}
 
 	if payload.Name != nil {
 		if *payload.Name == "" {
@@ -106,84 +163,147 @@ func (s SetlistService) Update(ctx context.Context, id int, bandID int, payload
 		setlist.IsArchived = *payload.IsArchived
 	}
 
-	updated, err := s.SetlistRepo.UpdateSetlist(ctx, setlist)
+	tx, err := s.SetlistRepo.BeginTx(ctx)
+	if err != nil {
+		return model.Setlist{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	updated, err := s.SetlistRepo.UpdateSetlist(ctx, tx, setlist, payload.Version)
 	if err != nil {
+		if isNotFound(err) && payload.Version != nil {
+			return model.Setlist{}, s.versionConflict(ctx, id, bandID)
+		}
+		return model.Setlist{}, err
+	}
+	if err := s.recordRevision(ctx, tx, id, userID, RevisionActionUpdate); err != nil {
+		return model.Setlist{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
 		return model.Setlist{}, err
 	}
 
+	s.publishChange(SetlistEventUpdated, SetlistChange{SetlistID: id, AuthorID: userID, Setlist: &updated})
 	cache.Delete(ctx, s.Cache, cache.SetlistKey(bandID))
 	return updated, nil
 }
 
+// Delete moves the setlist to the trash, from which it can be restored until
+// it is purged.
 func (s SetlistService) Delete(ctx context.Context, setlistID int, bandID int) error {
-	_, err := s.SetlistRepo.GetSetlistByID(ctx, setlistID, bandID)
-	if err != nil {
+	if err := s.SetlistRepo.TrashSetlist(ctx, setlistID, bandID); err != nil {
 		return mapNotFound(err, ErrSetlistNotFound)
 	}
-	if err := s.SetlistRepo.DeleteSetlist(ctx, setlistID, bandID); err != nil {
-		return err
-	}
 
 	cache.Delete(ctx, s.Cache, cache.SetlistKey(bandID))
 	return nil
 }
 
-func (s SetlistService) GetAllForBand(ctx context.Context, bandID int) ([]model.Setlist, error) {
-	key := cache.SetlistKey(bandID)
+func (s SetlistService) GetDetails(ctx context.Context, id int, bandID int) (SetlistDetails, error) {
+	setlist, err := s.SetlistRepo.GetSetlistByID(ctx, id, bandID)
+	if err != nil {
+		return SetlistDetails{}, mapNotFound(err, ErrSetlistNotFound)
+	}
+	items, err := s.SetlistRepo.GetSetlistItemsBySetlistID(ctx, id)
+	if err != nil {
+		return SetlistDetails{}, err
+	}
+	return SetlistDetails{Setlist: setlist, Items: items}, nil
+}
+
+// AddItem inserts an item at payload.Position, counted from 0, or appends it
+// when no position is given.
+func (s SetlistService) AddItem(ctx context.Context, setlistID int, bandID int, userID int, payload AddItemPayload) (model.SetlistItem, error) {
+	created, err := s.addItems(ctx, setlistID, bandID, userID, payload.Position, []AddItemPayload{payload})
+	if err != nil {
+		return model.SetlistItem{}, err
+	}
+
+	s.publishChange(SetlistEventItemAdded, SetlistChange{SetlistID: setlistID, AuthorID: userID, Item: &created[0]})
+	return created[0], nil
+}
 
-	if data, ok := cache.Get(ctx, s.Cache, key); ok {
-		var setlists []model.Setlist
-		if err := json.Unmarshal([]byte(data), &setlists); err == nil {
-			return setlists, nil
-		}
+// AddItems inserts several items, in order, at payload.Position in a single
+// transaction: either every item is added or none is.
+func (s SetlistService) AddItems(ctx context.Context, setlistID int, bandID int, userID int, payload AddItemsPayload) ([]model.SetlistItem, error) {
+	if len(payload.Items) == 0 || len(payload.Items) > maxBatchItems {
+		return nil, &ValidationError{Msg: fmt.Sprintf("Vous pouvez ajouter entre 1 et %d éléments à la fois.", maxBatchItems)}
 	}
 
-	setlists, err := s.SetlistRepo.GetSetlistsByBandID(ctx, bandID)
+	created, err := s.addItems(ctx, setlistID, bandID, userID, payload.Position, payload.Items)
 	if err != nil {
 		return nil, err
 	}
 
-	if data, err := json.Marshal(setlists); err == nil {
-		cache.Set(ctx, s.Cache, key, string(data), setlistCacheTTL)
+	s.publishChange(SetlistEventItemsAdded, SetlistChange{SetlistID: setlistID, AuthorID: userID, Items: created})
+	return created, nil
+}
+
+// addItems inserts the items and records the revision in one transaction,
+// holding the lock on the setlist so concurrent inserts are applied one after
+// the other.
+func (s SetlistService) addItems(ctx context.Context, setlistID int, bandID int, userID int, position *int, payloads []AddItemPayload) ([]model.SetlistItem, error) {
+	if position != nil && *position < 0 {
+		return nil, &ValidationError{Msg: "La position ne peut pas être négative."}
+	}
+	if _, err := s.bandSetlist(ctx, setlistID, bandID); err != nil {
+		return nil, err
 	}
 
-	return setlists, nil
-}
+	items := make([]model.SetlistItem, len(payloads))
+	for i, payload := range payloads {
+		item, err := s.newItem(ctx, setlistID, bandID, payload)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = item
+	}
 
-func (s SetlistService) GetDetails(ctx context.Context, id int, bandID int) (SetlistDetails, error) {
-	setlist, err := s.SetlistRepo.GetSetlistByID(ctx, id, bandID)
+	tx, err := s.SetlistRepo.BeginTx(ctx)
 	if err != nil {
-		return SetlistDetails{}, mapNotFound(err, ErrSetlistNotFound)
+		return nil, err
 	}
-	items, err := s.SetlistRepo.GetSetlistItemsBySetlistID(ctx, id)
+	defer tx.Rollback(ctx)
+
+	if err := s.lockUnlocked(ctx, tx, setlistID); err != nil {
+		return nil, err
+	}
+	created, err := s.SetlistRepo.InsertItems(ctx, tx, setlistID, position, items)
 	if err != nil {
-		return SetlistDetails{}, err
+		return nil, err
 	}
-	return SetlistDetails{Setlist: setlist, Items: items}, nil
-}
-
-func (s SetlistService) AddItem(ctx context.Context, setlistID int, bandID int, payload AddItemPayload) (model.SetlistItem, error) {
-	if _, err := s.SetlistRepo.GetSetlistByID(ctx, setlistID, bandID); err != nil {
-		return model.SetlistItem{}, mapNotFound(err, ErrSetlistNotFound)
+	if err := s.recordRevision(ctx, tx, setlistID, userID, RevisionActionAddItem); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
 	}
+	cache.Delete(ctx, s.Cache, cache.SetlistKey(bandID))
+	return created, nil
+}
 
+// newItem builds the item described by the payload after checking that the
+// song or interlude it refers to belongs to the band.
+func (s SetlistService) newItem(ctx context.Context, setlistID int, bandID int, payload AddItemPayload) (model.SetlistItem, error) {
 	var notes *string
 	if payload.Notes != "" {
 		notes = &payload.Notes
 	}
 	item := model.SetlistItem{
-		SetlistID: setlistID,
-		ItemType:  payload.ItemType,
-		Notes:     notes,
+		SetlistID:    setlistID,
+		ItemType:     payload.ItemType,
+		Notes:        notes,
+		NotesPrivate: payload.NotesPrivate,
 	}
 
-	if payload.ItemType == "song" {
+	switch payload.ItemType {
+	case model.ItemTypeSong:
 		itemID := int32(payload.ItemID)
 		item.SongID = &itemID
 		if _, err := s.SongRepo.GetSongByID(ctx, payload.ItemID, bandID); err != nil {
 			return model.SetlistItem{}, mapNotFound(err, ErrItemNotFound)
 		}
-	} else if payload.ItemType == "interlude" {
+	case model.ItemTypeInterlude:
 		itemID := int32(payload.ItemID)
 		item.InterludeID = &itemID
 		interlude, err := s.InterludeRepo.GetInterludeByID(ctx, payload.ItemID, bandID)
@@ -191,42 +311,215 @@ func (s SetlistService) AddItem(ctx context.Context, setlistID int, bandID int,
 			return model.SetlistItem{}, mapNotFound(err, ErrItemNotFound)
 		}
 		item.Notes = interlude.Script
-	} else {
+	case model.ItemTypeSetBreak, model.ItemTypeEncore, model.ItemTypeIntermission, model.ItemTypePlaceholder:
+		if payload.Label != "" {
+			item.Label = &payload.Label
+		}
+		hasDuration := payload.ItemType == model.ItemTypeIntermission || payload.ItemType == model.ItemTypePlaceholder
+		if hasDuration && payload.DurationSeconds != nil {
+			if *payload.DurationSeconds < 0 {
+				return model.SetlistItem{}, ErrInvalidDuration
+			}
+			item.ItemDurationSeconds = ptrInt32(payload.DurationSeconds)
+		}
+	default:
 		return model.SetlistItem{}, ErrInvalidItemType
 	}
-	return s.SetlistRepo.AddItemToSetlist(ctx, item)
+	return item, nil
 }
 
-func (s SetlistService) UpdateOrder(ctx context.Context, setlistID int, bandID int, payload UpdateOrderPayload) error {
-	if _, err := s.SetlistRepo.GetSetlistByID(ctx, setlistID, bandID); err != nil {
-		return mapNotFound(err, ErrSetlistNotFound)
+// UpdateOrder renumbers the items of a setlist and returns its new version.
+// The payload must list every item of the setlist exactly once; a list built
+// from a stale view of the setlist is reported as a VersionConflictError.
+// Grouped items are kept together, where the first of them is placed. An
+// empty list changes nothing and returns the current version.
+func (s SetlistService) UpdateOrder(ctx context.Context, setlistID int, bandID int, userID int, payload UpdateOrderPayload) (int, error) {
+	setlist, err := s.bandSetlist(ctx, setlistID, bandID)
+	if err != nil {
+		return 0, err
 	}
 	if len(payload.ItemIDs) == 0 {
-		return nil
+		return setlist.Version, nil
+	}
+	seen := make(map[int]bool, len(payload.ItemIDs))
+	for _, id := range payload.ItemIDs {
+		if seen[id] {
+			return 0, &ValidationError{Msg: "Un élément apparaît plusieurs fois dans le nouvel ordre."}
+		}
+		seen[id] = true
 	}
-	return s.SetlistRepo.UpdateItemOrder(ctx, setlistID, payload.ItemIDs)
+
+	tx, err := s.SetlistRepo.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := s.lockUnlocked(ctx, tx, setlistID); err != nil {
+		return 0, err
+	}
+	items, err := s.SetlistRepo.GetItems(ctx, tx, setlistID)
+	if err != nil {
+		return 0, err
+	}
+	itemIDs := keepGroupsTogether(items, payload.ItemIDs)
+
+	version, err := s.SetlistRepo.UpdateItemOrder(ctx, tx, setlistID, itemIDs, payload.Version)
+	if errors.Is(err, repository.ErrVersionConflict) || errors.Is(err, repository.ErrItemSetMismatch) {
+		return 0, s.versionConflict(ctx, setlistID, bandID)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := s.recordRevision(ctx, tx, setlistID, userID, RevisionActionUpdateOrder); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	s.publishChange(SetlistEventItemsReordered, SetlistChange{SetlistID: setlistID, AuthorID: userID, ItemIDs: itemIDs})
+	cache.Delete(ctx, s.Cache, cache.SetlistKey(bandID))
+	return version, nil
 }
 
-func (s SetlistService) UpdateItem(ctx context.Context, itemID int, bandID int, payload UpdateItemPayload) (model.SetlistItem, error) {
-	var notes *string
-	if payload.Notes != "" {
-		notes = &payload.Notes
+// versionConflict reports a stale write along with the current state of the
+// setlist.
+func (s SetlistService) versionConflict(ctx context.Context, setlistID int, bandID int) error {
+	current, err := s.GetDetails(ctx, setlistID, bandID)
+	if err != nil {
+		return err
+	}
+	return &VersionConflictError{Current: current}
+}
+
+func (s SetlistService) UpdateItem(ctx context.Context, itemID int, bandID int, userID int, payload UpdateItemPayload) (model.SetlistItem, error) {
+	for _, seconds := range []*int{payload.TransitionDurationSeconds, payload.DurationSeconds} {
+		if seconds != nil && *seconds < 0 {
+			return model.SetlistItem{}, ErrInvalidDuration
+		}
+	}
+	if payload.Tempo != nil && *payload.Tempo < 0 {
+		return model.SetlistItem{}, &ValidationError{Msg: "Le tempo ne peut pas être négatif."}
+	}
+	if payload.SongKey != nil && len(*payload.SongKey) > 10 {
+		return model.SetlistItem{}, &ValidationError{Msg: "La tonalité ne peut pas dépasser 10 caractères."}
+	}
+	setlistID, err := s.itemSetlistID(ctx, itemID, bandID)
+	if err != nil {
+		return model.SetlistItem{}, err
+	}
+
+	update := repository.SetlistItemUpdate{
+		Notes:                     payload.Notes,
+		NotesPrivate:              payload.NotesPrivate,
+		TransitionDurationSeconds: payload.TransitionDurationSeconds,
+		DurationSeconds:           ptrInt32(payload.DurationSeconds),
+		Tempo:                     ptrInt32(payload.Tempo),
+		SongKey:                   payload.SongKey,
 	}
-	item, err := s.SetlistRepo.UpdateSetlistItem(ctx, itemID, bandID, notes)
+
+	tx, err := s.SetlistRepo.BeginTx(ctx)
+	if err != nil {
+		return model.SetlistItem{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := s.lockUnlocked(ctx, tx, setlistID); err != nil {
+		return model.SetlistItem{}, err
+	}
+	item, err := s.SetlistRepo.UpdateSetlistItem(ctx, tx, itemID, setlistID, bandID, update)
 	if err != nil {
 		return model.SetlistItem{}, mapNotFound(err, ErrItemNotFound)
 	}
+	if err := s.recordRevision(ctx, tx, setlistID, userID, RevisionActionUpdateItem); err != nil {
+		return model.SetlistItem{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return model.SetlistItem{}, err
+	}
+
+	s.publishChange(SetlistEventItemUpdated, SetlistChange{SetlistID: setlistID, AuthorID: userID, Item: &item})
+	cache.Delete(ctx, s.Cache, cache.SetlistKey(bandID))
 	return item, nil
 }
 
-func (s SetlistService) DeleteItem(ctx context.Context, itemID int, bandID int) error {
-	if err := s.SetlistRepo.DeleteSetlistItem(ctx, itemID, bandID); err != nil {
+func (s SetlistService) DeleteItem(ctx context.Context, itemID int, bandID int, userID int) error {
+	setlistID, err := s.itemSetlistID(ctx, itemID, bandID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.SetlistRepo.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := s.lockUnlocked(ctx, tx, setlistID); err != nil {
+		return err
+	}
+	if err := s.SetlistRepo.DeleteSetlistItem(ctx, tx, itemID, setlistID); err != nil {
 		return mapNotFound(err, ErrItemNotFound)
 	}
+	if err := s.recordRevision(ctx, tx, setlistID, userID, RevisionActionDeleteItem); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	s.publishChange(SetlistEventItemRemoved, SetlistChange{SetlistID: setlistID, AuthorID: userID, ItemID: &itemID})
+	cache.Delete(ctx, s.Cache, cache.SetlistKey(bandID))
 	return nil
 }
 
-func (s SetlistService) Duplicate(ctx context.Context, originalSetlistID int, bandID int, newName, newColor string) (model.Setlist, error) {
+// PositionRepair lists the setlists whose item positions were renumbered.
+type PositionRepair struct {
+	RepairedSetlistIDs []int `json:"repaired_setlist_ids"`
+}
+
+// RepairPositions renumbers the items of every setlist of the band without
+// gaps or duplicates, e.g. after deletions made before positions were kept
+// contiguous. The order of the items does not change.
+func (s SetlistService) RepairPositions(ctx context.Context, bandID int, userID int) (PositionRepair, error) {
+	tx, err := s.SetlistRepo.BeginTx(ctx)
+	if err != nil {
+		return PositionRepair{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	repaired, err := s.SetlistRepo.RepairPositions(ctx, tx, bandID)
+	if err != nil {
+		return PositionRepair{}, err
+	}
+	for _, setlistID := range repaired {
+		if err := s.recordRevision(ctx, tx, setlistID, userID, RevisionActionRepair); err != nil {
+			return PositionRepair{}, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return PositionRepair{}, err
+	}
+
+	for _, setlistID := range repaired {
+		items, err := s.SetlistRepo.GetSetlistItemsBySetlistID(ctx, setlistID)
+		if err != nil {
+			continue
+		}
+		itemIDs := make([]int, len(items))
+		for i, item := range items {
+			itemIDs[i] = item.ID
+		}
+		s.publishChange(SetlistEventItemsReordered, SetlistChange{SetlistID: setlistID, AuthorID: userID, ItemIDs: itemIDs})
+	}
+	if len(repaired) > 0 {
+		cache.Delete(ctx, s.Cache, cache.SetlistKey(bandID))
+	}
+	return PositionRepair{RepairedSetlistIDs: repaired}, nil
+}
+
+func (s SetlistService) Duplicate(ctx context.Context, originalSetlistID int, bandID int, userID int, newName, newColor string) (model.Setlist, error) {
 	if newName == "" {
 		return model.Setlist{}, ErrSetlistNameRequired
 	}
@@ -240,7 +533,7 @@ func (s SetlistService) Duplicate(ctx context.Context, originalSetlistID int, ba
 	}
 	defer tx.Rollback(ctx)
 
-	_, err = s.SetlistRepo.GetSetlistByID(ctx, originalSetlistID, bandID)
+	original, err := s.SetlistRepo.GetSetlistByID(ctx, originalSetlistID, bandID)
 	if err != nil {
 		return model.Setlist{}, mapNotFound(err, ErrSetlistNotFound)
 	}
@@ -250,7 +543,7 @@ func (s SetlistService) Duplicate(ctx context.Context, originalSetlistID int, ba
 		return model.Setlist{}, err
 	}
 
-	newSetlist, err := s.SetlistRepo.CreateSetlist(ctx, tx, newName, newColor, bandID)
+	newSetlist, err := s.SetlistRepo.CreateSetlist(ctx, tx, newName, newColor, bandID, original.IsTemplate)
 	if err != nil {
 		return model.Setlist{}, err
 	}
@@ -258,6 +551,9 @@ func (s SetlistService) Duplicate(ctx context.Context, originalSetlistID int, ba
 	if err := s.SetlistRepo.CopyItemsToNewSetlist(ctx, tx, newSetlist.ID, originalItems); err != nil {
 		return model.Setlist{}, err
 	}
+	if err := s.recordRevision(ctx, tx, newSetlist.ID, userID, RevisionActionDuplicate); err != nil {
+		return model.Setlist{}, err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return model.Setlist{}, err
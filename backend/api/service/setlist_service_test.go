@@ -3,23 +3,33 @@ package service
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"reflect"
+	"slices"
 	"testing"
 
 	"setlist/api/model"
+	"setlist/api/repository"
 	"setlist/api/repository/mocks"
+	"setlist/realtime"
 
 	"github.com/jackc/pgx/v5"
 	"go.uber.org/mock/gomock"
 )
 
+// userID is the band member performing the changes in these tests.
+const userID = 2
+
 func TestSetlistService_Create(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSetlistRepository(ctrl)
+	mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+	mockTx := mocks.NewMockTx(ctrl)
 	// We don't need InterludeRepo for Create
-	svc := SetlistService{SetlistRepo: mockRepo}
+	svc := SetlistService{SetlistRepo: mockRepo, RevisionRepo: mockRevisionRepo}
 	ctx := context.Background()
 	bandID := 1
 
@@ -29,21 +39,25 @@ func TestSetlistService_Create(t *testing.T) {
 	}
 
 	expectedSetlist := model.Setlist{
+		ID:     10,
 		BandID: bandID,
 		Name:   payload.Name,
 		Color:  payload.Color,
 	}
 
-	// Expect GetDB to be called. We can return nil because the mock implementation of CreateSetlist
-	// doesn't actually use the DB connection, it just verifies arguments.
-	mockRepo.EXPECT().GetDB().Return(nil)
+	mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+	mockTx.EXPECT().Rollback(ctx).Return(nil)
 
-	// Expect CreateSetlist with the nil DB
+	// Expect CreateSetlist within TX
 	mockRepo.EXPECT().
-		CreateSetlist(ctx, nil, payload.Name, payload.Color, bandID).
+		CreateSetlist(ctx, mockTx, payload.Name, payload.Color, bandID, false).
 		Return(expectedSetlist, nil)
 
-	created, err := svc.Create(ctx, payload, bandID)
+	// The new setlist starts its history in the same TX.
+	mockRevisionRepo.EXPECT().Record(ctx, mockTx, expectedSetlist.ID, userID, RevisionActionCreate).Return(nil)
+	mockTx.EXPECT().Commit(ctx).Return(nil)
+
+	created, err := svc.Create(ctx, payload, bandID, userID)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -57,7 +71,9 @@ func TestSetlistService_Update(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockSetlistRepository(ctrl)
-	svc := SetlistService{SetlistRepo: mockRepo}
+	mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+	mockTx := mocks.NewMockTx(ctrl)
+	svc := SetlistService{SetlistRepo: mockRepo, RevisionRepo: mockRevisionRepo}
 	ctx := context.Background()
 
 	setlistID := 10
@@ -74,9 +90,13 @@ func TestSetlistService_Update(t *testing.T) {
 	updatedExpected := existingSetlist
 	updatedExpected.Name = newName
 
-	mockRepo.EXPECT().UpdateSetlist(ctx, updatedExpected).Return(updatedExpected, nil)
+	mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+	mockTx.EXPECT().Rollback(ctx).Return(nil)
+	mockRepo.EXPECT().UpdateSetlist(ctx, mockTx, updatedExpected, gomock.Nil()).Return(updatedExpected, nil)
+	mockRevisionRepo.EXPECT().Record(ctx, mockTx, setlistID, userID, RevisionActionUpdate).Return(nil)
+	mockTx.EXPECT().Commit(ctx).Return(nil)
 
-	updated, err := svc.Update(ctx, setlistID, bandID, payload)
+	updated, err := svc.Update(ctx, setlistID, bandID, userID, payload)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -92,8 +112,9 @@ func TestSetlistService_Duplicate(t *testing.T) {
 	mockRepo := mocks.NewMockSetlistRepository(ctrl)
 	// Mock Tx is generated in pgx_tx.go (package mocks)
 	mockTx := mocks.NewMockTx(ctrl)
+	mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
 
-	svc := SetlistService{SetlistRepo: mockRepo}
+	svc := SetlistService{SetlistRepo: mockRepo, RevisionRepo: mockRevisionRepo}
 	ctx := context.Background()
 
 	originalID := 10
@@ -116,15 +137,18 @@ func TestSetlistService_Duplicate(t *testing.T) {
 
 	// Expect Create new setlist within TX
 	newSetlist := model.Setlist{ID: 20, Name: newName, Color: newColor}
-	mockRepo.EXPECT().CreateSetlist(ctx, mockTx, newName, newColor, bandID).Return(newSetlist, nil)
+	mockRepo.EXPECT().CreateSetlist(ctx, mockTx, newName, newColor, bandID, false).Return(newSetlist, nil)
 
 	// Expect Copy items within TX
 	mockRepo.EXPECT().CopyItemsToNewSetlist(ctx, mockTx, newSetlist.ID, items).Return(nil)
 
+	// Expect the copy to start its own history within TX
+	mockRevisionRepo.EXPECT().Record(ctx, mockTx, newSetlist.ID, userID, RevisionActionDuplicate).Return(nil)
+
 	// Expect Commit
 	mockTx.EXPECT().Commit(ctx).Return(nil)
 
-	result, err := svc.Duplicate(ctx, originalID, bandID, newName, newColor)
+	result, err := svc.Duplicate(ctx, originalID, bandID, userID, newName, newColor)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -148,9 +172,9 @@ func TestSetlistService_AddItem(t *testing.T) {
 
 		// Setlist does not belong to the band: repo returns no row.
 		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(model.Setlist{}, pgx.ErrNoRows)
-		// No AddItemToSetlist call expected.
+		// No InsertItems call expected.
 
-		_, err := svc.AddItem(ctx, setlistID, bandID, AddItemPayload{ItemType: "song", ItemID: 5})
+		_, err := svc.AddItem(ctx, setlistID, bandID, userID, AddItemPayload{ItemType: "song", ItemID: 5})
 		if !errors.Is(err, ErrSetlistNotFound) {
 			t.Fatalf("expected ErrSetlistNotFound, got %v", err)
 		}
@@ -167,7 +191,7 @@ func TestSetlistService_AddItem(t *testing.T) {
 		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(ownedSetlist, nil)
 		mockSongRepo.EXPECT().GetSongByID(ctx, 5, bandID).Return(model.Song{}, pgx.ErrNoRows)
 
-		_, err := svc.AddItem(ctx, setlistID, bandID, AddItemPayload{ItemType: "song", ItemID: 5})
+		_, err := svc.AddItem(ctx, setlistID, bandID, userID, AddItemPayload{ItemType: "song", ItemID: 5})
 		if !errors.Is(err, ErrItemNotFound) {
 			t.Fatalf("expected ErrItemNotFound, got %v", err)
 		}
@@ -184,7 +208,7 @@ func TestSetlistService_AddItem(t *testing.T) {
 		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(ownedSetlist, nil)
 		mockInterludeRepo.EXPECT().GetInterludeByID(ctx, 7, bandID).Return(model.Interlude{}, pgx.ErrNoRows)
 
-		_, err := svc.AddItem(ctx, setlistID, bandID, AddItemPayload{ItemType: "interlude", ItemID: 7})
+		_, err := svc.AddItem(ctx, setlistID, bandID, userID, AddItemPayload{ItemType: "interlude", ItemID: 7})
 		if !errors.Is(err, ErrItemNotFound) {
 			t.Fatalf("expected ErrItemNotFound, got %v", err)
 		}
@@ -199,7 +223,7 @@ func TestSetlistService_AddItem(t *testing.T) {
 
 		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(ownedSetlist, nil)
 
-		_, err := svc.AddItem(ctx, setlistID, bandID, AddItemPayload{ItemType: "video", ItemID: 5})
+		_, err := svc.AddItem(ctx, setlistID, bandID, userID, AddItemPayload{ItemType: "video", ItemID: 5})
 		if !errors.Is(err, ErrInvalidItemType) {
 			t.Fatalf("expected ErrInvalidItemType, got %v", err)
 		}
@@ -210,21 +234,29 @@ func TestSetlistService_AddItem(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
 		mockSongRepo := mocks.NewMockSongRepository(ctrl)
-		svc := SetlistService{SetlistRepo: mockRepo, SongRepo: mockSongRepo}
+		mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo, SongRepo: mockSongRepo, RevisionRepo: mockRevisionRepo}
 
 		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(ownedSetlist, nil)
 		mockSongRepo.EXPECT().GetSongByID(ctx, 5, bandID).Return(model.Song{ID: 5, BandID: bandID}, nil)
-		mockRepo.EXPECT().AddItemToSetlist(ctx, gomock.Any()).DoAndReturn(
-			func(_ context.Context, item model.SetlistItem) (model.SetlistItem, error) {
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().LockSetlists(ctx, mockTx, setlistID).Return(nil)
+		mockRepo.EXPECT().InsertItems(ctx, mockTx, setlistID, gomock.Nil(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ repository.DBTX, _ int, _ *int, items []model.SetlistItem) ([]model.SetlistItem, error) {
+				item := items[0]
 				if item.SetlistID != setlistID || item.ItemType != "song" || item.SongID == nil || *item.SongID != 5 {
 					t.Errorf("unexpected item passed to repo: %+v", item)
 				}
 				item.ID = 1
-				return item, nil
+				return []model.SetlistItem{item}, nil
 			})
+		mockRevisionRepo.EXPECT().Record(ctx, mockTx, setlistID, userID, RevisionActionAddItem).Return(nil)
+		mockTx.EXPECT().Commit(ctx).Return(nil)
 
-		created, err := svc.AddItem(ctx, setlistID, bandID, AddItemPayload{ItemType: "song", ItemID: 5})
+		created, err := svc.AddItem(ctx, setlistID, bandID, userID, AddItemPayload{ItemType: "song", ItemID: 5})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -238,25 +270,83 @@ func TestSetlistService_AddItem(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
 		mockInterludeRepo := mocks.NewMockInterludeRepository(ctrl)
-		svc := SetlistService{SetlistRepo: mockRepo, InterludeRepo: mockInterludeRepo}
+		mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo, InterludeRepo: mockInterludeRepo, RevisionRepo: mockRevisionRepo}
 
 		script := "Talk to the crowd"
 		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(ownedSetlist, nil)
 		mockInterludeRepo.EXPECT().GetInterludeByID(ctx, 7, bandID).Return(model.Interlude{ID: 7, BandID: bandID, Script: &script}, nil)
-		mockRepo.EXPECT().AddItemToSetlist(ctx, gomock.Any()).DoAndReturn(
-			func(_ context.Context, item model.SetlistItem) (model.SetlistItem, error) {
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().LockSetlists(ctx, mockTx, setlistID).Return(nil)
+		mockRepo.EXPECT().InsertItems(ctx, mockTx, setlistID, gomock.Nil(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ repository.DBTX, _ int, _ *int, items []model.SetlistItem) ([]model.SetlistItem, error) {
+				item := items[0]
 				if item.InterludeID == nil || *item.InterludeID != 7 || item.Notes == nil || *item.Notes != script {
 					t.Errorf("unexpected item passed to repo: %+v", item)
 				}
-				return item, nil
+				return []model.SetlistItem{item}, nil
 			})
+		mockRevisionRepo.EXPECT().Record(ctx, mockTx, setlistID, userID, RevisionActionAddItem).Return(nil)
+		mockTx.EXPECT().Commit(ctx).Return(nil)
 
-		_, err := svc.AddItem(ctx, setlistID, bandID, AddItemPayload{ItemType: "interlude", ItemID: 7})
+		_, err := svc.AddItem(ctx, setlistID, bandID, userID, AddItemPayload{ItemType: "interlude", ItemID: 7})
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
+
+	t.Run("adds an intermission with its own duration", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
+		mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo, RevisionRepo: mockRevisionRepo}
+
+		duration := 900
+		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(ownedSetlist, nil)
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().LockSetlists(ctx, mockTx, setlistID).Return(nil)
+		mockRepo.EXPECT().InsertItems(ctx, mockTx, setlistID, gomock.Nil(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ repository.DBTX, _ int, _ *int, items []model.SetlistItem) ([]model.SetlistItem, error) {
+				item := items[0]
+				if item.SongID != nil || item.InterludeID != nil {
+					t.Errorf("expected a section marker without song or interlude: %+v", item)
+				}
+				if item.Label == nil || *item.Label != "Entracte" || item.ItemDurationSeconds == nil || *item.ItemDurationSeconds != 900 {
+					t.Errorf("unexpected item passed to repo: %+v", item)
+				}
+				return []model.SetlistItem{item}, nil
+			})
+		mockRevisionRepo.EXPECT().Record(ctx, mockTx, setlistID, userID, RevisionActionAddItem).Return(nil)
+		mockTx.EXPECT().Commit(ctx).Return(nil)
+
+		payload := AddItemPayload{ItemType: "intermission", Label: "Entracte", DurationSeconds: &duration}
+		if _, err := svc.AddItem(ctx, setlistID, bandID, userID, payload); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("rejects a negative intermission duration", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo}
+
+		duration := -10
+		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(ownedSetlist, nil)
+
+		_, err := svc.AddItem(ctx, setlistID, bandID, userID, AddItemPayload{ItemType: "intermission", DurationSeconds: &duration})
+		if !errors.Is(err, ErrInvalidDuration) {
+			t.Fatalf("expected ErrInvalidDuration, got %v", err)
+		}
+	})
 }
 
 func TestSetlistService_UpdateOrder(t *testing.T) {
@@ -274,7 +364,7 @@ func TestSetlistService_UpdateOrder(t *testing.T) {
 		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(model.Setlist{}, pgx.ErrNoRows)
 		// No UpdateItemOrder call expected.
 
-		err := svc.UpdateOrder(ctx, setlistID, bandID, UpdateOrderPayload{ItemIDs: []int{3, 1, 2}})
+		_, err := svc.UpdateOrder(ctx, setlistID, bandID, userID, UpdateOrderPayload{ItemIDs: []int{3, 1, 2}})
 		if !errors.Is(err, ErrSetlistNotFound) {
 			t.Fatalf("expected ErrSetlistNotFound, got %v", err)
 		}
@@ -285,16 +375,157 @@ func TestSetlistService_UpdateOrder(t *testing.T) {
 		defer ctrl.Finish()
 
 		mockRepo := mocks.NewMockSetlistRepository(ctrl)
-		svc := SetlistService{SetlistRepo: mockRepo}
+		mockTx := mocks.NewMockTx(ctrl)
+		mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo, RevisionRepo: mockRevisionRepo}
 
 		itemIDs := []int{3, 1, 2}
 		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(model.Setlist{ID: setlistID, BandID: bandID}, nil)
-		mockRepo.EXPECT().UpdateItemOrder(ctx, setlistID, itemIDs).Return(nil)
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().LockSetlists(ctx, mockTx, setlistID).Return(nil)
+		mockRepo.EXPECT().GetItems(ctx, mockTx, setlistID).Return([]model.SetlistItem{{ID: 1}, {ID: 2}, {ID: 3}}, nil)
+		mockRepo.EXPECT().UpdateItemOrder(ctx, mockTx, setlistID, itemIDs, gomock.Nil()).Return(2, nil)
+		mockRevisionRepo.EXPECT().Record(ctx, mockTx, setlistID, userID, RevisionActionUpdateOrder).Return(nil)
+		mockTx.EXPECT().Commit(ctx).Return(nil)
 
-		if err := svc.UpdateOrder(ctx, setlistID, bandID, UpdateOrderPayload{ItemIDs: itemIDs}); err != nil {
+		if _, err := svc.UpdateOrder(ctx, setlistID, bandID, userID, UpdateOrderPayload{ItemIDs: itemIDs}); err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
 	})
+
+	t.Run("publishes the new order to editors", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		broker := realtime.NewBroker(nil)
+		events, unsubscribe := broker.Subscribe(realtime.SetlistTopic(setlistID))
+		defer unsubscribe()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
+		mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo, RevisionRepo: mockRevisionRepo, Broker: broker}
+
+		itemIDs := []int{3, 1, 2}
+		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(model.Setlist{ID: setlistID, BandID: bandID}, nil)
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().LockSetlists(ctx, mockTx, setlistID).Return(nil)
+		mockRepo.EXPECT().GetItems(ctx, mockTx, setlistID).Return([]model.SetlistItem{{ID: 1}, {ID: 2}, {ID: 3}}, nil)
+		mockRepo.EXPECT().UpdateItemOrder(ctx, mockTx, setlistID, itemIDs, gomock.Nil()).Return(2, nil)
+		mockRevisionRepo.EXPECT().Record(ctx, mockTx, setlistID, userID, RevisionActionUpdateOrder).Return(nil)
+		mockTx.EXPECT().Commit(ctx).Return(nil)
+
+		if _, err := svc.UpdateOrder(ctx, setlistID, bandID, userID, UpdateOrderPayload{ItemIDs: itemIDs}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		select {
+		case event := <-events:
+			var change SetlistChange
+			if err := json.Unmarshal(event.Payload, &change); err != nil {
+				t.Fatalf("invalid payload: %v", err)
+			}
+			if event.Type != SetlistEventItemsReordered || change.AuthorID != userID || !reflect.DeepEqual(change.ItemIDs, itemIDs) {
+				t.Errorf("unexpected event %q: %+v", event.Type, change)
+			}
+		default:
+			t.Fatal("expected an event to be published")
+		}
+	})
+
+	t.Run("moves grouped items together", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
+		mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo, RevisionRepo: mockRevisionRepo}
+
+		groupID := 7
+		current := []model.SetlistItem{{ID: 1}, {ID: 2, GroupID: &groupID}, {ID: 3, GroupID: &groupID}, {ID: 4}}
+		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(model.Setlist{ID: setlistID, BandID: bandID}, nil)
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().LockSetlists(ctx, mockTx, setlistID).Return(nil)
+		mockRepo.EXPECT().GetItems(ctx, mockTx, setlistID).Return(current, nil)
+		mockRepo.EXPECT().UpdateItemOrder(ctx, mockTx, setlistID, []int{3, 2, 1, 4}, gomock.Nil()).Return(2, nil)
+		mockRevisionRepo.EXPECT().Record(ctx, mockTx, setlistID, userID, RevisionActionUpdateOrder).Return(nil)
+		mockTx.EXPECT().Commit(ctx).Return(nil)
+
+		if _, err := svc.UpdateOrder(ctx, setlistID, bandID, userID, UpdateOrderPayload{ItemIDs: []int{3, 1, 4, 2}}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("leaves the setlist unchanged for an empty order", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo}
+
+		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(model.Setlist{ID: setlistID, BandID: bandID, Version: 4}, nil)
+		// No transaction nor UpdateItemOrder call expected.
+
+		version, err := svc.UpdateOrder(ctx, setlistID, bandID, userID, UpdateOrderPayload{ItemIDs: []int{}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if version != 4 {
+			t.Errorf("expected the current version 4, got %d", version)
+		}
+	})
+
+	t.Run("rejects duplicated items", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo}
+
+		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(model.Setlist{ID: setlistID, BandID: bandID}, nil)
+
+		_, err := svc.UpdateOrder(ctx, setlistID, bandID, userID, UpdateOrderPayload{ItemIDs: []int{1, 2, 1}})
+		var ve *ValidationError
+		if !errors.As(err, &ve) {
+			t.Fatalf("expected a ValidationError, got %v", err)
+		}
+	})
+
+	for _, repoErr := range []error{repository.ErrVersionConflict, repository.ErrItemSetMismatch} {
+		t.Run("returns the current setlist on "+repoErr.Error(), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockSetlistRepository(ctrl)
+			mockTx := mocks.NewMockTx(ctrl)
+			svc := SetlistService{SetlistRepo: mockRepo}
+
+			stale := 3
+			itemIDs := []int{2, 1}
+			current := []model.SetlistItem{{ID: 1}, {ID: 2}, {ID: 3}}
+			mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(model.Setlist{ID: setlistID, BandID: bandID, Version: 4}, nil).Times(2)
+			mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+			mockTx.EXPECT().Rollback(ctx).Return(nil)
+			mockRepo.EXPECT().LockSetlists(ctx, mockTx, setlistID).Return(nil)
+			mockRepo.EXPECT().GetItems(ctx, mockTx, setlistID).Return(current, nil)
+			mockRepo.EXPECT().UpdateItemOrder(ctx, mockTx, setlistID, itemIDs, &stale).Return(0, repoErr)
+			mockRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, setlistID).Return(current, nil)
+
+			_, err := svc.UpdateOrder(ctx, setlistID, bandID, userID, UpdateOrderPayload{ItemIDs: itemIDs, Version: &stale})
+			var conflict *VersionConflictError
+			if !errors.As(err, &conflict) {
+				t.Fatalf("expected a VersionConflictError, got %v", err)
+			}
+			details, ok := conflict.Current.(SetlistDetails)
+			if !ok || details.Version != 4 || len(details.Items) != 3 {
+				t.Errorf("expected the current setlist, got %+v", conflict.Current)
+			}
+		})
+	}
 }
 
 func TestSetlistService_Create_Validation(t *testing.T) {
@@ -306,14 +537,14 @@ func TestSetlistService_Create_Validation(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("rejects empty name", func(t *testing.T) {
-		_, err := svc.Create(ctx, CreateSetlistPayload{Name: "", Color: "#FF0000"}, 1)
+		_, err := svc.Create(ctx, CreateSetlistPayload{Name: "", Color: "#FF0000"}, 1, userID)
 		if !errors.Is(err, ErrSetlistNameRequired) {
 			t.Fatalf("expected ErrSetlistNameRequired, got %v", err)
 		}
 	})
 
 	t.Run("rejects invalid color", func(t *testing.T) {
-		_, err := svc.Create(ctx, CreateSetlistPayload{Name: "My Setlist", Color: "red"}, 1)
+		_, err := svc.Create(ctx, CreateSetlistPayload{Name: "My Setlist", Color: "red"}, 1, userID)
 		if !errors.Is(err, ErrInvalidColor) {
 			t.Fatalf("expected ErrInvalidColor, got %v", err)
 		}
@@ -325,6 +556,26 @@ func TestSetlistService_Update_Errors(t *testing.T) {
 	setlistID := 10
 	bandID := 1
 
+	t.Run("rejects a stale version", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo}
+
+		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(model.Setlist{ID: setlistID, BandID: bandID, Version: 5}, nil).Times(2)
+		mockRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, setlistID).Return([]model.SetlistItem{}, nil)
+		// No UpdateSetlist call expected.
+
+		newName := "New Name"
+		stale := 4
+		_, err := svc.Update(ctx, setlistID, bandID, userID, UpdateSetlistPayload{Name: &newName, Version: &stale})
+		var conflict *VersionConflictError
+		if !errors.As(err, &conflict) {
+			t.Fatalf("expected a VersionConflictError, got %v", err)
+		}
+	})
+
 	t.Run("returns ErrSetlistNotFound when setlist is not in band", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -335,7 +586,7 @@ func TestSetlistService_Update_Errors(t *testing.T) {
 		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(model.Setlist{}, pgx.ErrNoRows)
 
 		newName := "New Name"
-		_, err := svc.Update(ctx, setlistID, bandID, UpdateSetlistPayload{Name: &newName})
+		_, err := svc.Update(ctx, setlistID, bandID, userID, UpdateSetlistPayload{Name: &newName})
 		if !errors.Is(err, ErrSetlistNotFound) {
 			t.Fatalf("expected ErrSetlistNotFound, got %v", err)
 		}
@@ -351,7 +602,7 @@ func TestSetlistService_Update_Errors(t *testing.T) {
 		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(model.Setlist{ID: setlistID, BandID: bandID}, nil)
 
 		emptyName := ""
-		_, err := svc.Update(ctx, setlistID, bandID, UpdateSetlistPayload{Name: &emptyName})
+		_, err := svc.Update(ctx, setlistID, bandID, userID, UpdateSetlistPayload{Name: &emptyName})
 		if !errors.Is(err, ErrSetlistNameRequired) {
 			t.Fatalf("expected ErrSetlistNameRequired, got %v", err)
 		}
@@ -368,7 +619,7 @@ func TestSetlistService_Update_Errors(t *testing.T) {
 		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(model.Setlist{}, dbErr)
 
 		newName := "New Name"
-		_, err := svc.Update(ctx, setlistID, bandID, UpdateSetlistPayload{Name: &newName})
+		_, err := svc.Update(ctx, setlistID, bandID, userID, UpdateSetlistPayload{Name: &newName})
 		if !errors.Is(err, dbErr) {
 			t.Fatalf("expected raw db error, got %v", err)
 		}
@@ -383,7 +634,7 @@ func TestSetlistService_Delete_NotFound(t *testing.T) {
 	svc := SetlistService{SetlistRepo: mockRepo}
 	ctx := context.Background()
 
-	mockRepo.EXPECT().GetSetlistByID(ctx, 10, 1).Return(model.Setlist{}, pgx.ErrNoRows)
+	mockRepo.EXPECT().TrashSetlist(ctx, 10, 1).Return(sql.ErrNoRows)
 
 	if err := svc.Delete(ctx, 10, 1); !errors.Is(err, ErrSetlistNotFound) {
 		t.Fatalf("expected ErrSetlistNotFound, got %v", err)
@@ -410,7 +661,7 @@ func TestSetlistService_Duplicate_Errors(t *testing.T) {
 
 	t.Run("rejects empty name", func(t *testing.T) {
 		svc := SetlistService{}
-		_, err := svc.Duplicate(ctx, 10, 1, "", "#00FF00")
+		_, err := svc.Duplicate(ctx, 10, 1, userID, "", "#00FF00")
 		if !errors.Is(err, ErrSetlistNameRequired) {
 			t.Fatalf("expected ErrSetlistNameRequired, got %v", err)
 		}
@@ -428,13 +679,207 @@ func TestSetlistService_Duplicate_Errors(t *testing.T) {
 		mockTx.EXPECT().Rollback(ctx).Return(nil)
 		mockRepo.EXPECT().GetSetlistByID(ctx, 10, 1).Return(model.Setlist{}, pgx.ErrNoRows)
 
-		_, err := svc.Duplicate(ctx, 10, 1, "Copy", "#00FF00")
+		_, err := svc.Duplicate(ctx, 10, 1, userID, "Copy", "#00FF00")
 		if !errors.Is(err, ErrSetlistNotFound) {
 			t.Fatalf("expected ErrSetlistNotFound, got %v", err)
 		}
 	})
 }
 
+func TestSetlistService_AddItems(t *testing.T) {
+	ctx := context.Background()
+	setlistID := 10
+	bandID := 1
+	ownedSetlist := model.Setlist{ID: setlistID, BandID: bandID}
+
+	t.Run("inserts every item at the requested position", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
+		mockSongRepo := mocks.NewMockSongRepository(ctrl)
+		mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo, SongRepo: mockSongRepo, RevisionRepo: mockRevisionRepo}
+
+		position := 2
+		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(ownedSetlist, nil)
+		mockSongRepo.EXPECT().GetSongByID(ctx, 5, bandID).Return(model.Song{ID: 5}, nil)
+		mockSongRepo.EXPECT().GetSongByID(ctx, 6, bandID).Return(model.Song{ID: 6}, nil)
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().LockSetlists(ctx, mockTx, setlistID).Return(nil)
+		mockRepo.EXPECT().InsertItems(ctx, mockTx, setlistID, &position, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ repository.DBTX, _ int, index *int, items []model.SetlistItem) ([]model.SetlistItem, error) {
+				if len(items) != 3 || *items[0].SongID != 5 || items[1].ItemType != model.ItemTypeSetBreak || *items[2].SongID != 6 {
+					t.Errorf("unexpected items passed to repo: %+v", items)
+				}
+				for i := range items {
+					items[i].ID = 100 + i
+					items[i].Position = *index + i
+				}
+				return items, nil
+			})
+		mockRevisionRepo.EXPECT().Record(ctx, mockTx, setlistID, userID, RevisionActionAddItem).Return(nil)
+		mockTx.EXPECT().Commit(ctx).Return(nil)
+
+		created, err := svc.AddItems(ctx, setlistID, bandID, userID, AddItemsPayload{
+			Position: &position,
+			Items: []AddItemPayload{
+				{ItemType: model.ItemTypeSong, ItemID: 5},
+				{ItemType: model.ItemTypeSetBreak},
+				{ItemType: model.ItemTypeSong, ItemID: 6},
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(created) != 3 || created[2].Position != 4 {
+			t.Errorf("unexpected created items: %+v", created)
+		}
+	})
+
+	t.Run("adds nothing when one item is invalid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockSongRepo := mocks.NewMockSongRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo, SongRepo: mockSongRepo}
+
+		mockRepo.EXPECT().GetSetlistByID(ctx, setlistID, bandID).Return(ownedSetlist, nil)
+		mockSongRepo.EXPECT().GetSongByID(ctx, 5, bandID).Return(model.Song{ID: 5}, nil)
+		mockSongRepo.EXPECT().GetSongByID(ctx, 99, bandID).Return(model.Song{}, pgx.ErrNoRows)
+		// No InsertItems call expected.
+
+		_, err := svc.AddItems(ctx, setlistID, bandID, userID, AddItemsPayload{Items: []AddItemPayload{
+			{ItemType: model.ItemTypeSong, ItemID: 5},
+			{ItemType: model.ItemTypeSong, ItemID: 99},
+		}})
+		if !errors.Is(err, ErrItemNotFound) {
+			t.Fatalf("expected ErrItemNotFound, got %v", err)
+		}
+	})
+
+	t.Run("validates the batch", func(t *testing.T) {
+		svc := SetlistService{}
+		negative := -1
+
+		if _, err := svc.AddItems(ctx, setlistID, bandID, userID, AddItemsPayload{}); !isValidationError(err) {
+			t.Errorf("expected a ValidationError for an empty batch, got %v", err)
+		}
+		if _, err := svc.AddItem(ctx, setlistID, bandID, userID, AddItemPayload{ItemType: model.ItemTypeSetBreak, Position: &negative}); !isValidationError(err) {
+			t.Errorf("expected a ValidationError for a negative position, got %v", err)
+		}
+	})
+}
+
+func TestSetlistService_UpdateItem_Overrides(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("passes the overrides to the repository", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo, RevisionRepo: mockRevisionRepo}
+
+		transition, duration, tempo, key := 15, 0, 132, "Bb"
+		mockRepo.EXPECT().GetItemSetlistID(ctx, 3, 1).Return(10, nil)
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().LockSetlists(ctx, mockTx, 10).Return(nil)
+		mockRepo.EXPECT().UpdateSetlistItem(ctx, mockTx, 3, 10, 1, repository.SetlistItemUpdate{
+			TransitionDurationSeconds: &transition,
+			DurationSeconds:           int32Ptr(0),
+			Tempo:                     int32Ptr(132),
+			SongKey:                   &key,
+		}).Return(model.SetlistItem{ID: 3, SetlistID: 10, Tempo: int32Ptr(132), SongKey: &key}, nil)
+		mockRevisionRepo.EXPECT().Record(ctx, mockTx, 10, userID, RevisionActionUpdateItem).Return(nil)
+		mockTx.EXPECT().Commit(ctx).Return(nil)
+
+		item, err := svc.UpdateItem(ctx, 3, 1, userID, UpdateItemPayload{
+			TransitionDurationSeconds: &transition,
+			DurationSeconds:           &duration,
+			Tempo:                     &tempo,
+			SongKey:                   &key,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if *item.Tempo != 132 || *item.SongKey != "Bb" {
+			t.Errorf("expected the effective values, got %+v", item)
+		}
+	})
+
+	t.Run("passes cleared notes to the repository", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo, RevisionRepo: mockRevisionRepo}
+
+		mockRepo.EXPECT().GetItemSetlistID(ctx, 3, 1).Return(10, nil)
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().LockSetlists(ctx, mockTx, 10).Return(nil)
+		mockRepo.EXPECT().UpdateSetlistItem(ctx, mockTx, 3, 10, 1, repository.SetlistItemUpdate{Notes: strPtr("")}).Return(model.SetlistItem{ID: 3, SetlistID: 10}, nil)
+		mockRevisionRepo.EXPECT().Record(ctx, mockTx, 10, userID, RevisionActionUpdateItem).Return(nil)
+		mockTx.EXPECT().Commit(ctx).Return(nil)
+
+		if _, err := svc.UpdateItem(ctx, 3, 1, userID, UpdateItemPayload{Notes: strPtr("")}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("rejects negative values", func(t *testing.T) {
+		svc := SetlistService{}
+		negative := -5
+
+		if _, err := svc.UpdateItem(ctx, 3, 1, userID, UpdateItemPayload{TransitionDurationSeconds: &negative}); !errors.Is(err, ErrInvalidDuration) {
+			t.Errorf("expected ErrInvalidDuration for the transition, got %v", err)
+		}
+		if _, err := svc.UpdateItem(ctx, 3, 1, userID, UpdateItemPayload{DurationSeconds: &negative}); !errors.Is(err, ErrInvalidDuration) {
+			t.Errorf("expected ErrInvalidDuration for the duration, got %v", err)
+		}
+		if _, err := svc.UpdateItem(ctx, 3, 1, userID, UpdateItemPayload{Tempo: &negative}); !isValidationError(err) {
+			t.Errorf("expected a ValidationError for the tempo, got %v", err)
+		}
+	})
+}
+
+func TestSetlistService_RepairPositions(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSetlistRepository(ctrl)
+	mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+	mockTx := mocks.NewMockTx(ctrl)
+	svc := SetlistService{SetlistRepo: mockRepo, RevisionRepo: mockRevisionRepo}
+
+	mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+	mockTx.EXPECT().Rollback(ctx).Return(nil)
+	mockRepo.EXPECT().RepairPositions(ctx, mockTx, 1).Return([]int{10, 12}, nil)
+	for _, setlistID := range []int{10, 12} {
+		mockRevisionRepo.EXPECT().Record(ctx, mockTx, setlistID, userID, RevisionActionRepair).Return(nil)
+		mockRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, setlistID).Return([]model.SetlistItem{{ID: 1, Position: 0}}, nil)
+	}
+	mockTx.EXPECT().Commit(ctx).Return(nil)
+
+	repair, err := svc.RepairPositions(ctx, 1, userID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(repair.RepairedSetlistIDs, []int{10, 12}) {
+		t.Errorf("unexpected repaired setlists: %v", repair.RepairedSetlistIDs)
+	}
+}
+
 func TestSetlistService_ItemErrors(t *testing.T) {
 	ctx := context.Background()
 	itemID := 3
@@ -447,9 +892,9 @@ func TestSetlistService_ItemErrors(t *testing.T) {
 		mockRepo := mocks.NewMockSetlistRepository(ctrl)
 		svc := SetlistService{SetlistRepo: mockRepo}
 
-		mockRepo.EXPECT().UpdateSetlistItem(ctx, itemID, bandID, gomock.Any()).Return(model.SetlistItem{}, pgx.ErrNoRows)
+		mockRepo.EXPECT().GetItemSetlistID(ctx, itemID, bandID).Return(0, pgx.ErrNoRows)
 
-		_, err := svc.UpdateItem(ctx, itemID, bandID, UpdateItemPayload{Notes: "notes"})
+		_, err := svc.UpdateItem(ctx, itemID, bandID, userID, UpdateItemPayload{Notes: strPtr("notes")})
 		if !errors.Is(err, ErrItemNotFound) {
 			t.Fatalf("expected ErrItemNotFound, got %v", err)
 		}
@@ -462,9 +907,50 @@ func TestSetlistService_ItemErrors(t *testing.T) {
 		mockRepo := mocks.NewMockSetlistRepository(ctrl)
 		svc := SetlistService{SetlistRepo: mockRepo}
 
-		mockRepo.EXPECT().DeleteSetlistItem(ctx, itemID, bandID).Return(sql.ErrNoRows)
+		mockRepo.EXPECT().GetItemSetlistID(ctx, itemID, bandID).Return(0, sql.ErrNoRows)
+
+		if err := svc.DeleteItem(ctx, itemID, bandID, userID); !errors.Is(err, ErrItemNotFound) {
+			t.Fatalf("expected ErrItemNotFound, got %v", err)
+		}
+	})
+
+	// The item is found in setlist 10, then moved to another setlist before
+	// the lock on setlist 10 is taken: the change must not follow it there.
+	t.Run("UpdateItem returns ErrItemNotFound when the item left the locked setlist", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo}
+
+		mockRepo.EXPECT().GetItemSetlistID(ctx, itemID, bandID).Return(10, nil)
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().LockSetlists(ctx, mockTx, 10).Return(nil)
+		mockRepo.EXPECT().UpdateSetlistItem(ctx, mockTx, itemID, 10, bandID, gomock.Any()).Return(model.SetlistItem{}, pgx.ErrNoRows)
+
+		_, err := svc.UpdateItem(ctx, itemID, bandID, userID, UpdateItemPayload{Notes: strPtr("notes")})
+		if !errors.Is(err, ErrItemNotFound) {
+			t.Fatalf("expected ErrItemNotFound, got %v", err)
+		}
+	})
+
+	t.Run("DeleteItem returns ErrItemNotFound when the item left the locked setlist", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo}
+
+		mockRepo.EXPECT().GetItemSetlistID(ctx, itemID, bandID).Return(10, nil)
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().LockSetlists(ctx, mockTx, 10).Return(nil)
+		mockRepo.EXPECT().DeleteSetlistItem(ctx, mockTx, itemID, 10).Return(pgx.ErrNoRows)
 
-		if err := svc.DeleteItem(ctx, itemID, bandID); !errors.Is(err, ErrItemNotFound) {
+		if err := svc.DeleteItem(ctx, itemID, bandID, userID); !errors.Is(err, ErrItemNotFound) {
 			t.Fatalf("expected ErrItemNotFound, got %v", err)
 		}
 	})
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"setlist/api/model"
+	"setlist/api/repository"
+	"time"
+)
+
+const (
+	defaultShareLifetime  = 48 * time.Hour
+	maxShareLifetimeHours = 30 * 24
+)
+
+var (
+	ErrShareNotFound = errors.New("share link not found or revoked")
+	ErrShareExpired  = errors.New("share link is expired")
+)
+
+type SetlistShareService struct {
+	ShareRepo   repository.SetlistShareRepository
+	SetlistRepo repository.SetlistRepository
+}
+
+type CreateSharePayload struct {
+	ExpiresInHours *int `json:"expires_in_hours"`
+}
+
+// SharedSetlist is the read-only view of a setlist behind a share link. It
+// leaves out everything internal to the band: ids, links and private notes.
+type SharedSetlist struct {
+	Name                 string              `json:"name"`
+	Color                string              `json:"color"`
+	TotalDurationSeconds int                 `json:"total_duration_seconds"`
+	ExpiresAt            time.Time           `json:"expires_at"`
+	Items                []SharedSetlistItem `json:"items"`
+}
+
+type SharedSetlistItem struct {
+	Position        int     `json:"position"`
+	ItemType        string  `json:"item_type"`
+	Title           *string `json:"title,omitempty"`
+	DurationSeconds *int32  `json:"duration_seconds,omitempty"`
+	Tempo           *int32  `json:"tempo,omitempty"`
+	SongKey         *string `json:"song_key,omitempty"`
+	Speaker         *string `json:"speaker,omitempty"`
+	Notes           *string `json:"notes,omitempty"`
+}
+
+func (s SetlistShareService) Create(ctx context.Context, setlistID int, bandID int, userID int, payload CreateSharePayload) (model.SetlistShare, error) {
+	lifetime := defaultShareLifetime
+	if payload.ExpiresInHours != nil {
+		// Checked before the conversion, which overflows for huge values.
+		hours := *payload.ExpiresInHours
+		if hours < 1 || hours > maxShareLifetimeHours {
+			return model.SetlistShare{}, &ValidationError{Msg: "La durée de validité doit être comprise entre 1 heure et 30 jours."}
+		}
+		lifetime = time.Duration(hours) * time.Hour
+	}
+
+	setlist, err := s.SetlistRepo.GetSetlistByID(ctx, setlistID, bandID)
+	if err != nil {
+		return model.SetlistShare{}, mapNotFound(err, ErrSetlistNotFound)
+	}
+	if setlist.IsTemplate {
+		return model.SetlistShare{}, &ValidationError{Msg: "Un modèle ne peut pas être partagé."}
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return model.SetlistShare{}, err
+	}
+
+	share := model.SetlistShare{
+		Token:     token,
+		SetlistID: setlistID,
+		CreatedBy: &userID,
+		ExpiresAt: time.Now().Add(lifetime),
+	}
+	if err := s.ShareRepo.Create(ctx, &share); err != nil {
+		return model.SetlistShare{}, err
+	}
+	return share, nil
+}
+
+func (s SetlistShareService) List(ctx context.Context, setlistID int, bandID int) ([]model.SetlistShare, error) {
+	if _, err := s.SetlistRepo.GetSetlistByID(ctx, setlistID, bandID); err != nil {
+		return nil, mapNotFound(err, ErrSetlistNotFound)
+	}
+	return s.ShareRepo.ListActiveBySetlistID(ctx, setlistID)
+}
+
+// Revoke deletes a single share link, or every link of the setlist when token
+// is empty.
+func (s SetlistShareService) Revoke(ctx context.Context, setlistID int, bandID int, token string) error {
+	if _, err := s.SetlistRepo.GetSetlistByID(ctx, setlistID, bandID); err != nil {
+		return mapNotFound(err, ErrSetlistNotFound)
+	}
+	if token == "" {
+		return s.ShareRepo.DeleteAllBySetlistID(ctx, setlistID)
+	}
+	return mapNotFound(s.ShareRepo.Delete(ctx, setlistID, token), ErrShareNotFound)
+}
+
+func (s SetlistShareService) GetShared(ctx context.Context, token string) (SharedSetlist, error) {
+	share, err := s.ShareRepo.GetByToken(ctx, token)
+	if err != nil {
+		return SharedSetlist{}, mapNotFound(err, ErrShareNotFound)
+	}
+	if time.Now().After(share.ExpiresAt) {
+		return SharedSetlist{}, ErrShareExpired
+	}
+
+	setlist, err := s.SetlistRepo.GetSetlistByID(ctx, share.SetlistID, share.BandID)
+	if err != nil {
+		return SharedSetlist{}, mapNotFound(err, ErrShareNotFound)
+	}
+	items, err := s.SetlistRepo.GetSetlistItemsBySetlistID(ctx, share.SetlistID)
+	if err != nil {
+		return SharedSetlist{}, err
+	}
+
+	shared := SharedSetlist{
+		Name:                 setlist.Name,
+		Color:                setlist.Color,
+		TotalDurationSeconds: ComputeTiming(items, nil).TotalDurationSeconds,
+		ExpiresAt:            share.ExpiresAt,
+		Items:                make([]SharedSetlistItem, 0, len(items)),
+	}
+	for _, item := range items {
+		sharedItem := SharedSetlistItem{
+			Position:        item.Position,
+			ItemType:        item.ItemType,
+			Title:           item.Title,
+			DurationSeconds: item.DurationSeconds,
+			Tempo:           item.Tempo,
+			SongKey:         item.SongKey,
+			Speaker:         item.Speaker,
+		}
+		if !item.NotesPrivate {
+			sharedItem.Notes = item.Notes
+		}
+		shared.Items = append(shared.Items, sharedItem)
+	}
+	return shared, nil
+}
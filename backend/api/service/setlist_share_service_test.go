@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"setlist/api/model"
+	"setlist/api/repository/mocks"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSetlistShareService_Create(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("creates a share expiring after the default lifetime", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockShareRepo := mocks.NewMockSetlistShareRepository(ctrl)
+		mockSetlistRepo := mocks.NewMockSetlistRepository(ctrl)
+		svc := SetlistShareService{ShareRepo: mockShareRepo, SetlistRepo: mockSetlistRepo}
+
+		mockSetlistRepo.EXPECT().GetSetlistByID(ctx, 10, 1).Return(model.Setlist{ID: 10, BandID: 1}, nil)
+		mockShareRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+
+		share, err := svc.Create(ctx, 10, 1, 3, CreateSharePayload{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(share.Token) != 32 || share.SetlistID != 10 || share.CreatedBy == nil || *share.CreatedBy != 3 {
+			t.Errorf("unexpected share: %+v", share)
+		}
+		if lifetime := time.Until(share.ExpiresAt); lifetime < defaultShareLifetime-time.Minute || lifetime > defaultShareLifetime {
+			t.Errorf("unexpected lifetime %v", lifetime)
+		}
+	})
+
+	t.Run("rejects a lifetime out of range", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc := SetlistShareService{ShareRepo: mocks.NewMockSetlistShareRepository(ctrl), SetlistRepo: mocks.NewMockSetlistRepository(ctrl)}
+
+		// 5124096 hours overflow a time.Duration into less than an hour.
+		for _, hours := range []int{0, 24 * 31, 5124096} {
+			_, err := svc.Create(ctx, 10, 1, 3, CreateSharePayload{ExpiresInHours: &hours})
+			var ve *ValidationError
+			if !errors.As(err, &ve) {
+				t.Fatalf("expected a ValidationError for %d hours, got %v", hours, err)
+			}
+		}
+	})
+
+	t.Run("rejects a template", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockSetlistRepo := mocks.NewMockSetlistRepository(ctrl)
+		svc := SetlistShareService{ShareRepo: mocks.NewMockSetlistShareRepository(ctrl), SetlistRepo: mockSetlistRepo}
+
+		mockSetlistRepo.EXPECT().GetSetlistByID(ctx, 30, 1).Return(model.Setlist{ID: 30, BandID: 1, IsTemplate: true}, nil)
+		// No share is created.
+
+		if _, err := svc.Create(ctx, 30, 1, 3, CreateSharePayload{}); !isValidationError(err) {
+			t.Fatalf("expected a ValidationError, got %v", err)
+		}
+	})
+}
+
+func TestSetlistShareService_GetShared(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("hides private notes and internal fields", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockShareRepo := mocks.NewMockSetlistShareRepository(ctrl)
+		mockSetlistRepo := mocks.NewMockSetlistRepository(ctrl)
+		svc := SetlistShareService{ShareRepo: mockShareRepo, SetlistRepo: mockSetlistRepo}
+
+		share := model.SetlistShare{Token: "tok", SetlistID: 10, BandID: 1, ExpiresAt: time.Now().Add(time.Hour)}
+		items := []model.SetlistItem{
+			{ID: 1, Position: 0, ItemType: model.ItemTypeSong, Title: strPtr("Intro"), DurationSeconds: int32Ptr(200), Notes: strPtr("Capo 2"), Links: strPtr("https://example.com")},
+			{ID: 2, Position: 1, ItemType: model.ItemTypeSong, Title: strPtr("Outro"), DurationSeconds: int32Ptr(100), Notes: strPtr("Cachet à négocier"), NotesPrivate: true},
+		}
+		mockShareRepo.EXPECT().GetByToken(ctx, "tok").Return(share, nil)
+		mockSetlistRepo.EXPECT().GetSetlistByID(ctx, 10, 1).Return(model.Setlist{ID: 10, BandID: 1, Name: "Concert"}, nil)
+		mockSetlistRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, 10).Return(items, nil)
+
+		shared, err := svc.GetShared(ctx, "tok")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if shared.Name != "Concert" || shared.TotalDurationSeconds != 300 || len(shared.Items) != 2 {
+			t.Fatalf("unexpected shared setlist: %+v", shared)
+		}
+		if shared.Items[0].Notes == nil || *shared.Items[0].Notes != "Capo 2" {
+			t.Error("expected public notes to be shared")
+		}
+		if shared.Items[1].Notes != nil {
+			t.Error("expected private notes to be hidden")
+		}
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockShareRepo := mocks.NewMockSetlistShareRepository(ctrl)
+		svc := SetlistShareService{ShareRepo: mockShareRepo, SetlistRepo: mocks.NewMockSetlistRepository(ctrl)}
+
+		mockShareRepo.EXPECT().GetByToken(ctx, "nope").Return(model.SetlistShare{}, pgx.ErrNoRows)
+
+		if _, err := svc.GetShared(ctx, "nope"); !errors.Is(err, ErrShareNotFound) {
+			t.Fatalf("expected ErrShareNotFound, got %v", err)
+		}
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockShareRepo := mocks.NewMockSetlistShareRepository(ctrl)
+		svc := SetlistShareService{ShareRepo: mockShareRepo, SetlistRepo: mocks.NewMockSetlistRepository(ctrl)}
+
+		mockShareRepo.EXPECT().GetByToken(ctx, "old").Return(model.SetlistShare{ExpiresAt: time.Now().Add(-time.Minute)}, nil)
+
+		if _, err := svc.GetShared(ctx, "old"); !errors.Is(err, ErrShareExpired) {
+			t.Fatalf("expected ErrShareExpired, got %v", err)
+		}
+	})
+}
+
+func TestSetlistShareService_Revoke(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockShareRepo := mocks.NewMockSetlistShareRepository(ctrl)
+	mockSetlistRepo := mocks.NewMockSetlistRepository(ctrl)
+	svc := SetlistShareService{ShareRepo: mockShareRepo, SetlistRepo: mockSetlistRepo}
+
+	mockSetlistRepo.EXPECT().GetSetlistByID(ctx, 10, 1).Return(model.Setlist{ID: 10, BandID: 1}, nil).Times(2)
+	mockShareRepo.EXPECT().DeleteAllBySetlistID(ctx, 10).Return(nil)
+	mockShareRepo.EXPECT().Delete(ctx, 10, "gone").Return(pgx.ErrNoRows)
+
+	if err := svc.Revoke(ctx, 10, 1, ""); err != nil {
+		t.Fatalf("unexpected error revoking every link: %v", err)
+	}
+	if err := svc.Revoke(ctx, 10, 1, "gone"); !errors.Is(err, ErrShareNotFound) {
+		t.Fatalf("expected ErrShareNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"setlist/api/model"
+	"setlist/cache"
+)
+
+var ErrTemplateNotFound = errors.New("template not found or does not belong to the user's band")
+
+// SaveTemplatePayload names the template saved from a setlist. The color
+// defaults to the setlist's.
+type SaveTemplatePayload struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+func (s SetlistService) GetTemplates(ctx context.Context, bandID int) ([]model.Setlist, error) {
+	return s.SetlistRepo.GetTemplatesByBandID(ctx, bandID)
+}
+
+// SaveAsTemplate copies the items of a setlist into a new template. The
+// setlist itself is left untouched.
+func (s SetlistService) SaveAsTemplate(ctx context.Context, setlistID int, bandID int, userID int, payload SaveTemplatePayload) (model.Setlist, error) {
+	source, err := s.SetlistRepo.GetSetlistByID(ctx, setlistID, bandID)
+	if err != nil {
+		return model.Setlist{}, mapNotFound(err, ErrSetlistNotFound)
+	}
+
+	name, color := payload.Name, payload.Color
+	if color == "" {
+		color = source.Color
+	}
+	if name == "" {
+		return model.Setlist{}, ErrSetlistNameRequired
+	}
+	if !hexColorRegex.MatchString(color) {
+		return model.Setlist{}, ErrInvalidColor
+	}
+
+	template, err := s.copySetlist(ctx, source, userID, name, color, true)
+	if err != nil {
+		return model.Setlist{}, err
+	}
+
+	return template, nil
+}
+
+func (s SetlistService) createFromTemplate(ctx context.Context, payload CreateSetlistPayload, bandID int, userID int) (model.Setlist, error) {
+	template, err := s.SetlistRepo.GetSetlistByID(ctx, *payload.TemplateID, bandID)
+	if err != nil {
+		return model.Setlist{}, mapNotFound(err, ErrTemplateNotFound)
+	}
+	if !template.IsTemplate {
+		return model.Setlist{}, ErrTemplateNotFound
+	}
+
+	color := payload.Color
+	if color == "" {
+		color = template.Color
+	}
+	if payload.Name == "" {
+		return model.Setlist{}, ErrSetlistNameRequired
+	}
+	if !hexColorRegex.MatchString(color) {
+		return model.Setlist{}, ErrInvalidColor
+	}
+
+	created, err := s.copySetlist(ctx, template, userID, payload.Name, color, false)
+	if err != nil {
+		return model.Setlist{}, err
+	}
+
+	cache.Delete(ctx, s.Cache, cache.SetlistKey(bandID))
+	return created, nil
+}
+
+// copySetlist creates a setlist or template holding a copy of the items of
+// source, the same way Duplicate does, and records its creation by userID.
+func (s SetlistService) copySetlist(ctx context.Context, source model.Setlist, userID int, name, color string, isTemplate bool) (model.Setlist, error) {
+	items, err := s.SetlistRepo.GetSetlistItemsBySetlistID(ctx, source.ID)
+	if err != nil {
+		return model.Setlist{}, err
+	}
+
+	tx, err := s.SetlistRepo.BeginTx(ctx)
+	if err != nil {
+		return model.Setlist{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	created, err := s.SetlistRepo.CreateSetlist(ctx, tx, name, color, source.BandID, isTemplate)
+	if err != nil {
+		return model.Setlist{}, err
+	}
+	if err := s.SetlistRepo.CopyItemsToNewSetlist(ctx, tx, created.ID, items); err != nil {
+		return model.Setlist{}, err
+	}
+	if err := s.recordRevision(ctx, tx, created.ID, userID, RevisionActionCreate); err != nil {
+		return model.Setlist{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return model.Setlist{}, err
+	}
+	return created, nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"setlist/api/model"
+	"setlist/api/repository/mocks"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSetlistService_Create_FromTemplate(t *testing.T) {
+	ctx := context.Background()
+	bandID := 1
+	templateID := 7
+
+	t.Run("copies the template items and color", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
+		mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo, RevisionRepo: mockRevisionRepo}
+
+		template := model.Setlist{ID: templateID, BandID: bandID, Name: "Festival", Color: "#123456", IsTemplate: true}
+		items := []model.SetlistItem{
+			{ID: 1, Position: 0, ItemType: model.ItemTypeSong, SongID: int32Ptr(5)},
+			{ID: 2, Position: 1, ItemType: model.ItemTypePlaceholder, Label: strPtr("Ballade de moins de 4 min"), ItemDurationSeconds: int32Ptr(240)},
+		}
+		created := model.Setlist{ID: 20, BandID: bandID, Name: "Lyon", Color: "#123456"}
+
+		mockRepo.EXPECT().GetSetlistByID(ctx, templateID, bandID).Return(template, nil)
+		mockRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, templateID).Return(items, nil)
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().CreateSetlist(ctx, mockTx, "Lyon", "#123456", bandID, false).Return(created, nil)
+		mockRepo.EXPECT().CopyItemsToNewSetlist(ctx, mockTx, created.ID, items).Return(nil)
+		mockRevisionRepo.EXPECT().Record(ctx, mockTx, created.ID, userID, RevisionActionCreate).Return(nil)
+		mockTx.EXPECT().Commit(ctx).Return(nil)
+
+		result, err := svc.Create(ctx, CreateSetlistPayload{Name: "Lyon", TemplateID: &templateID}, bandID, userID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.ID != created.ID || result.IsTemplate {
+			t.Errorf("unexpected setlist: %+v", result)
+		}
+	})
+
+	t.Run("rejects a setlist that is not a template", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo}
+
+		mockRepo.EXPECT().GetSetlistByID(ctx, templateID, bandID).Return(model.Setlist{ID: templateID, BandID: bandID}, nil)
+
+		_, err := svc.Create(ctx, CreateSetlistPayload{Name: "Lyon", TemplateID: &templateID}, bandID, userID)
+		if !errors.Is(err, ErrTemplateNotFound) {
+			t.Fatalf("expected ErrTemplateNotFound, got %v", err)
+		}
+	})
+
+	t.Run("rejects a template of another band", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo}
+
+		mockRepo.EXPECT().GetSetlistByID(ctx, templateID, bandID).Return(model.Setlist{}, pgx.ErrNoRows)
+
+		_, err := svc.Create(ctx, CreateSetlistPayload{Name: "Lyon", TemplateID: &templateID}, bandID, userID)
+		if !errors.Is(err, ErrTemplateNotFound) {
+			t.Fatalf("expected ErrTemplateNotFound, got %v", err)
+		}
+	})
+}
+
+func TestSetlistService_SaveAsTemplate(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSetlistRepository(ctrl)
+	mockTx := mocks.NewMockTx(ctrl)
+	mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+	svc := SetlistService{SetlistRepo: mockRepo, RevisionRepo: mockRevisionRepo}
+
+	source := model.Setlist{ID: 10, BandID: 1, Name: "Lyon", Color: "#FF0000"}
+	items := []model.SetlistItem{{ID: 1, ItemType: model.ItemTypeSong, SongID: int32Ptr(5)}}
+	template := model.Setlist{ID: 30, BandID: 1, Name: "Ouverture et rappel", Color: "#FF0000", IsTemplate: true}
+
+	mockRepo.EXPECT().GetSetlistByID(ctx, source.ID, 1).Return(source, nil)
+	mockRepo.EXPECT().GetSetlistItemsBySetlistID(ctx, source.ID).Return(items, nil)
+	mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+	mockTx.EXPECT().Rollback(ctx).Return(nil)
+	mockRepo.EXPECT().CreateSetlist(ctx, mockTx, template.Name, source.Color, 1, true).Return(template, nil)
+	mockRepo.EXPECT().CopyItemsToNewSetlist(ctx, mockTx, template.ID, items).Return(nil)
+	mockRevisionRepo.EXPECT().Record(ctx, mockTx, template.ID, userID, RevisionActionCreate).Return(nil)
+	mockTx.EXPECT().Commit(ctx).Return(nil)
+
+	result, err := svc.SaveAsTemplate(ctx, source.ID, 1, userID, SaveTemplatePayload{Name: template.Name})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsTemplate {
+		t.Errorf("expected a template, got %+v", result)
+	}
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"setlist/api/model"
+	"strings"
+	"time"
+)
+
+const (
+	ExportFormatText     = "txt"
+	ExportFormatMarkdown = "md"
+	ExportFormatCSV      = "csv"
+)
+
+type ExportOptions struct {
+	Format       string
+	PlannedStart *time.Time
+}
+
+// exportRow is one performed item of the setlist, already formatted.
+// Set breaks and encore markers do not get a row: they open a set heading.
+// A group gets a row of its own, with its combined duration, before the rows
+// of its items.
+type exportRow struct {
+	Set        string
+	Position   string
+	Number     string
+	Title      string
+	Key        string
+	Tempo      string
+	Duration   string
+	Notes      string
+	Cumulative string
+	IsGroup    bool
+	InGroup    bool
+}
+
+// displayTitle marks the items of a group as sub-entries of its row.
+func (row exportRow) displayTitle() string {
+	if row.InGroup {
+		return "↳ " + row.Title
+	}
+	return row.Title
+}
+
+// Export renders the setlist as a plain-text document: a numbered list to
+// paste into a chat, a Markdown table, a CSV sheet or a ChordPro songbook.
+func (s SetlistService) Export(ctx context.Context, id int, bandID int, opts ExportOptions) (ExportFile, error) {
+	details, err := s.GetDetails(ctx, id, bandID)
+	if err != nil {
+		return ExportFile{}, err
+	}
+	timing := ComputeTiming(details.Items, opts.PlannedStart)
+
+	switch opts.Format {
+	case "", ExportFormatText:
+		return ExportFile{
+			FileName:    exportFileName(details.Name, "", "txt"),
+			ContentType: "text/plain; charset=utf-8",
+			Content:     renderTextExport(details, timing),
+		}, nil
+	case ExportFormatMarkdown:
+		return ExportFile{
+			FileName:    exportFileName(details.Name, "", "md"),
+			ContentType: "text/markdown; charset=utf-8",
+			Content:     renderMarkdownExport(details, timing),
+		}, nil
+	case ExportFormatCSV:
+		content, err := renderCSVExport(details, timing)
+		if err != nil {
+			return ExportFile{}, err
+		}
+		return ExportFile{
+			FileName:    exportFileName(details.Name, "", "csv"),
+			ContentType: "text/csv; charset=utf-8",
+			Content:     content,
+		}, nil
+	case ExportFormatChordPro:
+		songs, err := s.loadSongs(ctx, details.Items, bandID)
+		if err != nil {
+			return ExportFile{}, err
+		}
+		return ExportFile{
+			FileName:    exportFileName(details.Name, "", "cho"),
+			ContentType: "application/x-chordpro; charset=utf-8",
+			Content:     renderChordProExport(details, timing, songs),
+		}, nil
+	}
+	return ExportFile{}, ErrInvalidExportFormat
+}
+
+// exportRows formats the items of the setlist, grouped by set. Groups that do
+// not open an announced set, such as the whole show when it has no section
+// marker, get a zero SetTiming. The cumulative time is the time elapsed since
+// the start of the show once the item is over.
+func exportRows(details SetlistDetails, timing SetlistTiming) ([]SetTiming, [][]exportRow) {
+	sets := setsByFirstItem(timing)
+	itemGroups := groupsByFirstItem(timing)
+	var headings []SetTiming
+	var groups [][]exportRow
+	songNumber := 0
+	for i, item := range details.Items {
+		if set, ok := sets[item.ID]; ok || len(groups) == 0 {
+			headings = append(headings, set)
+			groups = append(groups, nil)
+		}
+		if item.ItemType == model.ItemTypeSetBreak || item.ItemType == model.ItemTypeEncore {
+			continue
+		}
+		if group, ok := itemGroups[item.ID]; ok {
+			row := exportRow{
+				Title:      group.Name,
+				Duration:   formatDuration(group.DurationSeconds),
+				Cumulative: formatDuration(group.EndOffsetSeconds),
+				IsGroup:    true,
+			}
+			if set := headings[len(headings)-1]; set.Number > 0 {
+				row.Set = setTitle(set)
+			}
+			groups[len(groups)-1] = append(groups[len(groups)-1], row)
+		}
+
+		row := exportRow{
+			Position:   fmt.Sprintf("%d", item.Position+1),
+			Title:      itemTitle(item),
+			Key:        valueOrEmpty(item.SongKey),
+			Duration:   formatItemDuration(item.DurationSeconds),
+			Notes:      valueOrEmpty(item.Notes),
+			Cumulative: formatDuration(timing.Items[i].EndOffsetSeconds),
+			InGroup:    item.GroupID != nil,
+		}
+		if set := headings[len(headings)-1]; set.Number > 0 {
+			row.Set = setTitle(set)
+		}
+		switch item.ItemType {
+		case model.ItemTypeSong:
+			songNumber++
+			row.Number = fmt.Sprintf("%d", songNumber)
+		case model.ItemTypeInterlude:
+			row.Title = interludeLabel(item)
+		}
+		if item.Tempo != nil {
+			row.Tempo = fmt.Sprintf("%d", *item.Tempo)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], row)
+	}
+	return headings, groups
+}
+
+func exportSummary(timing SetlistTiming) string {
+	summary := "Durée totale : " + formatDuration(timing.TotalDurationSeconds)
+	if timing.PlannedStart != nil {
+		summary += fmt.Sprintf(" (%s - %s)", timing.PlannedStart.Format("15:04"), timing.PlannedEnd.Format("15:04"))
+	}
+	return summary
+}
+
+func renderTextExport(details SetlistDetails, timing SetlistTiming) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s\n%s\n", details.Name, exportSummary(timing))
+
+	headings, groups := exportRows(details, timing)
+	for g, rows := range groups {
+		if len(rows) == 0 {
+			continue
+		}
+		b.WriteString("\n")
+		if headings[g].Number > 0 {
+			fmt.Fprintf(&b, "%s · %s\n", setTitle(headings[g]), formatDuration(headings[g].DurationSeconds))
+		}
+		for _, row := range rows {
+			if row.IsGroup {
+				fmt.Fprintf(&b, "     %s (%s) [%s]\n", row.Title, row.Duration, row.Cumulative)
+				continue
+			}
+			number := "  -"
+			if row.Number != "" {
+				number = fmt.Sprintf("%3s.", row.Number)
+			}
+			facts := []string{row.Duration}
+			if row.Key != "" {
+				facts = append(facts, row.Key)
+			}
+			if row.Tempo != "" {
+				facts = append(facts, row.Tempo+" bpm")
+			}
+			fmt.Fprintf(&b, "%s %s (%s) [%s]\n", number, row.displayTitle(), strings.Join(facts, ", "), row.Cumulative)
+			for _, line := range strings.Split(row.Notes, "\n") {
+				if strings.TrimSpace(line) != "" {
+					fmt.Fprintf(&b, "     %s\n", strings.TrimSpace(line))
+				}
+			}
+		}
+	}
+	return b.Bytes()
+}
+
+func renderMarkdownExport(details SetlistDetails, timing SetlistTiming) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# %s\n\n_%s_\n", markdownCell(details.Name), exportSummary(timing))
+
+	headings, groups := exportRows(details, timing)
+	for g, rows := range groups {
+		if len(rows) == 0 {
+			continue
+		}
+		b.WriteString("\n")
+		if headings[g].Number > 0 {
+			fmt.Fprintf(&b, "## %s · %s\n\n", markdownCell(setTitle(headings[g])), formatDuration(headings[g].DurationSeconds))
+		}
+		b.WriteString("| # | Titre | Tonalité | Tempo | Durée | Notes | Cumul |\n")
+		b.WriteString("|--:|---|---|--:|--:|---|--:|\n")
+		for _, row := range rows {
+			title := markdownCell(row.displayTitle())
+			if row.IsGroup {
+				title = "**" + title + "**"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n",
+				row.Number, title, markdownCell(row.Key), row.Tempo,
+				row.Duration, markdownCell(row.Notes), row.Cumulative)
+		}
+	}
+	return b.Bytes()
+}
+
+// markdownCell keeps free text from breaking out of its table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(strings.TrimSpace(s), "\n", "<br>")
+}
+
+// csvCell keeps spreadsheet software from evaluating a cell as a formula: a
+// leading quote makes it plain text and is not displayed. A lone sign, such
+// as the "-" of an unknown duration, is no formula and is left as is.
+func csvCell(s string) string {
+	if s == "-" || s == "+" {
+		return s
+	}
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func renderCSVExport(details SetlistDetails, timing SetlistTiming) ([]byte, error) {
+	var b bytes.Buffer
+	// The byte order mark lets spreadsheet software detect UTF-8, otherwise
+	// accented titles come out garbled.
+	b.WriteString("\ufeff")
+	w := csv.NewWriter(&b)
+
+	// The first column is the position of the item in the setlist, like
+	// everywhere else, rather than the song number of the other exports.
+	records := [][]string{{"Position", "Set", "Titre", "Tonalité", "Tempo", "Durée", "Notes", "Cumul"}}
+	_, groups := exportRows(details, timing)
+	for _, rows := range groups {
+		for _, row := range rows {
+			record := []string{row.Position, row.Set, row.displayTitle(), row.Key, row.Tempo, row.Duration, row.Notes, row.Cumulative}
+			for i := range record {
+				record[i] = csvCell(record[i])
+			}
+			records = append(records, record)
+		}
+	}
+	if err := w.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
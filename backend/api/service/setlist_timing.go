@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"setlist/api/model"
+	"time"
+)
+
+type ItemTiming struct {
+	ItemID             int        `json:"item_id"`
+	Position           int        `json:"position"`
+	ItemType           string     `json:"item_type"`
+	Title              *string    `json:"title"`
+	DurationSeconds    *int32     `json:"duration_seconds"`
+	TransitionSeconds  int        `json:"transition_seconds"`
+	StartOffsetSeconds int        `json:"start_offset_seconds"`
+	EndOffsetSeconds   int        `json:"end_offset_seconds"`
+	StartsAt           *time.Time `json:"starts_at,omitempty"`
+	EndsAt             *time.Time `json:"ends_at,omitempty"`
+	GroupID            *int       `json:"group_id,omitempty"`
+}
+
+const (
+	SectionKindSet    = "set"
+	SectionKindEncore = "encore"
+)
+
+type SetTiming struct {
+	Number               int     `json:"number"`
+	Kind                 string  `json:"kind"`
+	Label                *string `json:"label,omitempty"`
+	FirstItemID          int     `json:"first_item_id"`
+	ItemCount            int     `json:"item_count"`
+	StartOffsetSeconds   int     `json:"start_offset_seconds"`
+	EndOffsetSeconds     int     `json:"end_offset_seconds"`
+	DurationSeconds      int     `json:"duration_seconds"`
+	UnknownDurationCount int     `json:"unknown_duration_count"`
+}
+
+// GroupTiming is the block formed by the items of a group, such as a medley.
+// Its duration includes the transitions between its items.
+type GroupTiming struct {
+	GroupID              int    `json:"group_id"`
+	Name                 string `json:"name"`
+	FirstItemID          int    `json:"first_item_id"`
+	ItemIDs              []int  `json:"item_ids"`
+	StartOffsetSeconds   int    `json:"start_offset_seconds"`
+	EndOffsetSeconds     int    `json:"end_offset_seconds"`
+	DurationSeconds      int    `json:"duration_seconds"`
+	UnknownDurationCount int    `json:"unknown_duration_count"`
+}
+
+type SetlistTiming struct {
+	SetlistID                    int           `json:"setlist_id"`
+	Items                        []ItemTiming  `json:"items"`
+	Sets                         []SetTiming   `json:"sets"`
+	Groups                       []GroupTiming `json:"groups"`
+	ItemsDurationSeconds         int           `json:"items_duration_seconds"`
+	TransitionsDurationSeconds   int           `json:"transitions_duration_seconds"`
+	IntermissionsDurationSeconds int           `json:"intermissions_duration_seconds"`
+	TotalDurationSeconds         int           `json:"total_duration_seconds"`
+	UnknownDurationCount         int           `json:"unknown_duration_count"`
+	PlannedStart                 *time.Time    `json:"planned_start,omitempty"`
+	PlannedEnd                   *time.Time    `json:"planned_end,omitempty"`
+}
+
+// ComputeTiming lays the items out on a timeline in the order given. An item's
+// transition is the gap between its end and the next item's start, so the
+// transition of the last item is not part of the show. Items without a known
+// duration take no time and are counted in UnknownDurationCount. When
+// plannedStart is set, every offset is also returned as a wall-clock time.
+//
+// Set breaks and encore markers take no time and open a new set; an
+// intermission closes the current set and counts towards the total only.
+func ComputeTiming(items []model.SetlistItem, plannedStart *time.Time) SetlistTiming {
+	timing := SetlistTiming{
+		Items:        make([]ItemTiming, 0, len(items)),
+		PlannedStart: plannedStart,
+	}
+
+	offset := 0
+	for i, item := range items {
+		transition := 0
+		if i < len(items)-1 && item.TransitionDurationSeconds > 0 {
+			transition = item.TransitionDurationSeconds
+		}
+
+		duration := 0
+		if item.DurationSeconds != nil {
+			duration = int(*item.DurationSeconds)
+		} else if !isZeroLengthMarker(item.ItemType) {
+			timing.UnknownDurationCount++
+		}
+
+		entry := ItemTiming{
+			ItemID:             item.ID,
+			Position:           item.Position,
+			ItemType:           item.ItemType,
+			Title:              item.Title,
+			DurationSeconds:    item.DurationSeconds,
+			TransitionSeconds:  transition,
+			StartOffsetSeconds: offset,
+			EndOffsetSeconds:   offset + duration,
+			GroupID:            item.GroupID,
+		}
+		if plannedStart != nil {
+			entry.StartsAt = offsetTime(*plannedStart, entry.StartOffsetSeconds)
+			entry.EndsAt = offsetTime(*plannedStart, entry.EndOffsetSeconds)
+		}
+		timing.Items = append(timing.Items, entry)
+
+		if item.ItemType == model.ItemTypeIntermission {
+			timing.IntermissionsDurationSeconds += duration
+		} else {
+			timing.ItemsDurationSeconds += duration
+		}
+		timing.TransitionsDurationSeconds += transition
+		offset += duration + transition
+	}
+
+	timing.TotalDurationSeconds = offset
+	if plannedStart != nil {
+		timing.PlannedEnd = offsetTime(*plannedStart, offset)
+	}
+	timing.Sets = computeSets(items, timing.Items)
+	timing.Groups = computeGroups(items, timing.Items)
+	return timing
+}
+
+func isZeroLengthMarker(itemType string) bool {
+	return itemType == model.ItemTypeSetBreak || itemType == model.ItemTypeEncore
+}
+
+// computeSets groups the timed items into sets. Sets that end up without any
+// performed item, such as a break placed first, are left out.
+func computeSets(items []model.SetlistItem, timed []ItemTiming) []SetTiming {
+	sets := make([]SetTiming, 0)
+	current := SetTiming{Kind: SectionKindSet}
+
+	flush := func() {
+		if current.ItemCount > 0 {
+			current.Number = len(sets) + 1
+			current.DurationSeconds = current.EndOffsetSeconds - current.StartOffsetSeconds
+			sets = append(sets, current)
+		}
+	}
+
+	for i, item := range items {
+		if model.IsSectionMarker(item.ItemType) {
+			flush()
+			// Only a break or an encore names the set it opens.
+			switch item.ItemType {
+			case model.ItemTypeEncore:
+				current = SetTiming{Kind: SectionKindEncore, Label: item.Label}
+			case model.ItemTypeSetBreak:
+				current = SetTiming{Kind: SectionKindSet, Label: item.Label}
+			default:
+				current = SetTiming{Kind: SectionKindSet}
+			}
+			continue
+		}
+
+		if current.ItemCount == 0 {
+			current.FirstItemID = item.ID
+			current.StartOffsetSeconds = timed[i].StartOffsetSeconds
+		}
+		current.EndOffsetSeconds = timed[i].EndOffsetSeconds
+		current.ItemCount++
+		if item.DurationSeconds == nil {
+			current.UnknownDurationCount++
+		}
+	}
+	flush()
+
+	return sets
+}
+
+// computeGroups merges the timed items of each group into a block, in setlist
+// order. The members of a group follow each other, so a group ends with the
+// last of them.
+func computeGroups(items []model.SetlistItem, timed []ItemTiming) []GroupTiming {
+	groups := make([]GroupTiming, 0)
+	index := make(map[int]int)
+	for i, item := range items {
+		if item.GroupID == nil {
+			continue
+		}
+		g, ok := index[*item.GroupID]
+		if !ok {
+			g = len(groups)
+			index[*item.GroupID] = g
+			groups = append(groups, GroupTiming{
+				GroupID:            *item.GroupID,
+				Name:               valueOrEmpty(item.GroupName),
+				FirstItemID:        item.ID,
+				StartOffsetSeconds: timed[i].StartOffsetSeconds,
+			})
+		}
+		group := &groups[g]
+		group.ItemIDs = append(group.ItemIDs, item.ID)
+		group.EndOffsetSeconds = timed[i].EndOffsetSeconds
+		if item.DurationSeconds == nil {
+			group.UnknownDurationCount++
+		}
+	}
+	for g := range groups {
+		groups[g].DurationSeconds = groups[g].EndOffsetSeconds - groups[g].StartOffsetSeconds
+	}
+	return groups
+}
+
+func offsetTime(start time.Time, seconds int) *time.Time {
+	t := start.Add(time.Duration(seconds) * time.Second)
+	return &t
+}
+
+func (s SetlistService) GetTiming(ctx context.Context, id int, bandID int, plannedStart *time.Time) (SetlistTiming, error) {
+	details, err := s.GetDetails(ctx, id, bandID)
+	if err != nil {
+		return SetlistTiming{}, err
+	}
+	timing := ComputeTiming(details.Items, plannedStart)
+	timing.SetlistID = details.ID
+	return timing, nil
+}
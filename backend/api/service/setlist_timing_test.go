@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"setlist/api/model"
+	"setlist/api/repository/mocks"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/mock/gomock"
+)
+
+func int32Ptr(v int32) *int32 { return &v }
+
+func TestComputeTiming(t *testing.T) {
+	items := []model.SetlistItem{
+		{ID: 1, Position: 0, ItemType: "song", DurationSeconds: int32Ptr(180), TransitionDurationSeconds: 30},
+		{ID: 2, Position: 1, ItemType: "interlude", DurationSeconds: nil, TransitionDurationSeconds: 10},
+		{ID: 3, Position: 2, ItemType: "song", DurationSeconds: int32Ptr(240), TransitionDurationSeconds: 60},
+	}
+
+	t.Run("accumulates durations and transitions between items", func(t *testing.T) {
+		timing := ComputeTiming(items, nil)
+
+		wantStarts := []int{0, 210, 220}
+		wantEnds := []int{180, 210, 460}
+		for i, entry := range timing.Items {
+			if entry.StartOffsetSeconds != wantStarts[i] || entry.EndOffsetSeconds != wantEnds[i] {
+				t.Errorf("item %d: expected %d-%d, got %d-%d", entry.ItemID, wantStarts[i], wantEnds[i], entry.StartOffsetSeconds, entry.EndOffsetSeconds)
+			}
+		}
+		if timing.TotalDurationSeconds != 460 {
+			t.Errorf("expected total 460s, got %d", timing.TotalDurationSeconds)
+		}
+		if timing.TransitionsDurationSeconds != 40 {
+			t.Errorf("expected trailing transition to be ignored (40s), got %d", timing.TransitionsDurationSeconds)
+		}
+		if timing.UnknownDurationCount != 1 {
+			t.Errorf("expected 1 item with unknown duration, got %d", timing.UnknownDurationCount)
+		}
+		if timing.Items[0].StartsAt != nil || timing.PlannedEnd != nil {
+			t.Error("expected no wall-clock times without a planned start")
+		}
+	})
+
+	t.Run("turns offsets into wall-clock times", func(t *testing.T) {
+		start := time.Date(2025, 6, 21, 21, 0, 0, 0, time.UTC)
+		timing := ComputeTiming(items, &start)
+
+		if got := *timing.Items[2].StartsAt; !got.Equal(start.Add(220 * time.Second)) {
+			t.Errorf("unexpected start time for last item: %v", got)
+		}
+		if got := *timing.PlannedEnd; !got.Equal(start.Add(460 * time.Second)) {
+			t.Errorf("unexpected planned end: %v", got)
+		}
+	})
+
+	t.Run("reports per-set subtotals around breaks and encores", func(t *testing.T) {
+		encore := "Rappel"
+		sectioned := []model.SetlistItem{
+			{ID: 1, ItemType: model.ItemTypeSong, DurationSeconds: int32Ptr(200), TransitionDurationSeconds: 20},
+			{ID: 2, ItemType: model.ItemTypeSong, DurationSeconds: int32Ptr(100)},
+			{ID: 3, ItemType: model.ItemTypeIntermission, DurationSeconds: int32Ptr(900)},
+			{ID: 4, ItemType: model.ItemTypeSong, DurationSeconds: nil},
+			{ID: 5, ItemType: model.ItemTypeSong, DurationSeconds: int32Ptr(300)},
+			{ID: 6, ItemType: model.ItemTypeEncore, Label: &encore},
+			{ID: 7, ItemType: model.ItemTypeSong, DurationSeconds: int32Ptr(240)},
+		}
+
+		timing := ComputeTiming(sectioned, nil)
+
+		if len(timing.Sets) != 3 {
+			t.Fatalf("expected 3 sets, got %d: %+v", len(timing.Sets), timing.Sets)
+		}
+		if got := timing.Sets[0]; got.DurationSeconds != 320 || got.ItemCount != 2 {
+			t.Errorf("unexpected first set: %+v", got)
+		}
+		if got := timing.Sets[1]; got.DurationSeconds != 300 || got.UnknownDurationCount != 1 || got.Kind != SectionKindSet {
+			t.Errorf("unexpected second set: %+v", got)
+		}
+		if got := timing.Sets[2]; got.Kind != SectionKindEncore || got.Label == nil || *got.Label != encore || got.DurationSeconds != 240 {
+			t.Errorf("unexpected encore: %+v", got)
+		}
+		if timing.IntermissionsDurationSeconds != 900 || timing.TotalDurationSeconds != 1760 {
+			t.Errorf("unexpected totals: intermissions=%d total=%d", timing.IntermissionsDurationSeconds, timing.TotalDurationSeconds)
+		}
+		if timing.UnknownDurationCount != 1 {
+			t.Errorf("expected markers not to count as unknown durations, got %d", timing.UnknownDurationCount)
+		}
+	})
+
+	t.Run("times grouped items as one block", func(t *testing.T) {
+		groupID := 4
+		medley := "Medley 80s"
+		grouped := []model.SetlistItem{
+			{ID: 1, ItemType: model.ItemTypeSong, DurationSeconds: int32Ptr(200), TransitionDurationSeconds: 20},
+			{ID: 2, ItemType: model.ItemTypeSong, DurationSeconds: int32Ptr(90), TransitionDurationSeconds: 5, GroupID: &groupID, GroupName: &medley},
+			{ID: 3, ItemType: model.ItemTypeSong, DurationSeconds: nil, GroupID: &groupID, GroupName: &medley},
+			{ID: 4, ItemType: model.ItemTypeSong, DurationSeconds: int32Ptr(120), TransitionDurationSeconds: 30, GroupID: &groupID, GroupName: &medley},
+			{ID: 5, ItemType: model.ItemTypeSong, DurationSeconds: int32Ptr(180)},
+		}
+
+		timing := ComputeTiming(grouped, nil)
+
+		if len(timing.Groups) != 1 {
+			t.Fatalf("expected 1 group, got %+v", timing.Groups)
+		}
+		got := timing.Groups[0]
+		if got.GroupID != groupID || got.Name != medley || got.FirstItemID != 2 || len(got.ItemIDs) != 3 {
+			t.Errorf("unexpected group: %+v", got)
+		}
+		// The transitions between the songs of the medley belong to it, the
+		// one after its last song does not.
+		if got.StartOffsetSeconds != 220 || got.DurationSeconds != 215 || got.UnknownDurationCount != 1 {
+			t.Errorf("unexpected group timing: %+v", got)
+		}
+		if timing.Items[1].GroupID == nil || timing.Items[0].GroupID != nil {
+			t.Errorf("expected only grouped items to carry their group: %+v", timing.Items)
+		}
+	})
+
+	t.Run("handles an empty setlist", func(t *testing.T) {
+		timing := ComputeTiming(nil, nil)
+		if timing.TotalDurationSeconds != 0 || len(timing.Items) != 0 {
+			t.Errorf("expected empty timing, got %+v", timing)
+		}
+	})
+}
+
+func TestSetlistService_GetTiming_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSetlistRepository(ctrl)
+	svc := SetlistService{SetlistRepo: mockRepo}
+	ctx := context.Background()
+
+	mockRepo.EXPECT().GetSetlistByID(ctx, 10, 1).Return(model.Setlist{}, pgx.ErrNoRows)
+
+	if _, err := svc.GetTiming(ctx, 10, 1, nil); !errors.Is(err, ErrSetlistNotFound) {
+		t.Fatalf("expected ErrSetlistNotFound, got %v", err)
+	}
+}
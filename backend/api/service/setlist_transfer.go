@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"setlist/api/model"
+	"setlist/cache"
+)
+
+const (
+	TransferModeMove = "move"
+	TransferModeCopy = "copy"
+)
+
+// TransferItemsPayload moves or copies items of a setlist into another setlist
+// of the band, before the item at Position or at the end when it is omitted.
+type TransferItemsPayload struct {
+	ItemIDs         []int  `json:"item_ids"`
+	TargetSetlistID int    `json:"target_setlist_id"`
+	Position        *int   `json:"position"`
+	Mode            string `json:"mode"`
+}
+
+// TransferItems moves or copies items from one setlist to another in a single
+// transaction and returns the items created in the target. The items keep
+// their order in the source setlist, their notes, transitions and overrides.
+// Copying into the same setlist duplicates the items.
+func (s SetlistService) TransferItems(ctx context.Context, setlistID int, bandID int, userID int, payload TransferItemsPayload) ([]model.SetlistItem, error) {
+	if err := validateTransfer(setlistID, payload); err != nil {
+		return nil, err
+	}
+
+	for _, id := range []int{setlistID, payload.TargetSetlistID} {
+		if _, err := s.bandSetlist(ctx, id, bandID); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.SetlistRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Copying leaves the source setlist unchanged, so it may be locked.
+	move := payload.Mode == TransferModeMove
+	changed := []int{payload.TargetSetlistID}
+	if move {
+		changed = []int{setlistID, payload.TargetSetlistID}
+	}
+	if err := s.lockUnlocked(ctx, tx, changed...); err != nil {
+		return nil, err
+	}
+
+	items, err := s.SetlistRepo.GetItemsByIDs(ctx, tx, setlistID, payload.ItemIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) != len(payload.ItemIDs) {
+		return nil, ErrItemNotFound
+	}
+
+	if move {
+		if err := s.SetlistRepo.DeleteItems(ctx, tx, setlistID, payload.ItemIDs); err != nil {
+			return nil, err
+		}
+	}
+	created, err := s.SetlistRepo.InsertItems(ctx, tx, payload.TargetSetlistID, payload.Position, items)
+	if err != nil {
+		return nil, err
+	}
+
+	action := RevisionActionCopyItems
+	if move {
+		action = RevisionActionMoveItems
+		if err := s.recordRevision(ctx, tx, setlistID, userID, action); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.recordRevision(ctx, tx, payload.TargetSetlistID, userID, action); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	if move {
+		s.publishChange(SetlistEventItemsRemoved, SetlistChange{SetlistID: setlistID, AuthorID: userID, ItemIDs: payload.ItemIDs})
+	}
+	s.publishChange(SetlistEventItemsAdded, SetlistChange{SetlistID: payload.TargetSetlistID, AuthorID: userID, Items: created})
+	cache.Delete(ctx, s.Cache, cache.SetlistKey(bandID))
+	return created, nil
+}
+
+func validateTransfer(setlistID int, payload TransferItemsPayload) error {
+	if payload.Mode != TransferModeMove && payload.Mode != TransferModeCopy {
+		return &ValidationError{Msg: "Le mode doit être « move » ou « copy »."}
+	}
+	if payload.Mode == TransferModeMove && payload.TargetSetlistID == setlistID {
+		return &ValidationError{Msg: "Pour déplacer des éléments dans la même setlist, modifiez leur ordre."}
+	}
+	if len(payload.ItemIDs) == 0 || len(payload.ItemIDs) > maxBatchItems {
+		return &ValidationError{Msg: fmt.Sprintf("Vous pouvez transférer entre 1 et %d éléments à la fois.", maxBatchItems)}
+	}
+	if payload.Position != nil && *payload.Position < 0 {
+		return &ValidationError{Msg: "La position ne peut pas être négative."}
+	}
+	seen := make(map[int]bool, len(payload.ItemIDs))
+	for _, id := range payload.ItemIDs {
+		if seen[id] {
+			return &ValidationError{Msg: "Un élément apparaît plusieurs fois dans la liste."}
+		}
+		seen[id] = true
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"setlist/api/model"
+	"setlist/api/repository"
+	"setlist/api/repository/mocks"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestSetlistService_TransferItems(t *testing.T) {
+	ctx := context.Background()
+	bandID := 1
+
+	t.Run("moves the items and keeps their notes", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo, RevisionRepo: mockRevisionRepo}
+
+		position := 0
+		items := []model.SetlistItem{
+			{ID: 4, SetlistID: 10, Position: 1, ItemType: model.ItemTypeSong, SongID: int32Ptr(5), Notes: strPtr("Capo 2"), TransitionDurationSeconds: 20},
+			{ID: 3, SetlistID: 10, Position: 2, ItemType: model.ItemTypeSong, SongID: int32Ptr(6)},
+		}
+		mockRepo.EXPECT().GetSetlistByID(ctx, 10, bandID).Return(model.Setlist{ID: 10}, nil)
+		mockRepo.EXPECT().GetSetlistByID(ctx, 20, bandID).Return(model.Setlist{ID: 20}, nil)
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().LockSetlists(ctx, mockTx, 10, 20).Return(nil)
+		mockRepo.EXPECT().GetItemsByIDs(ctx, mockTx, 10, []int{3, 4}).Return(items, nil)
+		mockRepo.EXPECT().DeleteItems(ctx, mockTx, 10, []int{3, 4}).Return(nil)
+		mockRepo.EXPECT().InsertItems(ctx, mockTx, 20, &position, items).DoAndReturn(
+			func(_ context.Context, _ repository.DBTX, setlistID int, _ *int, items []model.SetlistItem) ([]model.SetlistItem, error) {
+				created := make([]model.SetlistItem, len(items))
+				for i, item := range items {
+					item.ID, item.SetlistID, item.Position = 100+i, setlistID, i
+					created[i] = item
+				}
+				return created, nil
+			})
+		mockRevisionRepo.EXPECT().Record(ctx, mockTx, 10, userID, RevisionActionMoveItems).Return(nil)
+		mockRevisionRepo.EXPECT().Record(ctx, mockTx, 20, userID, RevisionActionMoveItems).Return(nil)
+		mockTx.EXPECT().Commit(ctx).Return(nil)
+
+		created, err := svc.TransferItems(ctx, 10, bandID, userID, TransferItemsPayload{
+			ItemIDs: []int{3, 4}, TargetSetlistID: 20, Position: &position, Mode: TransferModeMove,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(created) != 2 || created[0].SetlistID != 20 || *created[0].Notes != "Capo 2" || created[0].TransitionDurationSeconds != 20 {
+			t.Errorf("unexpected created items: %+v", created)
+		}
+	})
+
+	t.Run("copies into the same setlist without deleting", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockRevisionRepo := mocks.NewMockSetlistRevisionRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo, RevisionRepo: mockRevisionRepo}
+
+		items := []model.SetlistItem{{ID: 3, SetlistID: 10, ItemType: model.ItemTypeEncore}}
+		mockRepo.EXPECT().GetSetlistByID(ctx, 10, bandID).Return(model.Setlist{ID: 10}, nil).Times(2)
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().LockSetlists(ctx, mockTx, 10).Return(nil)
+		mockRepo.EXPECT().GetItemsByIDs(ctx, mockTx, 10, []int{3}).Return(items, nil)
+		mockRepo.EXPECT().InsertItems(ctx, mockTx, 10, gomock.Nil(), items).Return([]model.SetlistItem{{ID: 9, SetlistID: 10}}, nil)
+		mockRevisionRepo.EXPECT().Record(ctx, mockTx, 10, userID, RevisionActionCopyItems).Return(nil)
+		mockTx.EXPECT().Commit(ctx).Return(nil)
+
+		if _, err := svc.TransferItems(ctx, 10, bandID, userID, TransferItemsPayload{ItemIDs: []int{3}, TargetSetlistID: 10, Mode: TransferModeCopy}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("rejects items from another setlist", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		mockTx := mocks.NewMockTx(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo}
+
+		mockRepo.EXPECT().GetSetlistByID(ctx, gomock.Any(), bandID).Return(model.Setlist{}, nil).Times(2)
+		mockRepo.EXPECT().BeginTx(ctx).Return(mockTx, nil)
+		mockTx.EXPECT().Rollback(ctx).Return(nil)
+		mockRepo.EXPECT().LockSetlists(ctx, mockTx, 20).Return(nil)
+		mockRepo.EXPECT().GetItemsByIDs(ctx, mockTx, 10, []int{3, 77}).Return([]model.SetlistItem{{ID: 3}}, nil)
+
+		_, err := svc.TransferItems(ctx, 10, bandID, userID, TransferItemsPayload{ItemIDs: []int{3, 77}, TargetSetlistID: 20, Mode: TransferModeCopy})
+		if !errors.Is(err, ErrItemNotFound) {
+			t.Fatalf("expected ErrItemNotFound, got %v", err)
+		}
+	})
+
+	t.Run("validates the payload", func(t *testing.T) {
+		svc := SetlistService{}
+		negative := -1
+		cases := map[string]TransferItemsPayload{
+			"unknown mode":      {ItemIDs: []int{3}, TargetSetlistID: 20, Mode: "link"},
+			"move in place":     {ItemIDs: []int{3}, TargetSetlistID: 10, Mode: TransferModeMove},
+			"no items":          {TargetSetlistID: 20, Mode: TransferModeCopy},
+			"duplicate items":   {ItemIDs: []int{3, 3}, TargetSetlistID: 20, Mode: TransferModeCopy},
+			"negative position": {ItemIDs: []int{3}, TargetSetlistID: 20, Mode: TransferModeCopy, Position: &negative},
+		}
+		for name, payload := range cases {
+			if _, err := svc.TransferItems(ctx, 10, bandID, userID, payload); !isValidationError(err) {
+				t.Errorf("%s: expected a ValidationError, got %v", name, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"log"
+	"setlist/api/model"
+	"setlist/cache"
+	"time"
+)
+
+// GetTrash returns the deleted setlists and templates of the band, last
+// deleted first.
+func (s SetlistService) GetTrash(ctx context.Context, bandID int) ([]model.Setlist, error) {
+	return s.SetlistRepo.GetTrashedSetlists(ctx, bandID)
+}
+
+// Restore takes a setlist out of the trash, with its items as they were.
+func (s SetlistService) Restore(ctx context.Context, setlistID int, bandID int) (model.Setlist, error) {
+	setlist, err := s.SetlistRepo.RestoreSetlist(ctx, setlistID, bandID)
+	if err != nil {
+		return model.Setlist{}, mapNotFound(err, ErrSetlistNotFound)
+	}
+
+	cache.Delete(ctx, s.Cache, cache.SetlistKey(bandID))
+	return setlist, nil
+}
+
+// Purge deletes a setlist of the trash for good. Setlists that are not in the
+// trash are not found.
+func (s SetlistService) Purge(ctx context.Context, setlistID int, bandID int) error {
+	return mapNotFound(s.SetlistRepo.PurgeSetlist(ctx, setlistID, bandID), ErrSetlistNotFound)
+}
+
+// PurgeExpiredTrash deletes for good the setlists that have been in the trash
+// for longer than the retention period and returns how many were deleted.
+func (s SetlistService) PurgeExpiredTrash(ctx context.Context, now time.Time) (int, error) {
+	if s.TrashRetention <= 0 {
+		return 0, nil
+	}
+	return s.SetlistRepo.PurgeTrashedBefore(ctx, now.Add(-s.TrashRetention))
+}
+
+// RunTrashPurge calls PurgeExpiredTrash every interval until ctx is done.
+func (s SetlistService) RunTrashPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		count, err := s.PurgeExpiredTrash(ctx, time.Now())
+		if err != nil {
+			log.Printf("[setlists] Échec de la purge de la corbeille : %v", err)
+		} else if count > 0 {
+			log.Printf("[setlists] %d setlist(s) supprimée(s) définitivement de la corbeille", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
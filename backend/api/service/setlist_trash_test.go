@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"setlist/api/model"
+	"setlist/api/repository/mocks"
+
+	"go.uber.org/mock/gomock"
+)
+
+func TestSetlistService_Restore(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSetlistRepository(ctrl)
+	svc := SetlistService{SetlistRepo: mockRepo}
+
+	mockRepo.EXPECT().RestoreSetlist(ctx, 10, 1).Return(model.Setlist{ID: 10, BandID: 1, Version: 4}, nil)
+	mockRepo.EXPECT().RestoreSetlist(ctx, 11, 1).Return(model.Setlist{}, sql.ErrNoRows)
+
+	restored, err := svc.Restore(ctx, 10, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if restored.ID != 10 || restored.Version != 4 {
+		t.Errorf("unexpected setlist: %+v", restored)
+	}
+	if _, err := svc.Restore(ctx, 11, 1); !errors.Is(err, ErrSetlistNotFound) {
+		t.Fatalf("expected ErrSetlistNotFound for a setlist not in the trash, got %v", err)
+	}
+}
+
+func TestSetlistService_Purge_NotInTrash(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockSetlistRepository(ctrl)
+	svc := SetlistService{SetlistRepo: mockRepo}
+
+	mockRepo.EXPECT().PurgeSetlist(ctx, 10, 1).Return(sql.ErrNoRows)
+
+	if err := svc.Purge(ctx, 10, 1); !errors.Is(err, ErrSetlistNotFound) {
+		t.Fatalf("expected ErrSetlistNotFound, got %v", err)
+	}
+}
+
+func TestSetlistService_PurgeExpiredTrash(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("purges what is older than the retention period", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSetlistRepository(ctrl)
+		svc := SetlistService{SetlistRepo: mockRepo, TrashRetention: 30 * 24 * time.Hour}
+
+		mockRepo.EXPECT().PurgeTrashedBefore(ctx, now.AddDate(0, 0, -30)).Return(2, nil)
+
+		count, err := svc.PurgeExpiredTrash(ctx, now)
+		if err != nil || count != 2 {
+			t.Fatalf("expected 2 purged setlists, got %d, %v", count, err)
+		}
+	})
+
+	t.Run("keeps everything without a retention period", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc := SetlistService{SetlistRepo: mocks.NewMockSetlistRepository(ctrl)}
+
+		if count, err := svc.PurgeExpiredTrash(ctx, now); err != nil || count != 0 {
+			t.Fatalf("expected nothing purged, got %d, %v", count, err)
+		}
+	})
+}
@@ -30,7 +30,12 @@ type CreateSongPayload struct {
 	Links           *string          `json:"links"`
 }
 
-type UpdateSongPayload = CreateSongPayload
+// UpdateSongPayload takes the version the client last read; the update is
+// rejected with a VersionConflictError when the song has changed since.
+type UpdateSongPayload struct {
+	CreateSongPayload
+	Version *int `json:"version"`
+}
 
 type SongService struct {
 	SongRepo repository.SongRepository
@@ -116,11 +121,16 @@ func (s SongService) Update(ctx context.Context, id int, bandID int, payload Upd
 		return model.Song{}, ErrSongTitleRequired
 	}
 
-	song := s.buildSong(bandID, payload)
+	song := s.buildSong(bandID, payload.CreateSongPayload)
 	song.ID = id
 
-	updated, err := s.SongRepo.UpdateSong(ctx, song)
+	updated, err := s.SongRepo.UpdateSong(ctx, song, payload.Version)
 	if err != nil {
+		if isNotFound(err) && payload.Version != nil {
+			if current, getErr := s.SongRepo.GetSongByID(ctx, id, bandID); getErr == nil {
+				return model.Song{}, &VersionConflictError{Current: current}
+			}
+		}
 		return model.Song{}, mapNotFound(err, ErrSongNotFound)
 	}
 
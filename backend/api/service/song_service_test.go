@@ -6,8 +6,10 @@ import (
 	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 
 	"setlist/api/model"
+	"setlist/api/repository"
 	"setlist/api/repository/mocks"
 
 	"github.com/jackc/pgx/v5"
@@ -107,7 +109,7 @@ func TestSongService_Update(t *testing.T) {
 	bandID := 1
 	newTitle := "Updated Title"
 	payload := UpdateSongPayload{
-		Title: newTitle,
+		CreateSongPayload: CreateSongPayload{Title: newTitle},
 	}
 
 	expectedSong := model.Song{
@@ -117,7 +119,7 @@ func TestSongService_Update(t *testing.T) {
 		Instrumentation: json.RawMessage("null"), // Default null if not provided
 	}
 
-	mockRepo.EXPECT().UpdateSong(ctx, expectedSong).Return(expectedSong, nil)
+	mockRepo.EXPECT().UpdateSong(ctx, expectedSong, gomock.Nil()).Return(expectedSong, nil)
 
 	updated, err := svc.Update(ctx, songID, bandID, payload)
 	if err != nil {
@@ -203,7 +205,7 @@ func TestSongService_Update_Errors(t *testing.T) {
 
 	t.Run("rejects empty title", func(t *testing.T) {
 		svc := SongService{}
-		_, err := svc.Update(ctx, 10, 1, UpdateSongPayload{Title: ""})
+		_, err := svc.Update(ctx, 10, 1, UpdateSongPayload{CreateSongPayload: CreateSongPayload{Title: ""}})
 		if !errors.Is(err, ErrSongTitleRequired) {
 			t.Fatalf("expected ErrSongTitleRequired, got %v", err)
 		}
@@ -216,9 +218,9 @@ func TestSongService_Update_Errors(t *testing.T) {
 		mockRepo := mocks.NewMockSongRepository(ctrl)
 		svc := SongService{SongRepo: mockRepo}
 
-		mockRepo.EXPECT().UpdateSong(ctx, gomock.Any()).Return(model.Song{}, pgx.ErrNoRows)
+		mockRepo.EXPECT().UpdateSong(ctx, gomock.Any(), gomock.Nil()).Return(model.Song{}, pgx.ErrNoRows)
 
-		_, err := svc.Update(ctx, 10, 1, UpdateSongPayload{Title: "Title"})
+		_, err := svc.Update(ctx, 10, 1, UpdateSongPayload{CreateSongPayload: CreateSongPayload{Title: "Title"}})
 		if !errors.Is(err, ErrSongNotFound) {
 			t.Fatalf("expected ErrSongNotFound, got %v", err)
 		}
@@ -232,13 +234,35 @@ func TestSongService_Update_Errors(t *testing.T) {
 		svc := SongService{SongRepo: mockRepo}
 
 		dbErr := errors.New("connection lost")
-		mockRepo.EXPECT().UpdateSong(ctx, gomock.Any()).Return(model.Song{}, dbErr)
+		mockRepo.EXPECT().UpdateSong(ctx, gomock.Any(), gomock.Nil()).Return(model.Song{}, dbErr)
 
-		_, err := svc.Update(ctx, 10, 1, UpdateSongPayload{Title: "Title"})
+		_, err := svc.Update(ctx, 10, 1, UpdateSongPayload{CreateSongPayload: CreateSongPayload{Title: "Title"}})
 		if !errors.Is(err, dbErr) {
 			t.Fatalf("expected raw db error, got %v", err)
 		}
 	})
+
+	t.Run("returns the current song when the version is stale", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSongRepository(ctrl)
+		svc := SongService{SongRepo: mockRepo}
+
+		stale := 2
+		current := model.Song{ID: 10, BandID: 1, Title: "Renamed", Version: 3}
+		mockRepo.EXPECT().UpdateSong(ctx, gomock.Any(), &stale).Return(model.Song{}, pgx.ErrNoRows)
+		mockRepo.EXPECT().GetSongByID(ctx, 10, 1).Return(current, nil)
+
+		_, err := svc.Update(ctx, 10, 1, UpdateSongPayload{CreateSongPayload: CreateSongPayload{Title: "Title"}, Version: &stale})
+		var conflict *VersionConflictError
+		if !errors.As(err, &conflict) {
+			t.Fatalf("expected a VersionConflictError, got %v", err)
+		}
+		if song, ok := conflict.Current.(model.Song); !ok || song.Version != 3 {
+			t.Errorf("expected the current song, got %+v", conflict.Current)
+		}
+	})
 }
 
 func TestSongService_SoftDelete_NotFound(t *testing.T) {
@@ -256,3 +280,62 @@ func TestSongService_SoftDelete_NotFound(t *testing.T) {
 		t.Fatalf("expected ErrSongNotFound, got %v", err)
 	}
 }
+
+func TestSongService_Stats(t *testing.T) {
+	ctx := context.Background()
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0)
+	query := SongStatsQuery{From: &from, To: &to, ExcludeArchived: true}
+	filter := repository.SongStatsFilter{From: &from, To: &to, ExcludeArchived: true}
+	stats := []model.SongStats{
+		{SongID: 1, Title: "Tube", TimesUsed: 5, SetlistCount: 4, SetlistShare: 0.8},
+		{SongID: 2, Title: "Rareté", TimesUsed: 1, SetlistCount: 1, SetlistShare: 0.2},
+		{SongID: 3, Title: "Oubliée"},
+	}
+
+	t.Run("reports used songs with their share of setlists", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSongRepository(ctrl)
+		svc := SongService{SongRepo: mockRepo}
+
+		mockRepo.EXPECT().GetSongStats(ctx, 1, filter).Return(stats, 6, nil)
+
+		report, err := svc.Stats(ctx, 1, query)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if report.SetlistCount != 6 || len(report.Songs) != 2 {
+			t.Fatalf("unexpected report: %+v", report)
+		}
+		if report.Songs[0].SetlistShare != 0.8 || report.Songs[1].SetlistShare != 0.2 {
+			t.Errorf("unexpected shares: %v, %v", report.Songs[0].SetlistShare, report.Songs[1].SetlistShare)
+		}
+	})
+
+	t.Run("reports songs never played", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockRepo := mocks.NewMockSongRepository(ctrl)
+		svc := SongService{SongRepo: mockRepo}
+
+		mockRepo.EXPECT().GetSongStats(ctx, 1, filter).Return(stats, 6, nil)
+
+		report, err := svc.NeverPlayed(ctx, 1, query)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(report.Songs) != 1 || report.Songs[0].SongID != 3 {
+			t.Errorf("unexpected report: %+v", report)
+		}
+	})
+
+	t.Run("rejects an empty period", func(t *testing.T) {
+		svc := SongService{}
+		if _, err := svc.Stats(ctx, 1, SongStatsQuery{From: &to, To: &from}); !isValidationError(err) {
+			t.Fatalf("expected a ValidationError, got %v", err)
+		}
+	})
+}
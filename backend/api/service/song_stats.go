@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"setlist/api/model"
+	"setlist/api/repository"
+	"time"
+)
+
+// SongStatsQuery restricts the statistics to the setlists used between From
+// and To (excluded), optionally leaving the archived ones out.
+type SongStatsQuery struct {
+	From            *time.Time
+	To              *time.Time
+	ExcludeArchived bool
+}
+
+// SongStatsReport lists songs with their usage over SetlistCount setlists.
+type SongStatsReport struct {
+	From         *time.Time        `json:"from"`
+	To           *time.Time        `json:"to"`
+	SetlistCount int               `json:"setlist_count"`
+	Songs        []model.SongStats `json:"songs"`
+}
+
+// Stats returns the songs used in the selected setlists, most used first.
+func (s SongService) Stats(ctx context.Context, bandID int, query SongStatsQuery) (SongStatsReport, error) {
+	return s.statsReport(ctx, bandID, query, func(stats model.SongStats) bool { return stats.TimesUsed > 0 })
+}
+
+// NeverPlayed returns the songs of the library absent from every selected
+// setlist.
+func (s SongService) NeverPlayed(ctx context.Context, bandID int, query SongStatsQuery) (SongStatsReport, error) {
+	return s.statsReport(ctx, bandID, query, func(stats model.SongStats) bool { return stats.TimesUsed == 0 })
+}
+
+func (s SongService) statsReport(ctx context.Context, bandID int, query SongStatsQuery, keep func(model.SongStats) bool) (SongStatsReport, error) {
+	if query.From != nil && query.To != nil && !query.To.After(*query.From) {
+		return SongStatsReport{}, &ValidationError{Msg: "La fin de la période doit être après son début."}
+	}
+
+	stats, setlistCount, err := s.SongRepo.GetSongStats(ctx, bandID, repository.SongStatsFilter{
+		From:            query.From,
+		To:              query.To,
+		ExcludeArchived: query.ExcludeArchived,
+	})
+	if err != nil {
+		return SongStatsReport{}, err
+	}
+
+	report := SongStatsReport{From: query.From, To: query.To, SetlistCount: setlistCount, Songs: make([]model.SongStats, 0)}
+	for _, song := range stats {
+		if !keep(song) {
+			continue
+		}
+		report.Songs = append(report.Songs, song)
+	}
+	return report, nil
+}
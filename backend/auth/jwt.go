@@ -13,8 +13,12 @@ import (
 const (
 	AccessTokenDuration  = 30 * time.Minute
 	RefreshTokenDuration = 30 * 24 * time.Hour
+	StreamTokenDuration  = time.Minute
 )
 
+// StreamTokenAudience marks the tokens that only open event streams.
+const StreamTokenAudience = "stream"
+
 type JWTClaims struct {
 	UserID int `json:"user_id"`
 	jwt.RegisteredClaims
@@ -33,6 +37,32 @@ func GenerateJWT(secretKey string, userID int) (string, error) {
 	return token.SignedString([]byte(secretKey))
 }
 
+// StreamClaims are carried by stream tokens, which are bound to a band.
+type StreamClaims struct {
+	UserID int `json:"user_id"`
+	BandID int `json:"band_id"`
+	jwt.RegisteredClaims
+}
+
+// GenerateStreamToken issues a token that opens the event streams of a band.
+// Browsers' EventSource cannot send headers, so this token travels in the
+// URL; it lives only long enough to connect so a logged URL is of little use.
+func GenerateStreamToken(secretKey string, userID int, bandID int) (string, time.Time, error) {
+	expiresAt := time.Now().Add(StreamTokenDuration)
+	claims := StreamClaims{
+		UserID: userID,
+		BandID: bandID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{StreamTokenAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secretKey))
+	return token, expiresAt, err
+}
+
 func GenerateRefreshToken() (string, error) {
 	bytes := make([]byte, 32)
 	if _, err := rand.Read(bytes); err != nil {
@@ -43,8 +43,10 @@ func ProfileKey(userID int, bandID int) string {
 	return fmt.Sprintf("user:%d:band:%d:profile", userID, bandID)
 }
 
+// SetlistKey names the hash of the cached setlist lists. The v2 prefix keeps
+// it apart from the plain string the key used to hold.
 func SetlistKey(bandID int) string {
-	return fmt.Sprintf("band:%d:setlists", bandID)
+	return fmt.Sprintf("band:%d:setlists:v2", bandID)
 }
 
 func Get(ctx context.Context, client *redis.Client, key string) (string, bool) {
@@ -75,3 +77,29 @@ func Delete(ctx context.Context, client *redis.Client, key string) {
 		log.Printf("[cache] Erreur lors de la suppression de la clé %s : %v\n", key, err)
 	}
 }
+
+// GetField reads one field of a hash. Storing the variants of a list as the
+// fields of one key lets Delete invalidate all of them at once.
+func GetField(ctx context.Context, client *redis.Client, key string, field string) (string, bool) {
+	if client == nil {
+		return "", false
+	}
+	val, err := client.HGet(ctx, key, field).Result()
+	if err != nil {
+		return "", false
+	}
+	return val, true
+}
+
+// SetField writes one field of a hash and restarts the ttl of the whole hash.
+func SetField(ctx context.Context, client *redis.Client, key string, field string, value string, ttl time.Duration) {
+	if client == nil {
+		return
+	}
+	pipe := client.TxPipeline()
+	pipe.HSet(ctx, key, field, value)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("[cache] Erreur lors de l'écriture du champ %s de la clé %s : %v\n", field, key, err)
+	}
+}
@@ -3,13 +3,22 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 )
 
+// defaultTrashRetentionDays is how long deleted setlists stay in the trash
+// when SETLIST_TRASH_RETENTION_DAYS is not set.
+const defaultTrashRetentionDays = 30
+
 type Config struct {
 	DatabaseURL      string
 	JWTSecret        string
 	RateLimitEnabled bool
 	RedisURL         string
+	// TrashRetention is how long deleted setlists are kept before being
+	// purged; zero disables the automatic purge.
+	TrashRetention time.Duration
 }
 
 func Load() Config {
@@ -28,10 +37,20 @@ func Load() Config {
 		rateLimitEnabled = false
 	}
 
+	retentionDays := defaultTrashRetentionDays
+	if val := os.Getenv("SETLIST_TRASH_RETENTION_DAYS"); val != "" {
+		days, err := strconv.Atoi(val)
+		if err != nil || days < 0 {
+			log.Fatalf("SETLIST_TRASH_RETENTION_DAYS must be a number of days, got %q", val)
+		}
+		retentionDays = days
+	}
+
 	return Config{
 		DatabaseURL:      dbURL,
 		JWTSecret:        jwtSecret,
 		RateLimitEnabled: rateLimitEnabled,
 		RedisURL:         os.Getenv("REDIS_URL"),
+		TrashRetention:   time.Duration(retentionDays) * 24 * time.Hour,
 	}
 }
@@ -0,0 +1,3 @@
+ALTER TABLE setlist_items DROP COLUMN IF EXISTS notes_private;
+
+DROP TABLE IF EXISTS setlist_shares;
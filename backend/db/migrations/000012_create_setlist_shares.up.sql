@@ -0,0 +1,12 @@
+CREATE TABLE setlist_shares (
+    id         SERIAL PRIMARY KEY,
+    token      VARCHAR(255) NOT NULL UNIQUE,
+    setlist_id INT          NOT NULL REFERENCES setlists(id) ON DELETE CASCADE,
+    created_by INT          REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ  NOT NULL
+);
+
+CREATE INDEX idx_setlist_shares_setlist_id ON setlist_shares(setlist_id);
+
+ALTER TABLE setlist_items ADD COLUMN notes_private BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,42 @@
+CREATE TABLE setlist_revisions (
+    id         SERIAL PRIMARY KEY,
+    setlist_id INT         NOT NULL REFERENCES setlists(id) ON DELETE CASCADE,
+    author_id  INT         REFERENCES users(id) ON DELETE SET NULL,
+    action     VARCHAR(50) NOT NULL,
+    snapshot   JSONB       NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_setlist_revisions_setlist_id ON setlist_revisions(setlist_id, created_at DESC);
+
+-- Existing setlists start their history with their current state.
+INSERT INTO setlist_revisions (setlist_id, author_id, action, snapshot)
+SELECT s.id, NULL, 'baseline', jsonb_build_object(
+    'name', s.name,
+    'color', s.color,
+    'is_archived', s.is_archived,
+    'items', COALESCE((
+        SELECT jsonb_agg(jsonb_build_object(
+            'id', si.id,
+            'position', si.position,
+            'item_type', si.item_type,
+            'song_id', si.song_id,
+            'interlude_id', si.interlude_id,
+            'notes', si.notes,
+            'notes_private', si.notes_private,
+            'transition_duration_seconds', si.transition_duration_seconds,
+            'label', si.label,
+            'item_duration_seconds', si.duration_seconds,
+            'title', COALESCE(so.title, i.title, si.label),
+            'duration_seconds', COALESCE(si.duration_seconds, so.duration_seconds, i.duration_seconds),
+            'tempo', so.tempo,
+            'speaker', i.speaker,
+            'song_key', so.song_key
+        ) ORDER BY si.position)
+        FROM setlist_items si
+        LEFT JOIN songs so ON si.song_id = so.id
+        LEFT JOIN interludes i ON si.interlude_id = i.id
+        WHERE si.setlist_id = s.id
+    ), '[]'::jsonb)
+)
+FROM setlists s;
@@ -0,0 +1,7 @@
+CREATE TABLE live_sessions (
+    setlist_id      INT         PRIMARY KEY REFERENCES setlists(id) ON DELETE CASCADE,
+    current_item_id INT         REFERENCES setlist_items(id) ON DELETE SET NULL,
+    started_by      INT         REFERENCES users(id) ON DELETE SET NULL,
+    started_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    item_started_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+ALTER TABLE setlists ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE songs ADD COLUMN version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,18 @@
+DELETE FROM setlist_items WHERE item_type = 'placeholder';
+DELETE FROM setlists WHERE is_template;
+
+ALTER TABLE setlist_items DROP CONSTRAINT setlist_items_item_type_check;
+ALTER TABLE setlist_items DROP CONSTRAINT chk_item_is_defined;
+
+ALTER TABLE setlist_items ADD CONSTRAINT setlist_items_item_type_check
+    CHECK (item_type IN ('song', 'interlude', 'set_break', 'encore', 'intermission'));
+
+ALTER TABLE setlist_items ADD CONSTRAINT chk_item_is_defined CHECK (
+    (item_type = 'song' AND song_id IS NOT NULL AND interlude_id IS NULL)
+        OR
+    (item_type = 'interlude' AND interlude_id IS NOT NULL AND song_id IS NULL)
+        OR
+    (item_type IN ('set_break', 'encore', 'intermission') AND song_id IS NULL AND interlude_id IS NULL)
+);
+
+ALTER TABLE setlists DROP COLUMN is_template;
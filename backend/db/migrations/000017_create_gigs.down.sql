@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS gig_setlists;
+DROP TABLE IF EXISTS gigs;
@@ -0,0 +1,28 @@
+CREATE TABLE gigs (
+    id                 SERIAL PRIMARY KEY,
+    band_id            INT          NOT NULL REFERENCES bands(id) ON DELETE CASCADE,
+    name               VARCHAR(255) NOT NULL,
+    venue              VARCHAR(255),
+    address            TEXT,
+    starts_at          TIMESTAMPTZ  NOT NULL,
+    load_in_at         TIMESTAMPTZ,
+    soundcheck_at      TIMESTAMPTZ,
+    status             VARCHAR(20)  NOT NULL DEFAULT 'tentative'
+        CHECK (status IN ('tentative', 'confirmed', 'cancelled')),
+    notes              TEXT,
+    setlists_archived  BOOLEAN      NOT NULL DEFAULT FALSE,
+    created_at         TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_gigs_band_id_starts_at ON gigs(band_id, starts_at);
+
+CREATE TABLE gig_setlists (
+    gig_id     INT         NOT NULL REFERENCES gigs(id) ON DELETE CASCADE,
+    setlist_id INT         NOT NULL REFERENCES setlists(id) ON DELETE CASCADE,
+    position   INT         NOT NULL,
+    starts_at  TIMESTAMPTZ,
+    PRIMARY KEY (gig_id, setlist_id)
+);
+
+CREATE INDEX idx_gig_setlists_setlist_id ON gig_setlists(setlist_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS calendar_feeds;
+
+ALTER TABLE gigs DROP COLUMN IF EXISTS kind;
@@ -0,0 +1,10 @@
+ALTER TABLE gigs ADD COLUMN kind VARCHAR(20) NOT NULL DEFAULT 'gig'
+    CHECK (kind IN ('gig', 'rehearsal'));
+
+CREATE TABLE calendar_feeds (
+    user_id    INT         NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    band_id    INT         NOT NULL REFERENCES bands(id) ON DELETE CASCADE,
+    token      VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, band_id)
+);
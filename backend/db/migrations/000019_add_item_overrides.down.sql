@@ -0,0 +1,5 @@
+ALTER TABLE setlist_items DROP CONSTRAINT chk_transition_duration_positive;
+ALTER TABLE setlist_items ALTER COLUMN transition_duration_seconds DROP NOT NULL;
+
+ALTER TABLE setlist_items DROP COLUMN song_key;
+ALTER TABLE setlist_items DROP COLUMN tempo;
@@ -0,0 +1,8 @@
+-- Per-item overrides of the song's tempo and key; the duration override reuses
+-- setlist_items.duration_seconds.
+ALTER TABLE setlist_items ADD COLUMN tempo INT;
+ALTER TABLE setlist_items ADD COLUMN song_key VARCHAR(10);
+
+UPDATE setlist_items SET transition_duration_seconds = 0 WHERE transition_duration_seconds IS NULL;
+ALTER TABLE setlist_items ALTER COLUMN transition_duration_seconds SET NOT NULL;
+ALTER TABLE setlist_items ADD CONSTRAINT chk_transition_duration_positive CHECK (transition_duration_seconds >= 0);
@@ -0,0 +1,3 @@
+DELETE FROM setlists WHERE deleted_at IS NOT NULL;
+DROP INDEX idx_setlists_deleted_at;
+ALTER TABLE setlists DROP COLUMN deleted_at;
@@ -0,0 +1,4 @@
+-- Deleted setlists go to the trash: they are hidden until restored, or purged
+-- for good once the retention period is over.
+ALTER TABLE setlists ADD COLUMN deleted_at TIMESTAMPTZ;
+CREATE INDEX idx_setlists_deleted_at ON setlists (deleted_at) WHERE deleted_at IS NOT NULL;
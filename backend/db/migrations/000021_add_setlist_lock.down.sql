@@ -0,0 +1,3 @@
+ALTER TABLE setlists DROP COLUMN locked_by;
+ALTER TABLE setlists DROP COLUMN locked_at;
+ALTER TABLE setlists DROP COLUMN is_locked;
@@ -0,0 +1,5 @@
+-- A locked setlist is final: its items cannot change until an admin unlocks
+-- it. locked_by and locked_at tell who locked it and when.
+ALTER TABLE setlists ADD COLUMN is_locked BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE setlists ADD COLUMN locked_at TIMESTAMPTZ;
+ALTER TABLE setlists ADD COLUMN locked_by INT REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE setlist_items DROP COLUMN group_id;
+DROP TABLE setlist_item_groups;
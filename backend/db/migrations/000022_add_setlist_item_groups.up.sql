@@ -0,0 +1,14 @@
+-- A group ties consecutive items of a setlist together, e.g. the songs of a
+-- medley, so they are moved, timed and printed as one block. Items keep their
+-- own positions; the members of a group are always next to each other.
+CREATE TABLE setlist_item_groups (
+    id SERIAL PRIMARY KEY,
+    setlist_id INT NOT NULL REFERENCES setlists(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_setlist_item_groups_setlist_id ON setlist_item_groups(setlist_id);
+
+ALTER TABLE setlist_items ADD COLUMN group_id INT REFERENCES setlist_item_groups(id) ON DELETE SET NULL;
+CREATE INDEX idx_setlist_items_group_id ON setlist_items(group_id) WHERE group_id IS NOT NULL;
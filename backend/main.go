@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,6 +12,8 @@ import (
 	"setlist/cache"
 	"setlist/config"
 	"setlist/db"
+	"setlist/realtime"
+	"time"
 )
 
 func main() {
@@ -19,6 +22,7 @@ func main() {
 	defer dbPool.Close()
 
 	redisClient := cache.NewClient(cfg.RedisURL)
+	broker := realtime.NewBroker(redisClient)
 
 	userRepo := &repository.PgUserRepository{DB: dbPool}
 	refreshTokenRepo := &repository.PgRefreshTokenRepository{DB: dbPool}
@@ -48,8 +52,36 @@ func main() {
 	songHandler := handler.SongHandler{SongService: songService}
 
 	setlistRepo := &repository.PgSetlistRepository{DB: dbPool}
-	setlistService := service.SetlistService{SetlistRepo: setlistRepo, InterludeRepo: interludeRepo, SongRepo: songRepo, Cache: redisClient}
-	setlistHandler := handler.SetlistHandler{SetlistService: setlistService}
+	revisionRepo := &repository.PgSetlistRevisionRepository{DB: dbPool}
+	setlistService := service.SetlistService{
+		SetlistRepo:    setlistRepo,
+		InterludeRepo:  interludeRepo,
+		SongRepo:       songRepo,
+		RevisionRepo:   revisionRepo,
+		UserRepo:       userRepo,
+		Cache:          redisClient,
+		Broker:         broker,
+		TrashRetention: cfg.TrashRetention,
+	}
+	go setlistService.RunTrashPurge(context.Background(), time.Hour)
+	setlistHandler := handler.SetlistHandler{SetlistService: setlistService, Broker: broker}
+
+	shareRepo := &repository.PgSetlistShareRepository{DB: dbPool}
+	shareService := service.SetlistShareService{ShareRepo: shareRepo, SetlistRepo: setlistRepo}
+	shareHandler := handler.SetlistShareHandler{ShareService: shareService}
+
+	liveRepo := &repository.PgLiveSessionRepository{DB: dbPool}
+	liveService := service.LiveService{LiveRepo: liveRepo, SetlistRepo: setlistRepo, Broker: broker}
+	liveHandler := handler.LiveHandler{LiveService: liveService, Broker: broker}
+
+	gigRepo := &repository.PgGigRepository{DB: dbPool}
+	gigService := service.GigService{GigRepo: gigRepo, SetlistRepo: setlistRepo, Cache: redisClient}
+	gigHandler := handler.GigHandler{GigService: gigService}
+	go gigService.RunAutoArchive(context.Background(), time.Hour)
+
+	calendarRepo := &repository.PgCalendarFeedRepository{DB: dbPool}
+	calendarService := service.CalendarService{FeedRepo: calendarRepo, GigRepo: gigRepo, SetlistRepo: setlistRepo}
+	calendarHandler := handler.CalendarHandler{CalendarService: calendarService}
 
 	invitationRepo := &repository.PgInvitationRepository{DB: dbPool}
 	invitationService := service.InvitationService{InvitationRepo: invitationRepo, UserRepo: userRepo}
@@ -57,6 +89,7 @@ func main() {
 
 	authMiddleware := middleware.JWTAuth(cfg.JWTSecret, userRepo)
 	authMiddlewareUserOnly := middleware.JWTAuthUserOnly(cfg.JWTSecret)
+	streamMiddleware := middleware.StreamAuth(cfg.JWTSecret, userRepo)
 	adminMiddleware := middleware.AdminOnly(userRepo)
 	rateLimiter := middleware.NewRateLimiter(cfg.RateLimitEnabled)
 
@@ -66,6 +99,7 @@ func main() {
 	mux.Handle("/api/auth/signup", rateLimiter.LimitMiddleware(handler.Wrap(userHandler.Signup)))
 	mux.Handle("/api/auth/refresh", handler.Wrap(authHandler.RefreshToken))
 	mux.Handle("/api/auth/logout", handler.Wrap(authHandler.Logout))
+	mux.Handle("POST /api/stream-token", authMiddleware(handler.Wrap(authHandler.CreateStreamToken)))
 	mux.Handle("PUT /api/user/password", authMiddlewareUserOnly(handler.Wrap(userHandler.UpdatePassword)))
 	mux.Handle("GET /api/user/info", authMiddlewareUserOnly(handler.Wrap(infoHandler.GetCurrentUserInfo)))
 	mux.Handle("GET /api/user/search", authMiddlewareUserOnly(handler.Wrap(userHandler.SearchUsers)))
@@ -84,18 +118,67 @@ func main() {
 
 	mux.Handle("POST /api/setlist", authMiddleware(handler.Wrap(setlistHandler.CreateSetlist)))
 	mux.Handle("GET /api/setlist", authMiddleware(handler.Wrap(setlistHandler.GetSetlists)))
+	mux.Handle("POST /api/setlist/generate", authMiddleware(handler.Wrap(setlistHandler.GenerateSetlist)))
+	mux.Handle("POST /api/setlist/repair-positions", authMiddleware(adminMiddleware(handler.Wrap(setlistHandler.RepairPositions))))
+	mux.Handle("GET /api/setlist/trash", authMiddleware(handler.Wrap(setlistHandler.GetTrash)))
+	mux.Handle("POST /api/setlist/{id}/lock", authMiddleware(adminMiddleware(handler.Wrap(setlistHandler.LockSetlist))))
+	mux.Handle("POST /api/setlist/{id}/unlock", authMiddleware(adminMiddleware(handler.Wrap(setlistHandler.UnlockSetlist))))
+	mux.Handle("POST /api/setlist/{id}/restore", authMiddleware(handler.Wrap(setlistHandler.RestoreSetlist)))
+	mux.Handle("DELETE /api/setlist/{id}/purge", authMiddleware(adminMiddleware(handler.Wrap(setlistHandler.PurgeSetlist))))
 	mux.Handle("GET /api/setlist/{id}", authMiddleware(handler.Wrap(setlistHandler.GetSetlistDetails)))
+	mux.Handle("GET /api/setlist/{id}/timing", authMiddleware(handler.Wrap(setlistHandler.GetSetlistTiming)))
+	mux.Handle("GET /api/setlist/{id}/diff", authMiddleware(handler.Wrap(setlistHandler.GetSetlistDiff)))
+	mux.Handle("GET /api/setlist/{id}/export.pdf", authMiddleware(handler.Wrap(setlistHandler.ExportSetlistPDF)))
+	mux.Handle("GET /api/setlist/{id}/export", authMiddleware(handler.Wrap(setlistHandler.ExportSetlist)))
 	mux.Handle("PUT /api/setlist/{id}", authMiddleware(adminMiddleware(handler.Wrap(setlistHandler.UpdateSetlist))))
 	mux.Handle("DELETE /api/setlist/{id}", authMiddleware(adminMiddleware(handler.Wrap(setlistHandler.DeleteSetlist))))
 
 	mux.Handle("POST /api/setlist/{id}/duplicate", authMiddleware(adminMiddleware(handler.Wrap(setlistHandler.DuplicateSetlist))))
+	mux.Handle("POST /api/setlist/{id}/copy-to-band", authMiddleware(handler.Wrap(setlistHandler.CopyToBand)))
+	mux.Handle("POST /api/setlist/{id}/template", authMiddleware(adminMiddleware(handler.Wrap(setlistHandler.SaveAsTemplate))))
+	mux.Handle("GET /api/templates", authMiddleware(handler.Wrap(setlistHandler.GetTemplates)))
 	mux.Handle("POST /api/setlist/{id}/items", authMiddleware(handler.Wrap(setlistHandler.AddItem)))
+	mux.Handle("POST /api/setlist/{id}/items/batch", authMiddleware(handler.Wrap(setlistHandler.AddItems)))
+	mux.Handle("POST /api/setlist/{id}/items/transfer", authMiddleware(handler.Wrap(setlistHandler.TransferItems)))
 	mux.Handle("PUT /api/setlist/{id}/items/order", authMiddleware(handler.Wrap(setlistHandler.UpdateItemOrder)))
 	mux.Handle("PUT /api/setlist/item/{itemId}", authMiddleware(handler.Wrap(setlistHandler.UpdateItem)))
 	mux.Handle("DELETE /api/setlist/item/{itemId}", authMiddleware(handler.Wrap(setlistHandler.DeleteItem)))
+	mux.Handle("POST /api/setlist/{id}/groups", authMiddleware(handler.Wrap(setlistHandler.CreateItemGroup)))
+	mux.Handle("PUT /api/setlist/{id}/groups/{groupId}", authMiddleware(handler.Wrap(setlistHandler.RenameItemGroup)))
+	mux.Handle("DELETE /api/setlist/{id}/groups/{groupId}", authMiddleware(handler.Wrap(setlistHandler.DeleteItemGroup)))
+	mux.Handle("GET /api/setlist/{id}/events", streamMiddleware(handler.Wrap(setlistHandler.StreamSetlistEvents)))
+	mux.Handle("GET /api/setlist/{id}/revisions", authMiddleware(handler.Wrap(setlistHandler.GetRevisions)))
+	mux.Handle("GET /api/setlist/{id}/revisions/{revisionId}", authMiddleware(handler.Wrap(setlistHandler.GetRevision)))
+	mux.Handle("POST /api/setlist/{id}/revisions/{revisionId}/restore", authMiddleware(adminMiddleware(handler.Wrap(setlistHandler.RestoreRevision))))
+
+	mux.Handle("GET /api/setlist/{id}/shares", authMiddleware(handler.Wrap(shareHandler.GetShares)))
+	mux.Handle("POST /api/setlist/{id}/shares", authMiddleware(adminMiddleware(handler.Wrap(shareHandler.CreateShare))))
+	mux.Handle("DELETE /api/setlist/{id}/shares", authMiddleware(adminMiddleware(handler.Wrap(shareHandler.RevokeShares))))
+	mux.Handle("DELETE /api/setlist/{id}/shares/{token}", authMiddleware(adminMiddleware(handler.Wrap(shareHandler.RevokeShares))))
+	mux.Handle("GET /api/shared/{token}", handler.Wrap(shareHandler.GetSharedSetlist))
+
+	mux.Handle("GET /api/setlist/{id}/live", authMiddleware(handler.Wrap(liveHandler.GetLiveState)))
+	mux.Handle("GET /api/setlist/{id}/live/stream", streamMiddleware(handler.Wrap(liveHandler.StreamLive)))
+	mux.Handle("POST /api/setlist/{id}/live/start", authMiddleware(adminMiddleware(handler.Wrap(liveHandler.StartLive))))
+	mux.Handle("POST /api/setlist/{id}/live/next", authMiddleware(adminMiddleware(handler.Wrap(liveHandler.NextLiveItem))))
+	mux.Handle("POST /api/setlist/{id}/live/previous", authMiddleware(adminMiddleware(handler.Wrap(liveHandler.PreviousLiveItem))))
+	mux.Handle("POST /api/setlist/{id}/live/jump", authMiddleware(adminMiddleware(handler.Wrap(liveHandler.JumpLiveItem))))
+	mux.Handle("POST /api/setlist/{id}/live/stop", authMiddleware(adminMiddleware(handler.Wrap(liveHandler.StopLive))))
+
+	mux.Handle("GET /api/gigs", authMiddleware(handler.Wrap(gigHandler.GetGigs)))
+	mux.Handle("POST /api/gigs", authMiddleware(adminMiddleware(handler.Wrap(gigHandler.CreateGig))))
+	mux.Handle("GET /api/gigs/{id}", authMiddleware(handler.Wrap(gigHandler.GetGig)))
+	mux.Handle("PUT /api/gigs/{id}", authMiddleware(adminMiddleware(handler.Wrap(gigHandler.UpdateGig))))
+	mux.Handle("DELETE /api/gigs/{id}", authMiddleware(adminMiddleware(handler.Wrap(gigHandler.DeleteGig))))
+
+	mux.Handle("GET /api/calendar/feed", authMiddleware(handler.Wrap(calendarHandler.GetCalendarFeed)))
+	mux.Handle("POST /api/calendar/feed/regenerate", authMiddleware(handler.Wrap(calendarHandler.RegenerateCalendarFeed)))
+	mux.Handle("GET /api/calendar/{file}", handler.Wrap(calendarHandler.GetCalendar))
 
 	mux.Handle("POST /api/song", authMiddleware(handler.Wrap(songHandler.CreateSong)))
 	mux.Handle("GET /api/song", authMiddleware(handler.Wrap(songHandler.GetSongs)))
+	mux.Handle("GET /api/song/stats", authMiddleware(handler.Wrap(songHandler.GetSongStats)))
+	mux.Handle("GET /api/song/stats/never-played", authMiddleware(handler.Wrap(songHandler.GetNeverPlayedSongs)))
 	mux.Handle("GET /api/song/{id}", authMiddleware(handler.Wrap(songHandler.GetSong)))
 	mux.Handle("PUT /api/song/{id}", authMiddleware(handler.Wrap(songHandler.UpdateSong)))
 	mux.Handle("DELETE /api/song/{id}", authMiddleware(handler.Wrap(songHandler.DeleteSong)))
@@ -0,0 +1,171 @@
+// Package pdf writes simple text documents in pure Go. It only relies on the
+// standard Helvetica fonts every PDF reader ships with, so nothing has to be
+// embedded and no external service is involved.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A4 page size in points.
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+type Font int
+
+const (
+	Regular Font = iota
+	Bold
+	Italic
+)
+
+var fontNames = map[Font]string{
+	Regular: "Helvetica",
+	Bold:    "Helvetica-Bold",
+	Italic:  "Helvetica-Oblique",
+}
+
+type Color struct{ R, G, B uint8 }
+
+var Black = Color{0, 0, 0}
+
+// Hex parses a #rrggbb color; malformed values give black.
+func Hex(s string) Color {
+	var c Color
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return Black
+	}
+	return c
+}
+
+type Document struct {
+	pages []*bytes.Buffer
+}
+
+func New() *Document {
+	return &Document{}
+}
+
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+func (d *Document) PageCount() int {
+	return len(d.pages)
+}
+
+func (d *Document) current() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// Text draws s with its baseline at (x, y), measured in points from the
+// top-left corner of the current page.
+func (d *Document) Text(x, y float64, font Font, size float64, color Color, s string) {
+	fmt.Fprintf(d.current(), "BT /F%d %.2f Tf %s rg %.2f %.2f Td (%s) Tj ET\n",
+		int(font)+1, size, color.operands(), x, PageHeight-y, escape(encode(s)))
+}
+
+// FillRect paints a rectangle whose top-left corner is at (x, y).
+func (d *Document) FillRect(x, y, w, h float64, color Color) {
+	fmt.Fprintf(d.current(), "%s rg %.2f %.2f %.2f %.2f re f\n",
+		color.operands(), x, PageHeight-y-h, w, h)
+}
+
+// Line strokes a straight line between two points.
+func (d *Document) Line(x1, y1, x2, y2, width float64, color Color) {
+	fmt.Fprintf(d.current(), "%s RG %.2f w %.2f %.2f m %.2f %.2f l S\n",
+		color.operands(), width, x1, PageHeight-y1, x2, PageHeight-y2)
+}
+
+func (c Color) operands() string {
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+}
+
+// WriteTo serializes the document. An empty document still gets one blank
+// page so the output is always a valid PDF.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	out := &bytes.Buffer{}
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-2 are the catalog and page tree, 3-5 the fonts, then each
+	// page is followed by its content stream.
+	firstPage := 6
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	for _, font := range []Font{Regular, Bold, Italic} {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", fontNames[font]))
+	}
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R /F3 5 0 R >> >> /Contents %d 0 R >>",
+			PageWidth, PageHeight, firstPage+2*i+1,
+		))
+
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(page.Bytes()); err != nil {
+			return 0, err
+		}
+		if err := zw.Close(); err != nil {
+			return 0, err
+		}
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.WriteTo(w)
+}
+
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := d.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func escape(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		switch c {
+		case '\\', '(', ')':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case '\n', '\r', '\t':
+			sb.WriteByte(' ')
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
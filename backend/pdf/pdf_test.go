@@ -0,0 +1,111 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDocument_XrefPointsAtObjects(t *testing.T) {
+	doc := New()
+	doc.Text(40, 60, Bold, 20, Black, "Première page")
+	doc.AddPage()
+	doc.FillRect(40, 40, 100, 20, Hex("#FBBF24"))
+	doc.Text(40, 60, Regular, 12, Black, "Deuxième page")
+
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	if startxref == nil {
+		t.Fatal("missing startxref")
+	}
+	xrefAt, _ := strconv.Atoi(string(startxref[1]))
+	if !bytes.HasPrefix(out[xrefAt:], []byte("xref\n")) {
+		t.Fatalf("startxref does not point at the xref table")
+	}
+
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out[xrefAt:], -1)
+	// Catalog, page tree, three fonts and a page plus its content per page.
+	if len(entries) != 9 {
+		t.Fatalf("expected 9 objects, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		want := fmt.Sprintf("%d 0 obj", i+1)
+		if !bytes.HasPrefix(out[offset:], []byte(want)) {
+			t.Errorf("xref entry %d does not point at %q", i+1, want)
+		}
+	}
+	if !bytes.Contains(out, []byte("/Count 2")) {
+		t.Error("expected the page tree to count 2 pages")
+	}
+}
+
+func TestDocument_EncodesTextAsWinAnsi(t *testing.T) {
+	doc := New()
+	doc.Text(0, 0, Regular, 10, Black, "Entracte (é) \\ 5€")
+
+	out, _ := doc.Bytes()
+	content := firstStream(t, out)
+	if !bytes.Contains(content, []byte("(Entracte \\(\xe9\\) \\\\ 5\x80) Tj")) {
+		t.Errorf("unexpected content stream: %q", content)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	lines := WrapText(Regular, 10, "un deux trois quatre cinq six\nsept", TextWidth(Regular, 10, "quatre cinq six"))
+	want := []string{"un deux trois", "quatre cinq six", "sept"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %q, got %q", want, lines)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	long := "Bohemian Rhapsody (version longue)"
+	max := TextWidth(Bold, 12, "Bohemian Rhap")
+	got := Truncate(Bold, 12, long, max)
+	if !strings.HasSuffix(got, "…") || TextWidth(Bold, 12, got) > max {
+		t.Errorf("unexpected truncation %q", got)
+	}
+	if Truncate(Bold, 12, "Court", max) != "Court" {
+		t.Error("expected short text to be left untouched")
+	}
+}
+
+func TestLatin1BasesCoverUpperHalf(t *testing.T) {
+	if len(latin1Bases) != 64 {
+		t.Fatalf("expected 64 base letters, got %d", len(latin1Bases))
+	}
+	if TextWidth(Regular, 1000, "é") != TextWidth(Regular, 1000, "e") {
+		t.Error("expected accented letters to be measured as their base letter")
+	}
+}
+
+func firstStream(t *testing.T, out []byte) []byte {
+	t.Helper()
+	start := bytes.Index(out, []byte("stream\n"))
+	end := bytes.Index(out, []byte("\nendstream"))
+	if start < 0 || end < 0 {
+		t.Fatal("no content stream found")
+	}
+	r, err := zlib.NewReader(bytes.NewReader(out[start+len("stream\n") : end]))
+	if err != nil {
+		t.Fatalf("content stream is not zlib data: %v", err)
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("could not inflate content stream: %v", err)
+	}
+	return content
+}
@@ -0,0 +1,118 @@
+package pdf
+
+import "strings"
+
+// Extra characters of the Windows-1252 code page; U+00A0 to U+00FF map to
+// themselves.
+var winAnsiExtras = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+	'♭': 'b', '♯': '#',
+}
+
+// encode converts s to WinAnsiEncoding; characters the standard fonts cannot
+// draw become '?'.
+func encode(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r < 0x80:
+			out = append(out, byte(r))
+		case r >= 0xA0 && r <= 0xFF:
+			out = append(out, byte(r))
+		default:
+			if b, ok := winAnsiExtras[r]; ok {
+				out = append(out, b)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
+
+// Glyph widths of the printable ASCII range, in thousandths of the font size.
+var regularWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var boldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// Accented Latin-1 letters are measured as their base letter, which is close
+// enough for line breaking.
+const latin1Bases = "AAAAAAACEEEEIIIIDNOOOOOxOUUUUYPsaaaaaaaceeeeiiiidnooooo/ouuuuypy"
+
+func charWidth(font Font, c byte) int {
+	widths := &regularWidths
+	if font == Bold {
+		widths = &boldWidths
+	}
+	switch {
+	case c >= 32 && c < 127:
+		return widths[c-32]
+	case c >= 0xC0:
+		return widths[latin1Bases[c-0xC0]-32]
+	default:
+		return 556
+	}
+}
+
+// TextWidth returns the width of s in points.
+func TextWidth(font Font, size float64, s string) float64 {
+	total := 0
+	for _, c := range encode(s) {
+		total += charWidth(font, c)
+	}
+	return float64(total) * size / 1000
+}
+
+// WrapText splits s into lines no wider than maxWidth, breaking on spaces and
+// keeping explicit line breaks. A single word wider than maxWidth is left on
+// its own line.
+func WrapText(font Font, size float64, s string, maxWidth float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		line := words[0]
+		for _, word := range words[1:] {
+			if TextWidth(font, size, line+" "+word) > maxWidth {
+				lines = append(lines, line)
+				line = word
+			} else {
+				line += " " + word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Truncate shortens s with an ellipsis so that it fits in maxWidth.
+func Truncate(font Font, size float64, s string, maxWidth float64) string {
+	if TextWidth(font, size, s) <= maxWidth {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && TextWidth(font, size, string(runes)+"…") > maxWidth {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
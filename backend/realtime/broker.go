@@ -0,0 +1,136 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// subscriberBuffer is how many events a slow subscriber may fall behind
+// before further events are dropped for it.
+const subscriberBuffer = 16
+
+// channelPrefix namespaces the Redis channels used to fan events out between
+// backend instances.
+const channelPrefix = "realtime:"
+
+type Event struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Broker fans events out to the subscribers of a topic. With a Redis client,
+// events go through Redis pub/sub so subscribers connected to any backend
+// instance receive them; without one, they only reach this process.
+type Broker struct {
+	client      *redis.Client
+	mu          sync.Mutex
+	subscribers map[string]map[chan Event]struct{}
+}
+
+// NewBroker accepts the client returned by cache.NewClient, which is nil when
+// Redis is not available.
+func NewBroker(client *redis.Client) *Broker {
+	b := &Broker{client: client, subscribers: make(map[string]map[chan Event]struct{})}
+	if client != nil {
+		go b.listen(context.Background())
+	}
+	return b
+}
+
+func LiveTopic(setlistID int) string {
+	return fmt.Sprintf("setlist:%d:live", setlistID)
+}
+
+func SetlistTopic(setlistID int) string {
+	return fmt.Sprintf("setlist:%d:events", setlistID)
+}
+
+// NewEvent encodes payload as the body of an event of the given type.
+func NewEvent(eventType string, payload any) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{Type: eventType, Payload: data}, nil
+}
+
+// Subscribe returns the events published on topic from now on, and a function
+// that must be called once the caller stops reading.
+func (b *Broker) Subscribe(topic string) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = make(map[chan Event]struct{})
+	}
+	b.subscribers[topic][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers[topic], ch)
+			if len(b.subscribers[topic]) == 0 {
+				delete(b.subscribers, topic)
+			}
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, unsubscribe
+}
+
+// Publish never blocks: a subscriber whose buffer is full misses the event.
+// A nil broker drops everything, so services work without realtime updates.
+// When Redis cannot be reached the event is still delivered locally.
+func (b *Broker) Publish(topic string, event Event) {
+	if b == nil {
+		return
+	}
+	if b.client != nil {
+		data, err := json.Marshal(event)
+		if err == nil {
+			err = b.client.Publish(context.Background(), channelPrefix+topic, data).Err()
+		}
+		if err == nil {
+			return
+		}
+		log.Printf("[realtime] Publication Redis impossible sur %s, diffusion locale : %v", topic, err)
+	}
+	b.deliver(topic, event)
+}
+
+// listen relays the events published on Redis, including this instance's
+// own, to the local subscribers. The subscription reconnects on its own.
+func (b *Broker) listen(ctx context.Context) {
+	pubsub := b.client.PSubscribe(ctx, channelPrefix+"*")
+	defer pubsub.Close()
+
+	for msg := range pubsub.Channel() {
+		var event Event
+		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+			log.Printf("[realtime] Événement Redis invalide sur %s : %v", msg.Channel, err)
+			continue
+		}
+		b.deliver(strings.TrimPrefix(msg.Channel, channelPrefix), event)
+	}
+}
+
+func (b *Broker) deliver(topic string, event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers[topic] {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("[realtime] Abonné en retard sur %s, événement %q ignoré", topic, event.Type)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package realtime
+
+import (
+	"testing"
+)
+
+func TestBroker_PublishReachesTopicSubscribers(t *testing.T) {
+	b := NewBroker(nil)
+	events, unsubscribe := b.Subscribe(LiveTopic(1))
+	defer unsubscribe()
+	other, unsubscribeOther := b.Subscribe(LiveTopic(2))
+	defer unsubscribeOther()
+
+	b.Publish(LiveTopic(1), Event{Type: "live_state"})
+
+	select {
+	case event := <-events:
+		if event.Type != "live_state" {
+			t.Errorf("got event %q, want live_state", event.Type)
+		}
+	default:
+		t.Fatal("expected an event on the subscribed topic")
+	}
+	select {
+	case event := <-other:
+		t.Errorf("unexpected event %q on another topic", event.Type)
+	default:
+	}
+}
+
+func TestBroker_SlowSubscriberDoesNotBlock(t *testing.T) {
+	b := NewBroker(nil)
+	_, unsubscribe := b.Subscribe(LiveTopic(1))
+	defer unsubscribe()
+
+	for i := 0; i < subscriberBuffer*2; i++ {
+		b.Publish(LiveTopic(1), Event{Type: "live_state"})
+	}
+}
+
+func TestBroker_UnsubscribeClosesChannel(t *testing.T) {
+	b := NewBroker(nil)
+	events, unsubscribe := b.Subscribe(LiveTopic(1))
+	unsubscribe()
+	unsubscribe()
+
+	if _, ok := <-events; ok {
+		t.Error("expected the channel to be closed")
+	}
+	b.Publish(LiveTopic(1), Event{Type: "live_state"})
+}
+
+func TestBroker_NilPublishIsNoop(t *testing.T) {
+	var b *Broker
+	b.Publish(LiveTopic(1), Event{Type: "live_state"})
+}
@@ -9,9 +9,16 @@ export const load: PageLoad = async ({ fetch }) => {
     };
 
     const fetchSetlists = async () => {
-        const res = await fetch('/api/setlist');
-        if (!res.ok) throw error(res.status, 'Failed to fetch setlists');
-        return res.json();
+        const setlists = [];
+        let url: string | null = '/api/setlist';
+        while (url) {
+            const res = await fetch(url);
+            if (!res.ok) throw error(res.status, 'Failed to fetch setlists');
+            setlists.push(...(await res.json()));
+            const cursor = res.headers.get('X-Next-Cursor');
+            url = cursor ? `/api/setlist?cursor=${encodeURIComponent(cursor)}` : null;
+        }
+        return setlists;
     };
 
     try {